@@ -81,7 +81,7 @@ func init() {
 				}
 				// Add one-off job
 				if input.RunImmediately {
-					instances = slices.Insert[[]runtime.Object, runtime.Object](instances, 1, &batchv1.CronJob{})
+					instances = slices.Insert[[]runtime.Object, runtime.Object](instances, 1, &batchv1.Job{})
 				}
 
 				// Append role bindings
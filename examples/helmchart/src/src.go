@@ -13,15 +13,17 @@ import (
 )
 
 type ChartInput struct {
-	CertbotInput   certbot.Input
-	CertbotEnabled bool
+	CertbotInput certbot.Input
+	// NOTE: Boolean toggles should be pointers, so that an explicit `false`
+	// is not overwritten by the default `true` in `ApplyDefaults`.
+	CertbotEnabled *bool
 	KuardInput     kuard.Input
 	IngressInput   ingress.Input
 }
 
 func ChartDefaults() ChartInput {
 	return ChartInput{
-		CertbotEnabled: true,
+		CertbotEnabled: helpaUtils.PointerOf(true),
 		CertbotInput: certbot.Input{
 			RunImmediately:      false,
 			CertbotNamespace:    "certbot",
@@ -76,7 +78,7 @@ func RenderTemplates(input ChartInput, outdir string) (err error) {
 	}
 
 	var certbotSpecs []runtime.Object
-	if inputCopy.CertbotEnabled {
+	if inputCopy.CertbotEnabled != nil && *inputCopy.CertbotEnabled {
 		certbotSpecs, _, err = certbot.Component.Render(inputCopy.CertbotInput)
 		if err != nil {
 			return err
@@ -0,0 +1,55 @@
+// Package apply closes the loop between rendering resources with Helpa and
+// deploying them to a live cluster.
+//
+// It lives in its own package so that the controller-runtime dependency is
+// pulled in only by those who actually import it.
+package apply
+
+import (
+	"context"
+
+	eris "github.com/rotisserie/eris"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Name under which Helpa registers as the manager of the applied fields.
+// See https://kubernetes.io/docs/reference/using-api/server-side-apply/#managers
+const FieldManager = "helpa"
+
+var (
+	ErrNotClientObject = eris.New("resource does not implement client.Object")
+)
+
+// Server-side-apply each of the given resources to the cluster, in the order
+// they were given.
+//
+// Conflicts with other field managers are resolved in Helpa's favour, so that
+// the cluster state always matches the rendered resources.
+//
+// NOTE: The resources are expected to have their `apiVersion` and `kind` set,
+// which is the case for resources rendered from templates.
+func ApplyToCluster(resources []runtime.Object, c client.Client) error {
+	ctx := context.Background()
+
+	for index, resource := range resources {
+		obj, ok := resource.(client.Object)
+		if !ok {
+			return eris.Wrapf(ErrNotClientObject, "failed to apply resource at index %v", index)
+		}
+
+		// Server-side apply refuses objects that carry server-populated metadata,
+		// so we send a sanitized copy and leave the user's object untouched.
+		obj = obj.DeepCopyObject().(client.Object)
+		obj.SetResourceVersion("")
+		obj.SetManagedFields(nil)
+
+		err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+		if err != nil {
+			gvk := obj.GetObjectKind().GroupVersionKind()
+			return eris.Wrapf(err, "failed to apply %s %q at index %v", gvk.Kind, obj.GetName(), index)
+		}
+	}
+
+	return nil
+}
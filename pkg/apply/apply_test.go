@@ -0,0 +1,82 @@
+package apply
+
+import (
+	"context"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	types "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	client "sigs.k8s.io/controller-runtime/pkg/client"
+	fake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	interceptor "sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// The fake client does not support server-side apply, so we emulate it
+// by creating the object if it does not exist yet, or updating it otherwise.
+func newFakeApplyClient(applied *[]string) client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(clientgoscheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() != types.ApplyPatchType {
+					return c.Patch(ctx, obj, patch, opts...)
+				}
+				*applied = append(*applied, obj.GetName())
+
+				err := c.Create(ctx, obj)
+				if apierrors.IsAlreadyExists(err) {
+					return c.Update(ctx, obj)
+				}
+				return err
+			},
+		}).
+		Build()
+}
+
+func TestApplyToCluster(t *testing.T) {
+	assert := assert.New(t)
+
+	applied := []string{}
+	c := newFakeApplyClient(&applied)
+
+	resources := []runtime.Object{
+		&appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default"},
+		},
+		&corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default"},
+		},
+	}
+
+	err := ApplyToCluster(resources, c)
+	assert.Nil(err)
+	assert.Equal([]string{"kuard", "kuard"}, applied)
+
+	deploy := appsv1.Deployment{}
+	err = c.Get(context.Background(), client.ObjectKey{Name: "kuard", Namespace: "default"}, &deploy)
+	assert.Nil(err)
+
+	svc := corev1.Service{}
+	err = c.Get(context.Background(), client.ObjectKey{Name: "kuard", Namespace: "default"}, &svc)
+	assert.Nil(err)
+}
+
+func TestApplyToClusterNotClientObject(t *testing.T) {
+	assert := assert.New(t)
+
+	applied := []string{}
+	c := newFakeApplyClient(&applied)
+
+	err := ApplyToCluster([]runtime.Object{&metav1.Status{}}, c)
+	assert.NotNil(err)
+	assert.ErrorIs(err, ErrNotClientObject)
+	assert.Empty(applied)
+}
@@ -0,0 +1,293 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	serializers "github.com/jurooravec/helpa/pkg/serializers"
+	difflib "github.com/pmezard/go-difflib/difflib"
+	eris "github.com/rotisserie/eris"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	discovery "k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached/memory"
+	dynamic "k8s.io/client-go/dynamic"
+	rest "k8s.io/client-go/rest"
+	restmapper "k8s.io/client-go/restmapper"
+	client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrMissingKind = eris.New("resource has no apiVersion or kind")
+)
+
+// What applying the resource would do to the cluster
+type ChangeType string
+
+const (
+	ChangeCreate ChangeType = "create"
+	ChangeUpdate ChangeType = "update"
+	ChangeNone   ChangeType = "none"
+)
+
+// Difference between a rendered resource and its live version in the cluster
+type ResourceDiff struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+	Change           ChangeType
+	// Unified diff from the live to the rendered resource, both serialized the
+	// same way as by `serializers.HelmChartSerializer`. Empty if there's no change.
+	Diff string
+}
+
+// Compare the given resources against their live versions in the cluster,
+// similar to `kubectl diff`. Use it to review the changes before applying them
+// with `ApplyToCluster`.
+//
+// Resources whose kind is not known to the cluster (e.g. CRDs that are not
+// installed yet) are reported as `ChangeCreate`.
+//
+// NOTE: The resources are expected to have their `apiVersion` and `kind` set,
+// which is the case for resources rendered from templates.
+func Diff(ctx context.Context, cfg *rest.Config, objs []runtime.Object) ([]ResourceDiff, error) {
+	dynClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create dynamic client")
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create discovery client")
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	return DiffWithClient(ctx, dynClient, mapper, objs)
+}
+
+// Same as `Diff`, but with the given clients. Useful for tests, or to reuse
+// the clients across calls.
+func DiffWithClient(ctx context.Context, dynClient dynamic.Interface, mapper meta.RESTMapper, objs []runtime.Object) ([]ResourceDiff, error) {
+	diffs := []ResourceDiff{}
+	for index, obj := range objs {
+		diff, err := diffResource(ctx, dynamicGetter{client: dynClient}, mapper, obj)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to diff resource at index %v", index)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// Same as `Diff`, but with a controller-runtime client, e.g. the one passed
+// to `ApplyToCluster`, or a fake client in tests.
+func DiffAgainstCluster(resources []runtime.Object, c client.Client) ([]ResourceDiff, error) {
+	ctx := context.Background()
+
+	diffs := []ResourceDiff{}
+	for index, resource := range resources {
+		diff, err := diffResource(ctx, clientGetter{client: c}, c.RESTMapper(), resource)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to diff resource at index %v", index)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// Fetch the live version of a resource from the cluster, see `dynamicGetter`
+// and `clientGetter`. Namespace is empty for cluster-scoped resources.
+type liveGetter interface {
+	getLive(ctx context.Context, mapping *meta.RESTMapping, namespace string, name string) (*unstructured.Unstructured, error)
+}
+
+type dynamicGetter struct {
+	client dynamic.Interface
+}
+
+func (g dynamicGetter) getLive(ctx context.Context, mapping *meta.RESTMapping, namespace string, name string) (*unstructured.Unstructured, error) {
+	if namespace == "" {
+		return g.client.Resource(mapping.Resource).Get(ctx, name, metav1.GetOptions{})
+	}
+	return g.client.Resource(mapping.Resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+type clientGetter struct {
+	client client.Client
+}
+
+func (g clientGetter) getLive(ctx context.Context, mapping *meta.RESTMapping, namespace string, name string) (*unstructured.Unstructured, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(mapping.GroupVersionKind)
+	err := g.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, live)
+	if err != nil {
+		return nil, err
+	}
+	return live, nil
+}
+
+func diffResource(ctx context.Context, getter liveGetter, mapper meta.RESTMapper, obj runtime.Object) (ResourceDiff, error) {
+	result, desired, desiredContent, err := prepareDesired(obj)
+	if err != nil {
+		return ResourceDiff{}, err
+	}
+	gvk := result.GroupVersionKind
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The cluster doesn't know the kind yet, e.g. CRD is applied together with its resources
+		return withDiff(result, ChangeCreate, "", desiredContent)
+	} else if err != nil {
+		return ResourceDiff{}, eris.Wrapf(err, "failed to find resource for %s", gvk)
+	}
+
+	namespace := ""
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		// NOTE: Same as kubectl, resources without namespace go to the default one
+		namespace = desired.GetNamespace()
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+	}
+
+	live, err := getter.getLive(ctx, mapping, namespace, desired.GetName())
+	if apierrors.IsNotFound(err) {
+		return withDiff(result, ChangeCreate, "", desiredContent)
+	} else if err != nil {
+		return ResourceDiff{}, eris.Wrapf(err, "failed to get %s %q", gvk.Kind, desired.GetName())
+	}
+
+	return compareToLive(result, live, desired, desiredContent)
+}
+
+// Prepare the rendered resource for the comparison with its live version
+func prepareDesired(obj runtime.Object) (ResourceDiff, *unstructured.Unstructured, string, error) {
+	desired, err := toUnstructured(obj)
+	if err != nil {
+		return ResourceDiff{}, nil, "", err
+	}
+
+	gvk := desired.GroupVersionKind()
+	if gvk.Kind == "" || gvk.Version == "" {
+		return ResourceDiff{}, nil, "", ErrMissingKind
+	}
+
+	// NOTE: Typed resources come with empty `status: {}`, which would show up in every diff
+	stripServerFields(desired)
+
+	result := ResourceDiff{
+		GroupVersionKind: gvk,
+		Namespace:        desired.GetNamespace(),
+		Name:             desired.GetName(),
+	}
+
+	desiredContent, err := serializers.MarshalK8sResource(desired)
+	if err != nil {
+		return ResourceDiff{}, nil, "", err
+	}
+	return result, desired, desiredContent, nil
+}
+
+func compareToLive(result ResourceDiff, live *unstructured.Unstructured, desired *unstructured.Unstructured, desiredContent string) (ResourceDiff, error) {
+	// Ignore the fields that were populated by the server (status, defaults, ...),
+	// so that we compare only the fields that we manage.
+	stripServerFields(live)
+	live.Object = pruneToDesired(live.Object, desired.Object).(map[string]any)
+
+	liveContent, err := serializers.MarshalK8sResource(live)
+	if err != nil {
+		return ResourceDiff{}, err
+	}
+
+	if liveContent == desiredContent {
+		result.Change = ChangeNone
+		return result, nil
+	}
+	return withDiff(result, ChangeUpdate, liveContent, desiredContent)
+}
+
+func withDiff(result ResourceDiff, change ChangeType, from string, to string) (ResourceDiff, error) {
+	name := fmt.Sprintf("%s/%s", result.GroupVersionKind.Kind, result.Name)
+	if result.Namespace != "" {
+		name = fmt.Sprintf("%s/%s/%s", result.GroupVersionKind.Kind, result.Namespace, result.Name)
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "live/" + name,
+		ToFile:   "rendered/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return ResourceDiff{}, eris.Wrap(err, "failed to compute diff")
+	}
+
+	result.Change = change
+	result.Diff = diff
+	return result, nil
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy(), nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to convert resource to unstructured")
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+// Remove the metadata that's set by the server, and so is never in the rendered resources
+func stripServerFields(obj *unstructured.Unstructured) {
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetSelfLink("")
+	obj.SetManagedFields(nil)
+	obj.SetCreationTimestamp(metav1.Time{})
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	obj.SetAnnotations(annotations)
+}
+
+// Keep only those fields of the live object that are also in the desired object.
+// This drops the fields defaulted by the server, e.g. `spec.revisionHistoryLimit`.
+//
+// NOTE: As a consequence, fields that are in the cluster but were removed from
+// the rendered resource are not reported.
+func pruneToDesired(live any, desired any) any {
+	switch desiredVal := desired.(type) {
+	case map[string]any:
+		liveMap, ok := live.(map[string]any)
+		if !ok {
+			return live
+		}
+		pruned := map[string]any{}
+		for key, value := range liveMap {
+			if desiredField, ok := desiredVal[key]; ok {
+				pruned[key] = pruneToDesired(value, desiredField)
+			}
+		}
+		return pruned
+	case []any:
+		// NOTE: Items are matched by position. If the lengths differ, the list changed anyway.
+		liveList, ok := live.([]any)
+		if !ok || len(liveList) != len(desiredVal) {
+			return live
+		}
+		pruned := make([]any, len(liveList))
+		for index := range liveList {
+			pruned[index] = pruneToDesired(liveList[index], desiredVal[index])
+		}
+		return pruned
+	}
+	return live
+}
@@ -0,0 +1,169 @@
+package apply
+
+import (
+	"context"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newDiffMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func TestDiff(t *testing.T) {
+	assert := assert.New(t)
+
+	replicas := int32(2)
+	liveDeploy := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "kuard",
+			Namespace:       "default",
+			ResourceVersion: "123",
+			UID:             "abc",
+			Generation:      3,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// Defaulted by the server
+			RevisionHistoryLimit: &replicas,
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: 2},
+	}
+	liveConfigMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default", ResourceVersion: "456"},
+		Data:       map[string]string{"key": "old"},
+	}
+	dynClient := dynamicfake.NewSimpleDynamicClient(clientgoscheme.Scheme, liveDeploy, liveConfigMap)
+
+	sameReplicas := int32(2)
+	newWidget := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"name": "kuard"},
+	}}
+	objs := []runtime.Object{
+		// No change
+		&appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &sameReplicas},
+		},
+		// Update
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default"},
+			Data:       map[string]string{"key": "new"},
+		},
+		// Create, not in cluster
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		},
+		// Create, kind unknown to the cluster
+		newWidget,
+	}
+
+	diffs, err := DiffWithClient(context.Background(), dynClient, newDiffMapper(), objs)
+	assert.Nil(err)
+	assert.Len(diffs, 4)
+
+	assert.Equal(ChangeNone, diffs[0].Change)
+	assert.Equal("Deployment", diffs[0].GroupVersionKind.Kind)
+	assert.Equal("", diffs[0].Diff)
+
+	assert.Equal(ChangeUpdate, diffs[1].Change)
+	assert.Equal("default", diffs[1].Namespace)
+	assert.Equal("kuard", diffs[1].Name)
+	assert.Contains(diffs[1].Diff, "--- live/ConfigMap/default/kuard")
+	assert.Contains(diffs[1].Diff, "+++ rendered/ConfigMap/default/kuard")
+	assert.Contains(diffs[1].Diff, "-  key: old")
+	assert.Contains(diffs[1].Diff, "+  key: new")
+	assert.NotContains(diffs[1].Diff, "resourceVersion")
+
+	assert.Equal(ChangeCreate, diffs[2].Change)
+	assert.Contains(diffs[2].Diff, "+  name: other")
+
+	assert.Equal(ChangeCreate, diffs[3].Change)
+	assert.Equal("example.com", diffs[3].GroupVersionKind.Group)
+	assert.Contains(diffs[3].Diff, "+kind: Widget")
+}
+
+func TestDiffMissingKind(t *testing.T) {
+	assert := assert.New(t)
+
+	dynClient := dynamicfake.NewSimpleDynamicClient(clientgoscheme.Scheme)
+	objs := []runtime.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kuard"}}}
+
+	_, err := DiffWithClient(context.Background(), dynClient, newDiffMapper(), objs)
+	assert.ErrorIs(err, ErrMissingKind)
+}
+
+func TestDiffAgainstCluster(t *testing.T) {
+	assert := assert.New(t)
+
+	liveConfigMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default"},
+		Data:       map[string]string{"key": "old", "same": "value"},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(clientgoscheme.Scheme).
+		WithRESTMapper(newDiffMapper()).
+		WithObjects(liveConfigMap).
+		Build()
+
+	objs := []runtime.Object{
+		// Update, namespace defaults to `default`
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard"},
+			Data:       map[string]string{"key": "new", "same": "value"},
+		},
+		// Create, not in cluster
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		},
+		// Create, kind unknown to the cluster
+		&unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]any{"name": "kuard"},
+		}},
+	}
+
+	diffs, err := DiffAgainstCluster(objs, c)
+	assert.Nil(err)
+	assert.Len(diffs, 3)
+
+	assert.Equal(ChangeUpdate, diffs[0].Change)
+	assert.Contains(diffs[0].Diff, "-  key: old")
+	assert.Contains(diffs[0].Diff, "+  key: new")
+	assert.NotContains(diffs[0].Diff, "resourceVersion")
+	assert.Equal(ChangeCreate, diffs[1].Change)
+	assert.Equal(ChangeCreate, diffs[2].Change)
+
+	// Same resource as in the cluster
+	diffs, err = DiffAgainstCluster([]runtime.Object{liveConfigMap}, c)
+	assert.Nil(err)
+	assert.Equal(ChangeNone, diffs[0].Change)
+
+	_, err = DiffAgainstCluster([]runtime.Object{&corev1.ConfigMap{}}, c)
+	assert.ErrorIs(err, ErrMissingKind)
+}
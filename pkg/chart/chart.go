@@ -0,0 +1,321 @@
+// Package chart renders several components together, e.g. all components of a Helm chart.
+//
+// Components are rendered concurrently, except those that depend on the outputs
+// of others, e.g. a Deployment with the checksum of its ConfigMap:
+//
+//	result, err := chart.New(chart.Options{}).
+//		Add("config", configComp, configInput).
+//		Add("deploy", deployComp, chart.InputFunc(func(deps chart.Results) (any, error) {
+//			return DeployInput{ConfigHash: hash(deps["config"].Contents)}, nil
+//		}), chart.DependsOn("config")).
+//		Render()
+package chart
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jurooravec/helpa/pkg/component"
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrDuplicateComponent = eris.New("component with the same name was already added")
+	ErrInvalidComponent   = eris.New("component must be `component.Component` or `component.ComponentMulti`")
+	ErrInvalidInput       = eris.New("input cannot be passed to the component")
+	ErrUnknownDependency  = eris.New("component depends on a component that was not added")
+	ErrDependencyCycle    = eris.New("components depend on each other in a cycle")
+	ErrDependencyFailed   = eris.New("component was not rendered, because its dependency failed")
+)
+
+type Options struct {
+	// Max number of components rendered at the same time.
+	//
+	// Default: `runtime.GOMAXPROCS(0)`
+	Workers int
+}
+
+// Input computed from the outputs of the dependencies, see `DependsOn`
+type InputFunc func(deps Results) (any, error)
+
+// Outputs of the components, by their names
+type Results map[string]Output
+
+// Rendered component
+type Output struct {
+	Name string
+	// `TType` for `component.Component`, `[]TType` for `component.ComponentMulti`
+	Instance any
+	// Rendered documents. `component.Component` has only one.
+	Contents []string
+}
+
+type Result struct {
+	// Outputs of the rendered components, in the order they were added
+	Outputs []Output
+}
+
+// Output of the component with given name
+func (r Result) Get(name string) (Output, bool) {
+	for _, output := range r.Outputs {
+		if output.Name == name {
+			return output, true
+		}
+	}
+	return Output{}, false
+}
+
+type AddOption func(e *entry)
+
+// Render the component only after the given components. Their outputs are
+// passed to the component's input, if it's `InputFunc`.
+func DependsOn(names ...string) AddOption {
+	return func(e *entry) {
+		e.deps = append(e.deps, names...)
+	}
+}
+
+type entry struct {
+	name  string
+	comp  any
+	input any
+	deps  []string
+}
+
+// Collects the components, and renders them with `Render`. See the package docs.
+type Builder struct {
+	options Options
+	entries []*entry
+	// First error from `Add`, returned from `Render`
+	err error
+}
+
+func New(options Options) *Builder {
+	return &Builder{options: options}
+}
+
+// Add the component, to be rendered with the input. Component must be
+// `component.Component` or `component.ComponentMulti`, or a pointer to either.
+// Input may also be `InputFunc`, to compute it from the outputs of the dependencies.
+//
+// NOTE: Errors are returned from `Render`, so the calls may be chained.
+func (b *Builder) Add(name string, comp any, input any, opts ...AddOption) *Builder {
+	e := &entry{name: name, comp: comp, input: input}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if b.err == nil {
+		for _, other := range b.entries {
+			if other.name == name {
+				b.err = eris.Wrapf(ErrDuplicateComponent, "%q", name)
+			}
+		}
+	}
+	if b.err == nil {
+		if _, err := renderFunc(comp); err != nil {
+			b.err = eris.Wrapf(err, "in %q", name)
+		}
+	}
+
+	b.entries = append(b.entries, e)
+	return b
+}
+
+// Render all components, with at most `Options.Workers` at the same time,
+// each after the components it depends on.
+//
+// Components whose dependencies failed are not rendered, and fail with
+// `ErrDependencyFailed`. Errors of all components are returned together as
+// `component.Errors`, in the order the components were added, so they don't
+// depend on which components happened to render first.
+//
+// NOTE: Dependencies are checked before anything is rendered, so cycles
+// fail with `ErrDependencyCycle` right away.
+func (b *Builder) Render() (Result, error) {
+	if b.err != nil {
+		return Result{}, b.err
+	}
+	if err := b.checkDependencies(); err != nil {
+		return Result{}, err
+	}
+
+	workers := b.options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	sem := make(chan struct{}, workers)
+
+	type state struct {
+		done   chan struct{}
+		output Output
+		err    error
+	}
+	states := map[string]*state{}
+	for _, e := range b.entries {
+		states[e.name] = &state{done: make(chan struct{})}
+	}
+
+	// NOTE: Each component waits for its dependencies in its own goroutine,
+	// but only `workers` of them render at the same time.
+	var wg sync.WaitGroup
+	for _, e := range b.entries {
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			st := states[e.name]
+			defer close(st.done)
+
+			deps := Results{}
+			for _, dep := range e.deps {
+				depState := states[dep]
+				<-depState.done
+				if depState.err != nil {
+					st.err = eris.Wrapf(ErrDependencyFailed, "dependency %q failed", dep)
+					return
+				}
+				deps[dep] = depState.output
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			st.output, st.err = renderEntry(e, deps)
+		}(e)
+	}
+	wg.Wait()
+
+	result := Result{Outputs: []Output{}}
+	var errs component.Errors
+	for _, e := range b.entries {
+		st := states[e.name]
+		if st.err != nil {
+			component.AppendError(&errs, e.name, st.err)
+			continue
+		}
+		result.Outputs = append(result.Outputs, st.output)
+	}
+	return result, errs.ErrorOrNil()
+}
+
+// Check that all dependencies were added, and that there are no cycles.
+// Components are visited in the order they were added, so the reported
+// cycle is always the same.
+func (b *Builder) checkDependencies() error {
+	entries := map[string]*entry{}
+	for _, e := range b.entries {
+		entries[e.name] = e
+	}
+	for _, e := range b.entries {
+		for _, dep := range e.deps {
+			if _, ok := entries[dep]; !ok {
+				return eris.Wrapf(ErrUnknownDependency, "%q depends on %q", e.name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	status := map[string]int{}
+	path := []string{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch status[name] {
+		case visited:
+			return nil
+		case visiting:
+			// Cycle is the part of the path from where the name is
+			start := slices.Index(path, name)
+			cycle := append(append([]string{}, path[start:]...), name)
+			return eris.Wrapf(ErrDependencyCycle, "%s", strings.Join(cycle, " -> "))
+		}
+
+		status[name] = visiting
+		path = append(path, name)
+		for _, dep := range entries[name].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		status[name] = visited
+		return nil
+	}
+
+	for _, e := range b.entries {
+		if err := visit(e.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderEntry(e *entry, deps Results) (Output, error) {
+	output := Output{Name: e.name}
+
+	input := e.input
+	if fn, ok := input.(func(Results) (any, error)); ok {
+		input = InputFunc(fn)
+	}
+	if fn, ok := input.(InputFunc); ok {
+		var err error
+		input, err = fn(deps)
+		if err != nil {
+			return output, eris.Wrap(err, "failed to compute input")
+		}
+	}
+
+	render, _ := renderFunc(e.comp)
+	inputType := render.Type().In(0)
+	inputVal := reflect.Zero(inputType)
+	if input != nil {
+		inputVal = reflect.ValueOf(input)
+		if !inputVal.Type().AssignableTo(inputType) {
+			return output, eris.Wrapf(ErrInvalidInput, "expected %s, got %T", inputType, input)
+		}
+	}
+
+	out := render.Call([]reflect.Value{inputVal})
+	if err, _ := out[2].Interface().(error); err != nil {
+		return output, err
+	}
+
+	output.Instance = out[0].Interface()
+	switch contents := out[1].Interface().(type) {
+	case string:
+		output.Contents = []string{contents}
+	case []string:
+		output.Contents = contents
+	}
+	return output, nil
+}
+
+// Get the `Render` function of `component.Component` or `component.ComponentMulti`
+//
+// NOTE: The components are generic, so we access their `Render` with reflection.
+func renderFunc(comp any) (reflect.Value, error) {
+	compVal := reflect.ValueOf(comp)
+	for compVal.Kind() == reflect.Pointer && !compVal.IsNil() {
+		compVal = compVal.Elem()
+	}
+	if compVal.Kind() != reflect.Struct {
+		return reflect.Value{}, eris.Wrapf(ErrInvalidComponent, "got %T", comp)
+	}
+
+	render := compVal.FieldByName("Render")
+	if !render.IsValid() || render.Kind() != reflect.Func || render.IsNil() {
+		return reflect.Value{}, eris.Wrapf(ErrInvalidComponent, "got %T", comp)
+	}
+	renderType := render.Type()
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	if renderType.NumIn() != 1 || renderType.NumOut() != 3 || renderType.Out(2) != errorType {
+		return reflect.Value{}, eris.Wrapf(ErrInvalidComponent, "got %T with `Render` of type %s", comp, renderType)
+	}
+	return render, nil
+}
@@ -0,0 +1,150 @@
+package chart
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jurooravec/helpa/pkg/component"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type testInput struct {
+	Name string
+	Data string
+}
+
+// Component that renders a ConfigMap, and tracks how many of the components
+// render at the same time
+func newTestComponent(t *testing.T, running *int32, maxRunning *int32) component.Component[corev1.ConfigMap, testInput] {
+	comp, err := component.CreateComponent(
+		component.Def[corev1.ConfigMap, testInput, testInput]{
+			Name:     "ConfigMap",
+			Template: "kind: ConfigMap\nmetadata:\n  name: {{ .Helpa.Name }}\ndata:\n  data: {{ .Helpa.Data | quote }}\n",
+			Setup: func(input testInput) (testInput, error) {
+				current := atomic.AddInt32(running, 1)
+				defer atomic.AddInt32(running, -1)
+				for {
+					prev := atomic.LoadInt32(maxRunning)
+					if current <= prev || atomic.CompareAndSwapInt32(maxRunning, prev, current) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				if input.Name == "" {
+					return input, errors.New("name must be set")
+				}
+				return input, nil
+			},
+		},
+	)
+	assert.Nil(t, err)
+	return comp
+}
+
+func TestChartDiamond(t *testing.T) {
+	assert := assert.New(t)
+
+	var running, maxRunning int32
+	comp := newTestComponent(t, &running, &maxRunning)
+
+	// `config` -> `left` and `right` -> `deploy`
+	fromDeps := func(name string) InputFunc {
+		return func(deps Results) (any, error) {
+			names := []string{}
+			for _, dep := range []string{"config", "left", "right"} {
+				if output, ok := deps[dep]; ok {
+					names = append(names, output.Instance.(corev1.ConfigMap).Name)
+				}
+			}
+			return testInput{Name: name, Data: strings.Join(names, ",")}, nil
+		}
+	}
+
+	result, err := New(Options{Workers: 2}).
+		Add("deploy", comp, fromDeps("deploy"), DependsOn("left", "right")).
+		Add("left", comp, fromDeps("left"), DependsOn("config")).
+		Add("right", &comp, fromDeps("right"), DependsOn("config")).
+		Add("config", comp, testInput{Name: "config"}).
+		Add("other", comp, testInput{Name: "other"}).
+		Render()
+	assert.Nil(err)
+
+	// Outputs are in the order the components were added
+	names := []string{}
+	for _, output := range result.Outputs {
+		names = append(names, output.Name)
+	}
+	assert.Equal([]string{"deploy", "left", "right", "config", "other"}, names)
+
+	deploy, ok := result.Get("deploy")
+	assert.True(ok)
+	assert.Equal("left,right", deploy.Instance.(corev1.ConfigMap).Data["data"])
+	assert.Len(deploy.Contents, 1)
+	left, _ := result.Get("left")
+	assert.Equal("config", left.Instance.(corev1.ConfigMap).Data["data"])
+
+	// `left`, `right` and `other` may render together, but there are only 2 workers
+	assert.LessOrEqual(maxRunning, int32(2))
+}
+
+func TestChartCycle(t *testing.T) {
+	assert := assert.New(t)
+
+	var running, maxRunning int32
+	comp := newTestComponent(t, &running, &maxRunning)
+
+	_, err := New(Options{}).
+		Add("config", comp, testInput{Name: "config"}).
+		Add("a", comp, testInput{Name: "a"}, DependsOn("config", "b")).
+		Add("b", comp, testInput{Name: "b"}, DependsOn("c")).
+		Add("c", comp, testInput{Name: "c"}, DependsOn("a")).
+		Render()
+	assert.ErrorIs(err, ErrDependencyCycle)
+	assert.Contains(err.Error(), "a -> b -> c -> a")
+	// Nothing is rendered
+	assert.Equal(int32(0), maxRunning)
+
+	_, err = New(Options{}).Add("a", comp, testInput{}, DependsOn("missing")).Render()
+	assert.ErrorIs(err, ErrUnknownDependency)
+
+	_, err = New(Options{}).Add("a", comp, testInput{}).Add("a", comp, testInput{}).Render()
+	assert.ErrorIs(err, ErrDuplicateComponent)
+
+	_, err = New(Options{}).Add("a", "not a component", testInput{}).Render()
+	assert.ErrorIs(err, ErrInvalidComponent)
+
+	_, err = New(Options{}).Add("a", comp, "wrong input").Render()
+	assert.ErrorIs(err, ErrInvalidInput)
+}
+
+func TestChartErrorsDeterministic(t *testing.T) {
+	assert := assert.New(t)
+
+	var running, maxRunning int32
+	comp := newTestComponent(t, &running, &maxRunning)
+
+	var firstErr string
+	for range 5 {
+		result, err := New(Options{Workers: 4}).
+			Add("b", comp, testInput{}).
+			Add("a", comp, testInput{}).
+			Add("c", comp, testInput{Name: "c"}, DependsOn("a")).
+			Add("d", comp, testInput{Name: "d"}).
+			Render()
+		assert.NotNil(err)
+		assert.ErrorIs(err, ErrDependencyFailed)
+		if firstErr == "" {
+			firstErr = err.Error()
+		}
+		assert.Equal(firstErr, err.Error())
+
+		// Components that don't depend on the failed ones are rendered
+		assert.Len(result.Outputs, 1)
+		assert.Equal("d", result.Outputs[0].Name)
+	}
+	assert.True(strings.HasPrefix(firstErr, "3 errors occurred:\n  - b: "))
+}
@@ -0,0 +1,149 @@
+// Code generated by helpa codegen from values.yaml. DO NOT EDIT.
+
+package fixture
+
+// Values of the Helm chart
+type Values struct {
+	// Number of pods
+	ReplicaCount     int                     `json:"replicaCount"`
+	Image            ValuesImage             `json:"image"`
+	ImagePullSecrets []any                   `json:"imagePullSecrets"` // empty list in values.yaml, item type could not be inferred
+	NameOverride     string                  `json:"nameOverride"`
+	FullnameOverride any                     `json:"fullnameOverride"` // null in values.yaml, type could not be inferred
+	ServiceAccount   ValuesServiceAccount    `json:"serviceAccount"`
+	PodAnnotations   map[string]any          `json:"podAnnotations"`
+	SecurityContext  ValuesSecurityContext   `json:"securityContext"`
+	Service          ValuesService           `json:"service"`
+	Ingress          ValuesIngress           `json:"ingress"`
+	Resources        ValuesResources         `json:"resources"`
+	Autoscaling      ValuesAutoscaling       `json:"autoscaling"`
+	NodeSelector     map[string]any          `json:"nodeSelector"`
+	Tolerations      []ValuesTolerationsItem `json:"tolerations"`
+	ExtraArgs        []any                   `json:"extraArgs"` // list in values.yaml mixes types
+	ExtraEnv         []map[string]any        `json:"extraEnv"`  // list items in values.yaml have different keys or types
+}
+
+type ValuesImage struct {
+	Repository string `json:"repository"`
+	// Overrides the image tag whose default is the chart appVersion.
+	Tag        string `json:"tag"`
+	PullPolicy string `json:"pullPolicy"`
+}
+
+type ValuesServiceAccount struct {
+	Create      bool           `json:"create"`
+	Annotations map[string]any `json:"annotations"`
+	Name        string         `json:"name"`
+}
+
+type ValuesSecurityContext struct {
+	RunAsNonRoot bool `json:"runAsNonRoot"`
+	RunAsUser    int  `json:"runAsUser"`
+}
+
+type ValuesService struct {
+	Type string `json:"type"`
+	Port int    `json:"port"`
+}
+
+type ValuesIngress struct {
+	Enabled   bool                     `json:"enabled"`
+	ClassName string                   `json:"className"`
+	Hosts     []ValuesIngressHostsItem `json:"hosts"`
+	Tls       []any                    `json:"tls"` // empty list in values.yaml, item type could not be inferred
+}
+
+type ValuesIngressHostsItem struct {
+	Host  string                            `json:"host"`
+	Paths []ValuesIngressHostsItemPathsItem `json:"paths"`
+}
+
+type ValuesIngressHostsItemPathsItem struct {
+	Path     string `json:"path"`
+	PathType string `json:"pathType"`
+}
+
+type ValuesResources struct {
+	Limits ValuesResourcesLimits `json:"limits"`
+}
+
+type ValuesResourcesLimits struct {
+	Cpu    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+type ValuesAutoscaling struct {
+	Enabled                        bool    `json:"enabled"`
+	MinReplicas                    int     `json:"minReplicas"`
+	MaxReplicas                    int     `json:"maxReplicas"`
+	TargetCPUUtilizationPercentage float64 `json:"targetCPUUtilizationPercentage"`
+}
+
+type ValuesTolerationsItem struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value,omitempty"`
+}
+
+// Contents of values.yaml
+func Defaults() Values {
+	return Values{
+		ReplicaCount: 1,
+		Image: ValuesImage{
+			Repository: "gcr.io/kuar-demo/kuard-amd64",
+			Tag:        "",
+			PullPolicy: "IfNotPresent",
+		},
+		ImagePullSecrets: []any{},
+		NameOverride:     "",
+		ServiceAccount: ValuesServiceAccount{
+			Create:      true,
+			Annotations: map[string]any{},
+			Name:        "",
+		},
+		PodAnnotations: map[string]any{},
+		SecurityContext: ValuesSecurityContext{
+			RunAsNonRoot: true,
+			RunAsUser:    1000,
+		},
+		Service: ValuesService{
+			Type: "ClusterIP",
+			Port: 80,
+		},
+		Ingress: ValuesIngress{
+			Enabled:   false,
+			ClassName: "",
+			Hosts: []ValuesIngressHostsItem{{
+				Host: "chart-example.local",
+				Paths: []ValuesIngressHostsItemPathsItem{{
+					Path:     "/",
+					PathType: "ImplementationSpecific",
+				}},
+			}},
+			Tls: []any{},
+		},
+		Resources: ValuesResources{
+			Limits: ValuesResourcesLimits{
+				Cpu:    "100m",
+				Memory: "128Mi",
+			},
+		},
+		Autoscaling: ValuesAutoscaling{
+			Enabled:                        false,
+			MinReplicas:                    1,
+			MaxReplicas:                    100,
+			TargetCPUUtilizationPercentage: 80.5,
+		},
+		NodeSelector: map[string]any{},
+		Tolerations: []ValuesTolerationsItem{{
+			Key:      "dedicated",
+			Operator: "Equal",
+			Value:    "kuard",
+		}, {
+			Key:      "gpu",
+			Operator: "Exists",
+		}},
+		ExtraArgs: []any{"--verbose", 3, true},
+		ExtraEnv:  []map[string]any{{"name": "LOG_LEVEL", "value": "debug"}, {"name": "WORKERS", "value": 4}},
+	}
+}
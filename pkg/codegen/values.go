@@ -0,0 +1,593 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	eris "github.com/rotisserie/eris"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidValues     = eris.New("values.yaml must be a YAML mapping")
+	ErrInvalidSchema     = eris.New("invalid values.schema.json")
+	ErrInvalidIdentifier = eris.New("not a valid Go identifier")
+	ErrUnsupportedValue  = eris.New("value cannot be written as Go literal")
+)
+
+// Generate Go source with the `Input` struct of a Helm chart from its values.yaml,
+// e.g. to migrate the chart to Helpa. The output has:
+//   - Struct `typeName` with one field per key, with `json` tags, so it can be
+//     loaded with `utils.LoadValues`.
+//   - Nested structs for nested mappings, named after the path, e.g. `ValuesImage`.
+//   - Function `Defaults()` that returns the contents of values.yaml, to be used
+//     as component's `Defaults`.
+//
+// Comments above the keys in values.yaml are kept as comments of the fields.
+//
+// Types that cannot be inferred, like `null`, empty lists, or lists that mix types,
+// fall back to `any`, with a comment. Use `ValuesToStructWithSchema` to give them
+// types from values.schema.json.
+//
+// To keep the types in sync with the chart, wrap it in a small `main` and call it
+// with `go:generate`.
+func ValuesToStruct(valuesYAML []byte, pkgName, typeName string) ([]byte, error) {
+	return ValuesToStructWithSchema(valuesYAML, nil, pkgName, typeName)
+}
+
+// Same as `ValuesToStruct`, but types that cannot be inferred from the values
+// are taken from the JSON Schema (values.schema.json), if it describes them.
+// Descriptions from the schema are used as comments of the fields that have none.
+//
+// NOTE: Only `type`, `items`, `properties` and `description` are used.
+func ValuesToStructWithSchema(valuesYAML, schemaJSON []byte, pkgName, typeName string) ([]byte, error) {
+	for _, name := range []string{pkgName, typeName} {
+		if !token.IsIdentifier(name) {
+			return nil, eris.Wrapf(ErrInvalidIdentifier, "%q", name)
+		}
+	}
+
+	var root yaml.Node
+	err := yaml.Unmarshal(valuesYAML, &root)
+	if err != nil {
+		return nil, eris.Wrapf(ErrInvalidValues, "%s", err)
+	}
+	values := &yaml.Node{Kind: yaml.MappingNode}
+	if len(root.Content) > 0 {
+		values = resolveAlias(root.Content[0])
+	}
+	// NOTE: Empty values.yaml is `null`
+	if values.Kind == yaml.ScalarNode && values.Tag == "!!null" {
+		values = &yaml.Node{Kind: yaml.MappingNode}
+	}
+	if values.Kind != yaml.MappingNode {
+		return nil, ErrInvalidValues
+	}
+
+	var schema *valuesSchema
+	if len(schemaJSON) > 0 {
+		err = json.Unmarshal(schemaJSON, &schema)
+		if err != nil {
+			return nil, eris.Wrapf(ErrInvalidSchema, "%s", err)
+		}
+	}
+
+	gen := generator{typeNames: map[string]bool{typeName: true}}
+	rootDef, err := gen.structFor(values, schema, typeName)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := gen.structLiteral(values, rootDef)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by helpa codegen from values.yaml. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	for i, def := range gen.structs {
+		if i == 0 {
+			fmt.Fprintf(&buf, "// %s of the Helm chart\n", def.name)
+		}
+		gen.writeStruct(&buf, def)
+	}
+	fmt.Fprintf(&buf, "// Contents of values.yaml\nfunc Defaults() %s {\n\treturn %s\n}\n", typeName, defaults)
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, eris.Wrapf(err, "generated code for %q is not valid Go", typeName)
+	}
+	return out, nil
+}
+
+// Subset of JSON Schema that is used to type the values
+type valuesSchema struct {
+	Type        any                      `json:"type"`
+	Description string                   `json:"description"`
+	Properties  map[string]*valuesSchema `json:"properties"`
+	Items       *valuesSchema            `json:"items"`
+}
+
+func (s *valuesSchema) property(key string) *valuesSchema {
+	if s == nil {
+		return nil
+	}
+	return s.Properties[key]
+}
+
+// Go type described by the schema, or empty string if unknown
+func (s *valuesSchema) goType() string {
+	if s == nil {
+		return ""
+	}
+
+	// NOTE: `type` may be a list, e.g. `["string", "null"]`
+	types := []string{}
+	switch t := s.Type.(type) {
+	case string:
+		types = append(types, t)
+	case []any:
+		for _, item := range t {
+			if str, ok := item.(string); ok && str != "null" {
+				types = append(types, str)
+			}
+		}
+	}
+	if len(types) != 1 {
+		return ""
+	}
+
+	switch types[0] {
+	case "string":
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "object":
+		return "map[string]any"
+	case "array":
+		if item := s.Items.goType(); item != "" {
+			return "[]" + item
+		}
+		return "[]any"
+	}
+	return ""
+}
+
+type structDef struct {
+	name   string
+	fields []fieldDef
+}
+
+type fieldDef struct {
+	name    string
+	key     string
+	goType  string
+	doc     string
+	comment string
+	// Set if the field is a struct or list of structs
+	structDef *structDef
+	// If true, the key is missing in some of the list items
+	optional bool
+}
+
+type generator struct {
+	// Structs in the order in which they are written, root first
+	structs   []*structDef
+	typeNames map[string]bool
+}
+
+func (g *generator) uniqueTypeName(name string) string {
+	unique := name
+	for i := 2; g.typeNames[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.typeNames[unique] = true
+	return unique
+}
+
+func (g *generator) structFor(node *yaml.Node, schema *valuesSchema, name string) (*structDef, error) {
+	def := &structDef{name: name}
+	g.structs = append(g.structs, def)
+
+	fieldNames := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], resolveAlias(node.Content[i+1])
+		key := keyNode.Value
+		propSchema := schema.property(key)
+
+		fieldName := toFieldName(key)
+		uniqueName := fieldName
+		for j := 2; fieldNames[uniqueName]; j++ {
+			uniqueName = fmt.Sprintf("%s%d", fieldName, j)
+		}
+		fieldNames[uniqueName] = true
+
+		field := fieldDef{
+			name: uniqueName,
+			key:  key,
+			doc:  toGoComment(keyNode.HeadComment),
+		}
+		if field.doc == "" && propSchema != nil && propSchema.Description != "" {
+			field.doc = toGoComment(propSchema.Description)
+		}
+
+		var err error
+		field.goType, field.comment, field.structDef, err = g.typeFor(valNode, propSchema, name+uniqueName)
+		if err != nil {
+			return nil, eris.Wrapf(err, "key %q", key)
+		}
+		def.fields = append(def.fields, field)
+	}
+	return def, nil
+}
+
+// Infer Go type of the value. Mappings with keys become structs named `name`.
+func (g *generator) typeFor(node *yaml.Node, schema *valuesSchema, name string) (string, string, *structDef, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			if t := schema.goType(); t != "" {
+				return t, "", nil, nil
+			}
+			return "any", "null in values.yaml, type could not be inferred", nil, nil
+		}
+		return scalarType(node), "", nil, nil
+
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			return "map[string]any", "", nil, nil
+		}
+		def, err := g.structFor(node, schema, g.uniqueTypeName(name))
+		if err != nil {
+			return "", "", nil, err
+		}
+		return def.name, "", def, nil
+
+	case yaml.SequenceNode:
+		var itemSchema *valuesSchema
+		if schema != nil {
+			itemSchema = schema.Items
+		}
+
+		if len(node.Content) == 0 {
+			if t := schema.goType(); strings.HasPrefix(t, "[]") {
+				return t, "", nil, nil
+			}
+			return "[]any", "empty list in values.yaml, item type could not be inferred", nil, nil
+		}
+
+		items := []*yaml.Node{}
+		shapes := map[string]bool{}
+		allMappings := true
+		for _, item := range node.Content {
+			item = resolveAlias(item)
+			items = append(items, item)
+			shapes[shapeOf(item)] = true
+			allMappings = allMappings && item.Kind == yaml.MappingNode
+		}
+
+		// NOTE: Items like `{name, value}` and `{name, valueFrom}` still make a struct,
+		// as long as the keys they share have the same types.
+		if allMappings && len(shapes) > 1 {
+			if merged, optional, ok := mergeMappings(items); ok {
+				def, err := g.structFor(merged, itemSchema, g.uniqueTypeName(name+"Item"))
+				if err != nil {
+					return "", "", nil, err
+				}
+				for i, field := range def.fields {
+					def.fields[i].optional = optional[field.key]
+				}
+				return "[]" + def.name, "", def, nil
+			}
+		}
+
+		if len(shapes) == 1 {
+			// NOTE: A list of only nulls is as good as empty
+			if items[0].Kind == yaml.ScalarNode && items[0].Tag == "!!null" {
+				return "[]any", "list of nulls in values.yaml, item type could not be inferred", nil, nil
+			}
+			itemType, comment, def, err := g.typeFor(items[0], itemSchema, name+"Item")
+			if err != nil {
+				return "", "", nil, err
+			}
+			return "[]" + itemType, comment, def, nil
+		}
+		if allMappings {
+			return "[]map[string]any", "list items in values.yaml have different keys or types", nil, nil
+		}
+		return "[]any", "list in values.yaml mixes types", nil, nil
+	}
+
+	return "any", "", nil, nil
+}
+
+// Merge list items that are mappings into a single mapping with all the keys.
+// Fails if the same key has different types in different items.
+func mergeMappings(items []*yaml.Node) (*yaml.Node, map[string]bool, bool) {
+	merged := &yaml.Node{Kind: yaml.MappingNode}
+	shapes := map[string]string{}
+	counts := map[string]int{}
+	for _, item := range items {
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, val := item.Content[i], item.Content[i+1]
+			shape := shapeOf(val)
+			prevShape, seen := shapes[key.Value]
+			if seen && prevShape != shape {
+				return nil, nil, false
+			}
+			if !seen {
+				shapes[key.Value] = shape
+				merged.Content = append(merged.Content, key, val)
+			}
+			counts[key.Value]++
+		}
+	}
+
+	optional := map[string]bool{}
+	for key, count := range counts {
+		optional[key] = count < len(items)
+	}
+	return merged, optional, true
+}
+
+func scalarType(node *yaml.Node) string {
+	switch node.Tag {
+	case "!!int":
+		return "int"
+	case "!!float":
+		return "float64"
+	case "!!bool":
+		return "bool"
+	}
+	// NOTE: Timestamps and binary are kept as strings, same as when loaded as JSON
+	return "string"
+}
+
+// Describe the type of the value, so we can tell if all list items have the same shape
+func shapeOf(node *yaml.Node) string {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "null"
+		}
+		return scalarType(node)
+	case yaml.MappingNode:
+		fields := []string{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			fields = append(fields, strconv.Quote(node.Content[i].Value)+":"+shapeOf(node.Content[i+1]))
+		}
+		sort.Strings(fields)
+		return "{" + strings.Join(fields, ",") + "}"
+	case yaml.SequenceNode:
+		items := map[string]bool{}
+		for _, item := range node.Content {
+			items[shapeOf(item)] = true
+		}
+		shapes := []string{}
+		for shape := range items {
+			shapes = append(shapes, shape)
+		}
+		sort.Strings(shapes)
+		return "[" + strings.Join(shapes, "|") + "]"
+	}
+	return "any"
+}
+
+func (g *generator) writeStruct(buf *bytes.Buffer, def *structDef) {
+	fmt.Fprintf(buf, "type %s struct {\n", def.name)
+	for _, field := range def.fields {
+		if field.doc != "" {
+			buf.WriteString(field.doc)
+		}
+		tag := field.key
+		if field.optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(buf, "%s %s `json:%q`", field.name, field.goType, tag)
+		if field.comment != "" {
+			fmt.Fprintf(buf, " // %s", field.comment)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n\n")
+}
+
+func (g *generator) structLiteral(node *yaml.Node, def *structDef) (string, error) {
+	var buf strings.Builder
+	values := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		values[node.Content[i].Value] = resolveAlias(node.Content[i+1])
+	}
+
+	buf.WriteString(def.name + "{\n")
+	for _, field := range def.fields {
+		valNode, ok := values[field.key]
+		// NOTE: Nulls are left as zero values
+		if !ok || (valNode.Kind == yaml.ScalarNode && valNode.Tag == "!!null") {
+			continue
+		}
+		lit, err := g.literal(valNode, field.goType, field.structDef)
+		if err != nil {
+			return "", eris.Wrapf(err, "key %q", field.key)
+		}
+		fmt.Fprintf(&buf, "%s: %s,\n", field.name, lit)
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+// Write the value as Go literal of given type
+func (g *generator) literal(node *yaml.Node, goType string, def *structDef) (string, error) {
+	node = resolveAlias(node)
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return "nil", nil
+	}
+
+	switch {
+	case goType == "any":
+		return anyLiteral(node)
+	case def != nil && node.Kind == yaml.MappingNode:
+		return g.structLiteral(node, def)
+	case strings.HasPrefix(goType, "[]") && node.Kind == yaml.SequenceNode:
+		itemType := strings.TrimPrefix(goType, "[]")
+		items := []string{}
+		for _, item := range node.Content {
+			var lit string
+			var err error
+			if itemType == "map[string]any" {
+				lit, err = anyLiteral(item)
+				lit = strings.TrimPrefix(lit, itemType)
+			} else {
+				lit, err = g.literal(item, itemType, def)
+				// NOTE: Type of the items is implied, e.g. `[]Item{{...}}`
+				if def != nil {
+					lit = strings.TrimPrefix(lit, def.name)
+				}
+			}
+			if err != nil {
+				return "", err
+			}
+			items = append(items, lit)
+		}
+		return goType + "{" + strings.Join(items, ", ") + "}", nil
+	case goType == "map[string]any" && node.Kind == yaml.MappingNode:
+		return anyLiteral(node)
+	case node.Kind == yaml.ScalarNode:
+		return scalarLiteral(node, goType)
+	}
+
+	return "", eris.Wrapf(ErrUnsupportedValue, "value at line %d does not match type %s", node.Line, goType)
+}
+
+// Write the value as literal that keeps its type when assigned to `any`
+func anyLiteral(node *yaml.Node) (string, error) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "nil", nil
+		}
+		return scalarLiteral(node, scalarType(node))
+	case yaml.MappingNode:
+		entries := []string{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			lit, err := anyLiteral(node.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, fmt.Sprintf("%q: %s", node.Content[i].Value, lit))
+		}
+		return "map[string]any{" + strings.Join(entries, ", ") + "}", nil
+	case yaml.SequenceNode:
+		items := []string{}
+		for _, item := range node.Content {
+			lit, err := anyLiteral(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, lit)
+		}
+		return "[]any{" + strings.Join(items, ", ") + "}", nil
+	}
+	return "nil", nil
+}
+
+func scalarLiteral(node *yaml.Node, goType string) (string, error) {
+	switch goType {
+	case "int":
+		var value int64
+		if err := node.Decode(&value); err != nil {
+			return "", eris.Wrapf(ErrUnsupportedValue, "line %d: %s", node.Line, err)
+		}
+		return strconv.FormatInt(value, 10), nil
+	case "float64":
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return "", eris.Wrapf(ErrUnsupportedValue, "line %d: %s", node.Line, err)
+		}
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return "", eris.Wrapf(ErrUnsupportedValue, "line %d: %v", node.Line, value)
+		}
+		lit := strconv.FormatFloat(value, 'g', -1, 64)
+		// NOTE: Keep the literal a float, e.g. when assigned to `any`
+		if !strings.ContainsAny(lit, ".e") {
+			lit += ".0"
+		}
+		return lit, nil
+	case "bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return "", eris.Wrapf(ErrUnsupportedValue, "line %d: %s", node.Line, err)
+		}
+		return strconv.FormatBool(value), nil
+	case "string":
+		return strconv.Quote(node.Value), nil
+	}
+	return "", eris.Wrapf(ErrUnsupportedValue, "value at line %d does not match type %s", node.Line, goType)
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// Convert values key to exported Go field name, e.g. `image-pull.secrets` to `ImagePullSecrets`
+func toFieldName(key string) string {
+	var name strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		name.WriteRune(r)
+	}
+
+	if name.Len() == 0 {
+		return "Field"
+	}
+	// NOTE: Identifiers cannot start with digit, e.g. `2fa`
+	if unicode.IsDigit([]rune(name.String())[0]) {
+		return "X" + name.String()
+	}
+	return name.String()
+}
+
+// Convert YAML comment (`# ...`) or plain text to Go comment lines
+func toGoComment(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+
+	var out strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "#")
+		line = strings.TrimRight(line, " \t")
+		if line != "" && !strings.HasPrefix(line, " ") {
+			line = " " + line
+		}
+		out.WriteString("//" + line + "\n")
+	}
+	return out.String()
+}
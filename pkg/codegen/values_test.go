@@ -0,0 +1,113 @@
+package codegen
+
+import (
+	"encoding/json"
+	"go/format"
+	"os"
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/codegen/internal/fixture"
+	assert "github.com/stretchr/testify/assert"
+	yaml "sigs.k8s.io/yaml"
+)
+
+func TestValuesToStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	values, err := os.ReadFile("testdata/values.yaml")
+	assert.Nil(err)
+
+	out, err := ValuesToStruct(values, "fixture", "Values")
+	assert.Nil(err)
+
+	formatted, err := format.Source(out)
+	assert.Nil(err)
+	assert.Equal(string(formatted), string(out))
+
+	// The generated code is checked in as the `fixture` package, so we can
+	// check that it compiles and that `Defaults()` gives back the values.
+	expected, err := os.ReadFile("internal/fixture/values.go")
+	assert.Nil(err)
+	assert.Equal(string(expected), string(out))
+
+	assert.Contains(string(out), "ImagePullSecrets []any")
+	assert.Contains(string(out), "// null in values.yaml, type could not be inferred")
+	assert.Contains(string(out), "// list in values.yaml mixes types")
+	assert.Contains(string(out), "// Overrides the image tag whose default is the chart appVersion.")
+}
+
+func TestValuesToStructRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	values, err := os.ReadFile("testdata/values.yaml")
+	assert.Nil(err)
+	expected, err := yaml.YAMLToJSON(values)
+	assert.Nil(err)
+
+	actual, err := json.Marshal(fixture.Defaults())
+	assert.Nil(err)
+	assert.JSONEq(string(expected), string(actual))
+
+	// Values load into the generated struct
+	var input fixture.Values
+	err = yaml.UnmarshalStrict(values, &input)
+	assert.Nil(err)
+	// NOTE: Numbers in `any` are loaded as float64, so we compare them as JSON
+	loaded, err := json.Marshal(input)
+	assert.Nil(err)
+	assert.JSONEq(string(expected), string(loaded))
+}
+
+func TestValuesToStructWithSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	values := []byte("nameOverride: null\nimagePullSecrets: []\nport: 80\n")
+	schema := []byte(`{
+		"type": "object",
+		"properties": {
+			"nameOverride": {"type": ["string", "null"]},
+			"imagePullSecrets": {"type": "array", "items": {"type": "string"}},
+			"port": {"type": "integer", "description": "Port of the service"}
+		}
+	}`)
+
+	out, err := ValuesToStructWithSchema(values, schema, "chart", "Input")
+	assert.Nil(err)
+	assert.Contains(string(out), "NameOverride     string   `json:\"nameOverride\"`\n")
+	assert.Contains(string(out), "ImagePullSecrets []string `json:\"imagePullSecrets\"`\n")
+	assert.Contains(string(out), "// Port of the service\n")
+	assert.Contains(string(out), "ImagePullSecrets: []string{},")
+
+	_, err = ValuesToStructWithSchema(values, []byte("{"), "chart", "Input")
+	assert.ErrorIs(err, ErrInvalidSchema)
+}
+
+func TestValuesToStructErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ValuesToStruct([]byte("- a\n- b\n"), "chart", "Input")
+	assert.ErrorIs(err, ErrInvalidValues)
+
+	_, err = ValuesToStruct([]byte("a: [\n"), "chart", "Input")
+	assert.ErrorIs(err, ErrInvalidValues)
+
+	_, err = ValuesToStruct([]byte("a: 1\n"), "my-chart", "Input")
+	assert.ErrorIs(err, ErrInvalidIdentifier)
+
+	_, err = ValuesToStruct([]byte("a: .inf\n"), "chart", "Input")
+	assert.ErrorIs(err, ErrUnsupportedValue)
+
+	// Empty values give empty struct
+	out, err := ValuesToStruct([]byte(""), "chart", "Input")
+	assert.Nil(err)
+	assert.Contains(string(out), "type Input struct {\n}")
+}
+
+func TestToFieldName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("ReplicaCount", toFieldName("replicaCount"))
+	assert.Equal("ImagePullSecrets", toFieldName("image-pull.secrets"))
+	assert.Equal("X2fa", toFieldName("2fa"))
+	assert.Equal("Field", toFieldName("--"))
+}
@@ -0,0 +1,200 @@
+package component
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	template "text/template"
+	"text/template/parse"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// What a component's template uses, see `Analyze`.
+type Analysis struct {
+	// Names of the functions called in the template, e.g. `toYaml`, sorted.
+	// Includes also the `text/template` builtins like `printf` or `index`.
+	Functions []string
+	// Fields of the template data that are referenced, e.g. `.Helpa.Input.Name`, sorted
+	Variables []string
+	// Whether the template contains escaped Helm actions, e.g. `{{! .Release.Name }}`
+	HasEscapedActions bool
+}
+
+// Whether the template calls the function with given name
+func (a Analysis) UsesFunction(name string) bool {
+	return slices.Contains(a.Functions, name)
+}
+
+// Find out which functions and variables the component's template uses, without
+// rendering it. Useful for auditing, e.g. to forbid `exec` or `readFile`:
+//
+//	analysis, err := component.Analyze(def)
+//	if analysis.UsesFunction("exec") {
+//		...
+//	}
+//
+// The template is loaded and preprocessed the same way as in `CreateComponent`.
+// All parts of the template are analyzed, incl. those inside `define` blocks.
+//
+// NOTE: Escaped Helm actions are left for Helm to render, so they are not analyzed.
+func Analyze[TType any, TInput any, TContext any](def Def[TType, TInput, TContext]) (Analysis, error) {
+	tmpl, replMap, _, err := doPrepareComponentInput(def.Name, def.Template, def.TemplateIsFile, applyDefaultOptions(def.Options.Copy()))
+	if err != nil {
+		return Analysis{}, err
+	}
+	return analyzeTemplate(def.Name, tmpl, replMap)
+}
+
+// Same as `Analyze`, but for `DefMulti`.
+func AnalyzeMulti[TType any, TInput any, TContext any](def DefMulti[TType, TInput, TContext]) (Analysis, error) {
+	tmpl, replMap, _, err := doPrepareComponentInput(def.Name, def.Template, def.TemplateIsFile, applyDefaultOptions(def.Options.Copy()))
+	if err != nil {
+		return Analysis{}, err
+	}
+	return analyzeTemplate(def.Name, tmpl, replMap)
+}
+
+// Names of all template functions that the component's template may call, sorted.
+// Includes Helm's functions (incl. Sprig), Helmfile's functions, our own functions
+// (e.g. `indentRest`), and the functions of the context. Useful for documentation,
+// or to find out what's available.
+//
+// Functions disabled with `Options.Sandbox` or `Options.DisableHelmfileFuncs` are left out.
+//
+// NOTE: `text/template` builtins like `printf` or `index` are not included.
+func AvailableFuncs[TType any, TInput any, TContext any](def Def[TType, TInput, TContext]) []string {
+	return availableFuncs[TContext](newRenderConfig(applyDefaultOptions(def.Options.Copy())))
+}
+
+// Same as `AvailableFuncs`, but for `DefMulti`.
+func AvailableFuncsMulti[TType any, TInput any, TContext any](def DefMulti[TType, TInput, TContext]) []string {
+	return availableFuncs[TContext](newRenderConfig(applyDefaultOptions(def.Options.Copy())))
+}
+
+func availableFuncs[TContext any](cfg renderConfig) []string {
+	funcMap := template.FuncMap{}
+
+	// Functions of the context are its fields of function type
+	contextType := reflect.TypeOf((*TContext)(nil)).Elem()
+	for contextType.Kind() == reflect.Pointer {
+		contextType = contextType.Elem()
+	}
+	if contextType.Kind() == reflect.Struct {
+		for index := 0; index < contextType.NumField(); index++ {
+			field := contextType.Field(index)
+			if field.IsExported() && field.Type.Kind() == reflect.Func {
+				funcMap[field.Name] = nil
+			}
+		}
+	}
+
+	addTemplateFuncs(funcMap, cfg)
+
+	// NOTE: Disabled functions are replaced with stubs, see `disableTemplateFuncs`
+	for _, name := range cfg.disabledFuncs {
+		delete(funcMap, name)
+	}
+	// Used internally, see `rewriteNilArgs`
+	for name := range nilArgFuncMap() {
+		delete(funcMap, name)
+	}
+
+	names := []string{}
+	for name := range funcMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func analyzeTemplate(templateName string, templateStr string, replMap map[string]string) (Analysis, error) {
+	// NOTE: Functions defined on the context are known only after `Setup`,
+	// so we don't check if the functions exist.
+	tree := parse.New(templateName)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := map[string]*parse.Tree{}
+	_, err := tree.Parse(templateStr, "", "", treeSet)
+	if err != nil {
+		return Analysis{}, eris.Wrapf(err, "parse error in %q", templateName)
+	}
+
+	walker := analysisWalker{functions: map[string]bool{}, variables: map[string]bool{}}
+	if _, ok := treeSet[tree.Name]; !ok {
+		walker.walk(tree.Root)
+	}
+	for _, definedTree := range treeSet {
+		walker.walk(definedTree.Root)
+	}
+
+	return Analysis{
+		Functions:         sortedKeys(walker.functions),
+		Variables:         sortedKeys(walker.variables),
+		HasEscapedActions: len(replMap) > 0,
+	}, nil
+}
+
+type analysisWalker struct {
+	functions map[string]bool
+	variables map[string]bool
+}
+
+func (w *analysisWalker) walk(node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			w.walk(child)
+		}
+	case *parse.ActionNode:
+		w.walk(node.Pipe)
+	case *parse.IfNode:
+		w.walkBranch(&node.BranchNode)
+	case *parse.RangeNode:
+		w.walkBranch(&node.BranchNode)
+	case *parse.WithNode:
+		w.walkBranch(&node.BranchNode)
+	case *parse.TemplateNode:
+		w.walk(node.Pipe)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			w.walk(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			w.walk(arg)
+		}
+	case *parse.ChainNode:
+		w.walk(node.Node)
+	case *parse.IdentifierNode:
+		w.functions[node.Ident] = true
+	case *parse.FieldNode:
+		w.variables["."+strings.Join(node.Ident, ".")] = true
+	case *parse.VariableNode:
+		// NOTE: `$` is the root of the data, other variables are local to the template
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			w.variables["."+strings.Join(node.Ident[1:], ".")] = true
+		}
+	}
+}
+
+func (w *analysisWalker) walkBranch(node *parse.BranchNode) {
+	w.walk(node.Pipe)
+	w.walk(node.List)
+	w.walk(node.ElseList)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package component
+
+import (
+	"sort"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+type analyzeContext struct {
+	Input struct {
+		Name string
+	}
+}
+
+func TestAnalyzeMulti(t *testing.T) {
+	assert := assert.New(t)
+
+	def := DefMulti[runtime.Object, Input, analyzeContext]{
+		Name:           "Kuard",
+		Template:       `../../examples/helm/helm.yaml`,
+		TemplateIsFile: true,
+	}
+
+	analysis, err := AnalyzeMulti(def)
+	assert.Nil(err)
+	assert.Equal([]string{"indentRest", "toYaml"}, analysis.Functions)
+	assert.True(analysis.UsesFunction("toYaml"))
+	assert.False(analysis.UsesFunction("exec"))
+	assert.Contains(analysis.Variables, ".Helpa.Input.Name")
+	assert.Contains(analysis.Variables, ".Helpa.Input.Port.ContainerPort")
+	assert.False(analysis.HasEscapedActions)
+}
+
+func TestAnalyze(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[runtime.Object, Input, analyzeContext]{
+		Name: "Hidden",
+		Template: `
+		{{- define "secret" }}{{ readFile $.Helpa.Path | b64enc }}{{ end -}}
+		name: {{ include "secret" . }}
+		namespace: {{! .Release.Namespace }}
+		{{- range $i, $port := .Helpa.Ports }}
+		- {{ if $port }}{{ env "PORT" }}{{ end }}
+		{{- end }}
+		`,
+	}
+
+	analysis, err := Analyze(def)
+	assert.Nil(err)
+	// Calls inside `define` are reported too
+	assert.Equal([]string{"b64enc", "env", "include", "readFile"}, analysis.Functions)
+	assert.Equal([]string{".Helpa.Path", ".Helpa.Ports"}, analysis.Variables)
+	assert.True(analysis.HasEscapedActions)
+
+	// Same analysis is available on the created component
+	def.Setup = func(Input) (analyzeContext, error) { return analyzeContext{}, nil }
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	compAnalysis, err := comp.Analyze()
+	assert.Nil(err)
+	assert.Equal(analysis, compAnalysis)
+
+	def.Template = `{{ .Helpa.Name `
+	_, err = Analyze(def)
+	assert.NotNil(err)
+}
+
+func TestAvailableFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[runtime.Object, Input, Context]{Name: "Kuard"}
+
+	funcs := AvailableFuncs(def)
+	// Helm, Sprig, Helmfile, our own, and context functions
+	for _, name := range []string{"toYaml", "include", "snakecase", "readFile", "indentRest", "Catify"} {
+		assert.Contains(funcs, name)
+	}
+	assert.NotContains(funcs, nilArgFuncLookup)
+	assert.True(sort.StringsAreSorted(funcs))
+
+	// Disabled functions are left out
+	def.Options.Sandbox = true
+	def.Options.DisableHelmfileFuncs = true
+	funcs = AvailableFuncs(def)
+	assert.NotContains(funcs, "env")
+	assert.NotContains(funcs, "readFile")
+	assert.Contains(funcs, "toYaml")
+
+	multiDef := DefMulti[runtime.Object, Input, Context]{Name: "Kuard"}
+	assert.Contains(AvailableFuncsMulti(multiDef), "Catify")
+}
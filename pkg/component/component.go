@@ -2,14 +2,20 @@ package component
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	template "text/template"
+	"time"
 
+	filesystem "github.com/helmfile/helmfile/pkg/filesystem"
 	helmfile "github.com/helmfile/helmfile/pkg/tmpl"
 	reflections "github.com/oleiade/reflections"
 	dynamicstruct "github.com/ompluscator/dynamic-struct"
@@ -24,10 +30,90 @@ import (
 
 var (
 	ErrComponentRenderResultMismatch = eris.New("number of instances extracted from the rendered template does not match the number of declared instances in `GetInstances`")
+	ErrDocumentIndexOutOfRange       = eris.New("document index is out of range")
+	ErrTemplateNotFound              = eris.New("template file not found in any of the template directories")
+	ErrMissingInstances              = eris.New("either `GetInstances` or `InstanceFor` must be set")
+	ErrDocumentCountOutOfBounds      = eris.New("number of documents in the rendered template is out of the bounds of `MinDocs` and `MaxDocs`")
+	ErrSkipTemplateWithoutRender     = eris.New("`Options.SkipTemplate` requires a custom `Render`")
+	ErrInvalidInput                  = eris.New("input was rejected by `Options.ValidateInput`")
+	ErrAmbiguousContextField         = eris.New("context has fields whose names differ only by case")
+	ErrSetupConflict                 = eris.New("only one of `Setup` and `SetupCtx` may be set")
 )
 
+// See `Options.ValidateInput`
+func checkInput[TInput any](compName string, input TInput, options Options[TInput]) error {
+	if options.ValidateInput == nil {
+		return nil
+	}
+	if err := options.ValidateInput(input); err != nil {
+		return eris.Wrapf(ErrInvalidInput, "invalid input in %q: %v", compName, err)
+	}
+	return nil
+}
+
+// Signature of `Def.Setup`, see `Def.SetupMiddleware`
+type SetupFunc[TInput any, TContext any] func(TInput) (TContext, error)
+
+// Wrap the setup function with the middleware, the first one being the outermost
+func applySetupMiddleware[TInput any, TContext any](
+	setup SetupFunc[TInput, TContext],
+	middleware []func(next SetupFunc[TInput, TContext]) SetupFunc[TInput, TContext],
+) SetupFunc[TInput, TContext] {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		setup = middleware[i](setup)
+	}
+	return setup
+}
+
+// Run `postSetup` on the context returned by the setup function
+func applyPostSetup[TInput any, TContext any](
+	setup SetupFunc[TInput, TContext],
+	postSetup func(context *TContext) error,
+) SetupFunc[TInput, TContext] {
+	if postSetup == nil {
+		return setup
+	}
+	return func(input TInput) (TContext, error) {
+		context, err := setup(input)
+		if err != nil {
+			return context, err
+		}
+		err = postSetup(&context)
+		if err != nil {
+			return context, eris.Wrap(err, "post-setup failed")
+		}
+		return context, nil
+	}
+}
+
+// Build the setup function of a single render, which passes `ctx` to `setupCtx`.
+//
+// NOTE: The middleware is applied on each render, because `SetupFunc` doesn't
+// receive the context.
+func newRenderSetup[TInput any, TContext any](
+	ctx context.Context,
+	setup SetupFunc[TInput, TContext],
+	setupCtx func(ctx context.Context, input TInput) (TContext, error),
+	middleware []func(next SetupFunc[TInput, TContext]) SetupFunc[TInput, TContext],
+	postSetup func(context *TContext) error,
+) SetupFunc[TInput, TContext] {
+	if setupCtx != nil {
+		setup = func(input TInput) (TContext, error) { return setupCtx(ctx, input) }
+	}
+	if setup == nil {
+		setup = func(t TInput) (context TContext, err error) { return context, err }
+	}
+	setup = applySetupMiddleware(setup, middleware)
+	return applyPostSetup(setup, postSetup)
+}
+
 // Component definition
+//
+// NOTE: If `TType` is a slice and the rendered template has multiple documents
+// (see `Options.MultiDocSeparator`), each document is unmarshalled into an element.
 type Def[TType any, TInput any, TContext any] struct {
+	// Name of the component. Available in the template as `.ComponentName`,
+	// e.g. to build resource names.
 	Name     string
 	Template string
 	// If true, the `Template` is evaluated as a path to a template file.
@@ -38,21 +124,41 @@ type Def[TType any, TInput any, TContext any] struct {
 	// Function that transforms input to context. Functions defined on the context
 	// will be made available as template functions. Other context fields will b
 	// available as template variables.
-	Setup   func(TInput) (TContext, error)
-	Render  func(input TInput, context TContext, content string) (TType, error)
-	Options Options[TInput]
+	//
+	// NOTE: Unexported fields are invisible to templates, so the context may hold
+	// e.g. a client that its functions use.
+	Setup func(TInput) (TContext, error)
+	// Same as `Setup`, but receives a `context.Context` that is cancelled when
+	// the render exceeds `Options.Timeout`, e.g. to abort network calls.
+	//
+	// NOTE: Set either `Setup` or `SetupCtx`, not both.
+	SetupCtx func(ctx context.Context, input TInput) (TContext, error)
+	// Wrap the `Setup` with cross-cutting behavior, e.g. timing, or injecting
+	// values shared by all components. The first middleware is the outermost.
+	// Applied also when `Setup` is not set.
+	//
+	// NOTE: Set on the definition rather than on `Options`, because it needs `TContext`.
+	//
+	// NOTE: Applied on each render, see `SetupCtx`.
+	SetupMiddleware []func(next SetupFunc[TInput, TContext]) SetupFunc[TInput, TContext]
+	// Adjust the context after `Setup` (and its middleware), e.g. in tests,
+	// to change a single value without rewriting the whole `Setup`.
+	PostSetup func(context *TContext) error
+	Render    func(input TInput, context TContext, content string) (TType, error)
+	Options   Options[TInput]
 }
 
 func (i Def[TType, TInput, TContext]) Copy() Def[TType, TInput, TContext] {
 	// NOTE: Should be sufficient according to https://stackoverflow.com/questions/51635766
 	copy := i
-	options := i.Options
-	copy.Options = options
+	copy.SetupMiddleware = slices.Clone(i.SetupMiddleware)
+	copy.Options = i.Options.Copy()
 	return copy
 }
 
 // Component definition
 type DefMulti[TType any, TInput any, TContext any] struct {
+	// Name of the component. Available in the template as `.ComponentName`.
 	Name     string
 	Template string
 	// If true, the `Template` is evaluated as a path to a template file.
@@ -63,22 +169,60 @@ type DefMulti[TType any, TInput any, TContext any] struct {
 	// Function that transforms input to context. Functions defined on the context
 	// will be made available as template functions. Other context fields will b
 	// available as template variables.
+	//
+	// NOTE: Unexported fields are invisible to templates, so the context may hold
+	// e.g. a client that its functions use.
 	Setup func(TInput) (TContext, error)
+	// Same as `Setup`, but receives a `context.Context` that is cancelled when
+	// the render exceeds `Options.Timeout`, e.g. to abort network calls.
+	//
+	// NOTE: Set either `Setup` or `SetupCtx`, not both.
+	SetupCtx func(ctx context.Context, input TInput) (TContext, error)
+	// Wrap the `Setup` with cross-cutting behavior, e.g. timing, or injecting
+	// values shared by all components. The first middleware is the outermost.
+	// Applied also when `Setup` is not set.
+	//
+	// NOTE: Set on the definition rather than on `Options`, because it needs `TContext`.
+	//
+	// NOTE: Applied on each render, see `SetupCtx`.
+	SetupMiddleware []func(next SetupFunc[TInput, TContext]) SetupFunc[TInput, TContext]
+	// Adjust the context after `Setup` (and its middleware), e.g. in tests,
+	// to change a single value without rewriting the whole `Setup`.
+	PostSetup func(context *TContext) error
 	// When we use ComponentMulti, the component does not know what data types to instantiate
 	// for each element in the array/slice. Thus, we need to specify them ourselves here.
 	//
 	// The component reports error if the size of the Array/Slice does not match
 	// the number of instances extracted from the template.
+	//
+	// Set an instance to `nil` to omit its document from the output, e.g. to
+	// conditionally skip a resource without changing the template. This works
+	// only if `TType` is an interface with methods, e.g. `runtime.Object`.
 	GetInstances func(input TInput, context TContext) ([]TType, error)
-	Render       func(input TInput, context TContext, contentParts []string) ([]TType, error)
-	Options      Options[TInput]
+	// Alternative to `GetInstances` for templates that render a variable number
+	// of documents, e.g. one per namespace. Called once for each rendered document,
+	// with the position of the document in the template.
+	//
+	// NOTE: Used only when `GetInstances` is not set.
+	InstanceFor func(index int) (TType, error)
+	// With `InstanceFor`, the render fails if the template renders fewer documents
+	// than `MinDocs`, or more than `MaxDocs`. Zero means no bound.
+	MinDocs int
+	MaxDocs int
+	Render  func(input TInput, context TContext, contentParts []string) ([]TType, error)
+	// Optionally validate each of the rendered instances. `index` is the position
+	// of the document in the template.
+	//
+	// By default, the first invalid instance fails the whole render. See `Options.SkipInvalid`.
+	Validate func(instance TType, index int) error
+	Options  Options[TInput]
 }
 
 func (i DefMulti[TType, TInput, TContext]) Copy() DefMulti[TType, TInput, TContext] {
 	// NOTE: Should be sufficient according to https://stackoverflow.com/questions/51635766
 	copy := i
-	options := i.Options
-	copy.Options = options
+	copy.SetupMiddleware = slices.Clone(i.SetupMiddleware)
+	copy.Options = i.Options.Copy()
 	return copy
 }
 
@@ -96,7 +240,16 @@ type Options[TInput any] struct {
 	//
 	// Use this option to if you want to modify the rendered template before unmarshalling it,
 	// or if you want to use different data types like JSON, TOML, etc.
+	//
+	// Use `K8sUnmarshal` to decode K8s objects the way `kubectl` does, e.g. when
+	// the default unmarshalling rejects valid objects.
 	Unmarshal func(rendered string, container any, options Options[TInput]) error
+	// Pick the unmarshal function for each document, e.g. when a template mixes
+	// YAML and JSON documents. `index` is the position of the document in the template.
+	// If it returns nil, `Unmarshal` is used.
+	//
+	// Use `SniffFormat` to decode documents that look like JSON as JSON.
+	UnmarshalFor func(doc string, index int) UnmarshalFunc[TInput]
 	// If the document contains lines that contain this separator and nothing else,
 	// then the document will be split at these points, and evaluated as a list of
 	// smaller documents.
@@ -120,13 +273,263 @@ type Options[TInput any] struct {
 	FrontloadEnabled bool
 	// Configure the input for the frontloading call.
 	FrontloadInput TInput
+	// If set, the random template functions (`randAlphaNum`, `randAlpha`,
+	// `randNumeric`, `randAscii`, `randInt`, `uuidv4`) are seeded with this value,
+	// so the same input always renders the same output.
+	//
+	// Useful for golden tests and drift detection.
+	RandSeed *int64
+	// Additionally expose the context under the `.Values` key, so templates
+	// ported from Helm charts can be used without rewriting `.Values.foo` to
+	// `.Helpa.Foo`.
+	//
+	// If the context has a `Values` field, only that field is exposed. Keys
+	// follow the lowerCamel convention of Helm values (e.g. `ReplicaCount` is
+	// accessed as `.Values.replicaCount`), unless set by `json` struct tags.
+	//
+	// NOTE: Actions escaped with `{{! }}` are left for Helm, so `{{! .Values.foo }}`
+	// ends up in the output as `{{ .Values.foo }}`, while `{{ .Values.foo }}` is
+	// resolved by Helpa from the context.
+	ExposeValues bool
+	// If set, Helm's built-in objects `.Release`, `.Chart` and `.Capabilities`
+	// are emulated in the templates with these values.
+	//
+	// NOTE: Actions escaped with `{{! }}`, e.g. `{{! .Release.Name }}`, are still
+	// left for Helm.
+	HelmBuiltins *HelmBuiltins
+	// If true, documents of a `ComponentMulti` whose instances fail `DefMulti.Validate`
+	// are dropped instead of failing the whole render. Useful for best-effort applies.
+	//
+	// NOTE: The number of returned instances may then be less than the number of
+	// documents in the template. Use `ComponentMulti.RenderDetailed` to find out
+	// which documents were skipped and why.
+	SkipInvalid bool
+	// Root directory for all file reads of the component - file templates (see
+	// `TemplateIsFile`), and template functions like `readFile`, `readDir` or `isFile`.
+	// Relative paths are resolved from this directory.
+	//
+	// Default: current working directory
+	BaseDir string
+	// If set, relative paths of file templates (see `TemplateIsFile`) are resolved
+	// from this directory. Takes precedence over `BaseDir`.
+	//
+	// Use `CallerDir()` to resolve them relative to the Go file that defines
+	// the component, so the component works regardless of where it's run from.
+	TemplateBaseDir string
+	// Directories in which to search for file templates (see `TemplateIsFile`)
+	// with relative paths, e.g. `kuard/kuard.yaml`. Directories are tried in order,
+	// and the first match wins. Relative directories are resolved from
+	// `TemplateBaseDir` or `BaseDir`.
+	//
+	// NOTE: Absolute template paths are used as they are.
+	//
+	// Default: directories set with `SetTemplateDirs`, or none
+	TemplateDirs []string
+	// If true, template functions that access the filesystem, environment or network,
+	// or run commands (e.g. `exec`, `readFile` or `env`) fail the render when called.
+	// Use it when rendering templates that you don't trust.
+	Sandbox bool
+	// Template functions to disable in sandbox mode, see `Sandbox`.
+	//
+	// Default: `SandboxDisabledFuncs`
+	DisabledFuncs []string
+	// If true, the rendered documents are checked to be valid YAML before they
+	// are unmarshalled, or passed to the custom `Render`. Syntax errors are reported
+	// as `ErrInvalidYaml`, with the line where they occurred.
+	//
+	// NOTE: The default `Unmarshal` reports syntax errors as `ErrInvalidYaml` too.
+	// Use this with custom `Render` or `Unmarshal`.
+	ValidateYaml bool
+	// Called for each issue found while rendering that doesn't fail the render,
+	// e.g. when the input sets a deprecated field. See `Warning`.
+	//
+	// Default: warnings are logged with `log.Printf`
+	OnWarning func(Warning)
+	// If true, setting input fields marked with `helpa:"deprecated=..."` fails
+	// the render with `ErrDeprecatedInput`, instead of emitting a warning.
+	DeprecationsAsErrors bool
+	// If true, `${VAR}` and `$VAR` outside of template actions are replaced
+	// with environment variables before the template is rendered, like with
+	// `envsubst`. See `preprocess.ExpandEnv`.
+	//
+	// NOTE: Ignored in sandbox mode (see `Options.Sandbox`).
+	ExpandEnv bool
+	// If true, values of `data` of rendered Secrets must be valid base64, otherwise
+	// the render fails with `ErrInvalidSecretData`, naming the key. And `stringData`
+	// of the Secret instances is moved into `data`, see `serializers.NormalizeSecret`.
+	NormalizeSecrets bool
+	// If true, the default unmarshalling (and `JSONUnmarshal`) ignores fields
+	// of the rendered documents that the instance doesn't have, and emits
+	// a `WarningUnknownField` warning instead of failing.
+	AllowUnknownFields bool
+	// If true, results of successful renders are cached, keyed by a hash of
+	// the input, and rendering the same input again returns the cached result
+	// without calling `Setup` or executing the template. Use it for components
+	// that are pure, i.e. the same input always renders the same output.
+	//
+	// NOTE: The hash covers all fields of the input, also unexported ones. Inputs
+	// with functions, channels or pointer cycles cannot be hashed, so they are
+	// rendered without the cache, and `WarningNotMemoizable` is emitted.
+	//
+	// NOTE: Cached instances are shared between the renders, so don't modify them.
+	MemoizeRenders bool
+	// Max number of cached renders, see `MemoizeRenders`. When full, the least
+	// recently used render is dropped.
+	//
+	// Default: `DefaultMemoizeMaxEntries`
+	MemoizeMaxEntries int
+	// How long the cached renders are kept, see `MemoizeRenders`.
+	//
+	// Default: 0 (kept until dropped with `MemoizeMaxEntries`)
+	MemoizeTTL time.Duration
+	// If true, Helmfile's template functions (e.g. `env`, `exec` or `readFile`)
+	// are not available, and templates that use them fail to parse with
+	// "function not defined". Functions that Helm defines too are kept as Helm's.
+	// Use it for a smaller function surface, e.g. together with `Sandbox`.
+	DisableHelmfileFuncs bool
+	// If true, the template is not rendered (nor parsed), and the instances are
+	// created only by the custom `Render`, which gets empty content. Use it for
+	// components that don't use the template, to skip the cost of rendering it.
+	//
+	// NOTE: `Setup` still runs, as its context is passed to `Render`.
+	//
+	// NOTE: Requires `Render`, otherwise creating the component fails with
+	// `ErrSkipTemplateWithoutRender`.
+	SkipTemplate bool
+	// What happens when a function from the context receives a value from
+	// a missing map key, e.g. `{{ Catify .Helpa.Labels.missing }}`. With
+	// `missingkey=zero`, the function gets a zero value, and may render
+	// misleading output. See `NilArgPolicy`.
+	//
+	// NOTE: Only field chains passed directly to the function are checked,
+	// e.g. `{{ Catify .Helpa.Labels.app }}` or `{{ $x.app | Catify }}`.
+	//
+	// Default: `NilArgPolicyZero`
+	NilArgPolicy NilArgPolicy
+	// By default, `content` returned from `Component.Render` is the rendered
+	// template, also when a custom `Render` creates the instance. If true,
+	// `content` is the instance marshalled to YAML instead, so it matches
+	// what `Render` returned. With `ComponentMulti`, there is one document
+	// per instance.
+	ContentFromInstance bool
+	// If true, the component is added to the package-level registry when created,
+	// so that `ValidateAll` renders it with `FrontloadInput`.
+	Register bool
+	// Max time that a render may take in `Setup`, template execution, and custom
+	// `Render`. When exceeded, the render fails with `ErrTimeout`, which tells
+	// the phase and the elapsed time, e.g. when `Setup` hangs on a network call.
+	//
+	// NOTE: Use `SetupCtx` to be told when the timeout expires. Other phases
+	// cannot be stopped, and are left running in the background, see `runWithDeadline`.
+	//
+	// Default: 0 (no timeout)
+	Timeout time.Duration
+	// Check the input before anything else is done with it, e.g. that a required
+	// field is set. If it returns an error, the render fails with `ErrInvalidInput`
+	// wrapping it, and `Setup` is not called.
+	//
+	// NOTE: Called after `Defaults` are applied.
+	ValidateInput func(input TInput) error
+}
+
+// Copy the options, including the values behind pointers, so that changes
+// to the original options don't affect the copy, and vice versa.
+//
+// NOTE: `FrontloadInput` is copied shallowly, as it's used only at component creation.
+func (o Options[TInput]) Copy() Options[TInput] {
+	copy := o
+	if o.TabSize != nil {
+		copy.TabSize = utils.PointerOf(*o.TabSize)
+	}
+	if o.RandSeed != nil {
+		copy.RandSeed = utils.PointerOf(*o.RandSeed)
+	}
+	if o.HelmBuiltins != nil {
+		builtins := *o.HelmBuiltins
+		builtins.Capabilities.APIVersions = slices.Clone(o.HelmBuiltins.Capabilities.APIVersions)
+		copy.HelmBuiltins = &builtins
+	}
+	copy.TemplateDirs = slices.Clone(o.TemplateDirs)
+	copy.DisabledFuncs = slices.Clone(o.DisabledFuncs)
+	return copy
 }
 
 type Component[TType any, TInput any] struct {
 	Render func(input TInput) (instance TType, content string, err error)
+	// Find out which functions and variables the template uses. See `Analyze`.
+	Analyze func() (Analysis, error)
+	// Same as `Render`, but also returns the instance marshalled to canonical JSON,
+	// e.g. for tools that consume JSON. Fails with `ErrEscapedActionInJSON` if the
+	// template contains escaped Helm actions that end up in the output.
+	RenderJSON func(input TInput) (instance TType, data []byte, err error)
+	// Render the component and return a hash of the output, e.g. for cache keys
+	// or checksum annotations. The output is normalized first, so the hash doesn't
+	// change with the formatting of the template, e.g. comments, whitespace or key order.
+	Hash func(input TInput) (string, error)
+	// Render the component with each input, same as when frontloading, and return
+	// all the errors joined. With no inputs, the zero input is used. E.g. for a CI
+	// step that checks all components, without enabling `Options.FrontloadEnabled`.
+	Validate func(inputs ...TInput) error
+	// Same as `Render`, but the context variables are overridden with the values
+	// after `Setup`, similar to Helm's `--set`. Keys are dotted paths, e.g. `Image.Tag`.
+	// String values are parsed as YAML if the field is not a string, e.g. `"3"` for an int,
+	// and numbers or booleans are formatted for string fields.
+	//
+	// Fails with `ErrUnknownOverride` for fields that the context doesn't have,
+	// and with `ErrOverrideFunction` for functions of the context.
+	//
+	// NOTE: Results are not cached with `Options.MemoizeRenders`.
+	RenderWithValues func(input TInput, overrides map[string]any) (instance TType, content string, err error)
 }
 type ComponentMulti[TType any, TInput any] struct {
 	Render func(input TInput) (instances []TType, contents []string, err error)
+	// Same as `Render`, but also reports the documents skipped with `Options.SkipInvalid`.
+	RenderDetailed func(input TInput) (result RenderMultiResult[TType], err error)
+	// Same as `Render`, but returns only the document at given index (and its instance).
+	// Useful for debugging a single resource of a large component.
+	//
+	// NOTE: All documents are still rendered, `index` is the position in the output of `Render`.
+	RenderIndex func(input TInput, index int) (instance TType, content string, err error)
+	// Find out which functions and variables the template uses. See `Analyze`.
+	Analyze func() (Analysis, error)
+	// Same as `Component.RenderJSON`, with one JSON per instance.
+	RenderJSON func(input TInput) (instances []TType, data [][]byte, err error)
+	// Same as `Component.Hash`. Documents are hashed separately, and the hash
+	// doesn't depend on their order.
+	Hash func(input TInput) (string, error)
+	// Same as `Component.Validate`. Also checks that the number of documents
+	// matches the instances from `GetInstances`, and runs `DefMulti.Validate`.
+	Validate func(inputs ...TInput) error
+	// Same as `Component.RenderWithValues`.
+	RenderWithValues func(input TInput, overrides map[string]any) (instances []TType, contents []string, err error)
+}
+
+// Result of `Component.Render`, as it's cached with `Options.MemoizeRenders`
+type renderResult[TType any] struct {
+	instance TType
+	content  string
+}
+
+// Result of `ComponentMulti.RenderDetailed`
+type RenderMultiResult[TType any] struct {
+	// Valid instances, in the order of the documents in the template
+	Instances []TType
+	// Rendered documents of the valid instances
+	Contents []string
+	// Documents dropped because their instances were invalid
+	Skipped []SkippedDocument
+	// Escaped Helm actions (`{{! ... }}`) that ended up in the rendered template,
+	// in the order of the output, e.g. `{{ .Values.image.tag }}`. Includes also
+	// the actions of the skipped documents. See `serializers.ReportEscapedActions`.
+	EscapedActions []string
+}
+
+// Document dropped from the render because it failed validation.
+type SkippedDocument struct {
+	// Position of the document in the template
+	Index   int
+	Content string
+	Err     error
 }
 
 func isFunc(v any) bool {
@@ -135,9 +538,26 @@ func isFunc(v any) bool {
 
 func genCustomFuncMap() template.FuncMap {
 	return template.FuncMap{
-		"indentRest": functions.IndentRest,
-		"yamlToJson": functions.YamlToJson,
-		"jsonToYaml": functions.JsonToYaml,
+		"indentRest":   functions.IndentRest,
+		"nindentRest":  functions.NindentRest,
+		"toYamlIndent": functions.ToYamlIndent,
+		"embedYaml":    functions.EmbedYaml,
+		"multiline":    functions.Multiline,
+		// NOTE: Alias of `multiline`, for those who think of it in YAML terms.
+		"toBlockScalar":    functions.Multiline,
+		"yamlToJson":       functions.YamlToJson,
+		"yamlToJsonIndent": functions.YamlToJsonIndent,
+		"jsonToYaml":       functions.JsonToYaml,
+		"jsonToYamlIndent": functions.JsonToYamlIndent,
+		"svcDNS":           functions.SvcDNS,
+		"svcURL":           functions.SvcURL,
+		"urlJoin":          functions.UrlJoin,
+		"k8sName":          functions.K8sName,
+		"quantity":         functions.Quantity,
+		"duration":         functions.Duration,
+		"dget":             functions.Dget,
+		"mustDget":         functions.MustDget,
+		"b64encMap":        functions.B64encMap,
 	}
 }
 
@@ -156,16 +576,25 @@ func defaultPreprocessor[TInput any](tmpl string, opts Options[TInput]) (string,
 }
 
 func defaultUnmarshaller[TInput any](rendered string, container any, opts Options[TInput]) error {
+	// NOTE: Templates are trimmed of trailing newlines. But a block scalar keeps
+	// its final line break only if there is one, so without this, a block scalar
+	// at the end of the document would lose its trailing newline.
+	if !strings.HasSuffix(rendered, "\n") {
+		rendered += "\n"
+	}
 	jsondata, err := yaml.YAMLToJSON([]byte(rendered))
 	if err != nil {
-		return eris.Wrap(err, "failed to convert rendered template from YAML to JSON")
+		// NOTE: Report syntax errors separately from errors of the types not matching
+		return eris.Wrap(yamlSyntaxError(err, rendered), "failed to convert rendered template from YAML to JSON")
 	}
-	dec := json.NewDecoder(bytes.NewReader(jsondata))
-	dec.DisallowUnknownFields()
-	return dec.Decode(container)
+	err = decodeJSON(jsondata, container, opts)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal document as YAML")
+	}
+	return nil
 }
 
-// Process the fields in Context.
+// Process the fields in Context. Context may be a struct, or `map[string]any`.
 //
 // If a field is a function, it will be made available as template function.
 // If it's a non-func, we will expose it as a template variable.
@@ -178,17 +607,49 @@ func parseContext(
 ) (template.FuncMap, any, error) {
 	funcMap := template.FuncMap{}
 
+	// Maps are exposed as they are, e.g. with `CreateDynamic`
+	if contextMap, ok := context.(map[string]any); ok {
+		varMap := map[string]any{}
+		for key, val := range contextMap {
+			if val != nil && isFunc(val) {
+				funcMap[key] = val
+			} else {
+				varMap[key] = val
+			}
+		}
+		return funcMap, varMap, nil
+	}
+
 	structBuilder := dynamicstruct.NewStruct()
-	structItems, err := reflections.Items(context)
+	structItems, err := contextItems(context)
 	if err != nil {
 		return funcMap, nil, eris.Wrapf(err, "failed to process context in %q", compName)
 	}
 
+	// NOTE: Fields are processed in a stable order, so the built struct
+	// is the same on each render, e.g. when printed with `{{ printf "%+v" .Helpa }}`
+	keys := make([]string, 0, len(structItems))
+	for key := range structItems {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// Lookups are case-sensitive, so e.g. `.Helpa.Name` and `.Helpa.NAME` are
+	// easily confused
+	lowerKeys := map[string]string{}
+	for _, key := range keys {
+		if other, ok := lowerKeys[strings.ToLower(key)]; ok {
+			return funcMap, nil, eris.Wrapf(ErrAmbiguousContextField, "fields %q and %q in %q", other, key, compName)
+		}
+		lowerKeys[strings.ToLower(key)] = key
+	}
+
 	varMap := map[string]any{}
-	for key, val := range structItems {
+	for _, key := range keys {
+		val := structItems[key]
 		// Pass functions to the engine's FuncMap, so users may call them as
 		// `{{ MyFunc arg1 arg2 }}`
-		if isFunc(val) {
+		if val != nil && isFunc(val) {
 			funcMap[key] = val
 			continue
 		}
@@ -202,7 +663,11 @@ func parseContext(
 	dataStructInst := structBuilder.Build().New()
 
 	// The above only created an empty struct, but we still need to populate it
-	for key, val := range varMap {
+	for _, key := range keys {
+		val, ok := varMap[key]
+		if !ok {
+			continue
+		}
 		err = reflections.SetField(dataStructInst, key, val)
 		if err != nil {
 			return funcMap, dataStructInst, eris.Wrapf(err, "failed to create data struct in %q", compName)
@@ -212,15 +677,234 @@ func parseContext(
 	return funcMap, dataStructInst, nil
 }
 
+// Exported fields of the context struct, by their names.
+//
+// NOTE: Unexported fields are skipped, as templates cannot access them anyway.
+func contextItems(context any) (map[string]any, error) {
+	val := reflect.ValueOf(context)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil, eris.Errorf("context is a nil %T", context)
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, eris.Errorf("context must be a struct or `map[string]any`, got %T", context)
+	}
+
+	items := map[string]any{}
+	for index := 0; index < val.NumField(); index++ {
+		field := val.Type().Field(index)
+		if !field.IsExported() {
+			continue
+		}
+		items[field.Name] = val.Field(index).Interface()
+	}
+	return items, nil
+}
+
+// Add Helm's functions, Helmfile's functions, and our own functions to the functions
+// from the context. See `AvailableFuncs`.
+//
+// NOTE: Functions from the context are overridden by the others of the same name.
+func addTemplateFuncs(funcMap template.FuncMap, cfg renderConfig) {
+	libraryFuncs := cfg.libraryFuncs
+	if libraryFuncs == nil {
+		libraryFuncs = libraryFuncMap(cfg)
+	}
+	for key, val := range libraryFuncs {
+		funcMap[key] = val
+	}
+
+	componentInfo := cfg.componentInfo
+	funcMap[componentInfoFunc] = func() ComponentInfo { return componentInfo }
+
+	// Replace the random functions with their seeded counterparts. The source
+	// is created anew for each render, so each render starts from the same seed.
+	if cfg.randSeed != nil {
+		for key, val := range functions.NewSeededRand(*cfg.randSeed).FuncMap() {
+			funcMap[key] = recoverTemplateFunc(key, val)
+		}
+	}
+
+	// NOTE: Applied last, so the functions cannot be brought back e.g. via the context
+	disableTemplateFuncs(funcMap, cfg.disabledFuncs)
+}
+
+// Functions that are the same for all renders of a component, wrapped with
+// `recoverTemplateFunc`. Created once per component, see `renderConfig.libraryFuncs`.
+func libraryFuncMap(cfg renderConfig) template.FuncMap {
+	funcMap := template.FuncMap{}
+
+	// Using the FuncMap from Helm package ensures that we use all the same
+	// functions as they do (with a few exceptions).
+	// See https://helm.sh/docs/chart_template_guide/function_list/
+	for key, val := range templateEngine.New().FuncMap {
+		funcMap[key] = val
+	}
+
+	// Similarly we use generate FuncMap for Helmfile's functions
+	// See https://helmfile.readthedocs.io/en/latest/templating_funcs/#env
+	// and https://github.com/helmfile/helmfile/blob/main/pkg/tmpl/context_funcs.go
+	if !cfg.disableHelmfileFuncs {
+		for key, val := range helmfileFuncMap(cfg.baseDir) {
+			funcMap[key] = val
+		}
+	}
+
+	// Set our own custom functions
+	customFuncs := genCustomFuncMap()
+	for key, val := range customFuncs {
+		funcMap[key] = val
+	}
+	// Functions from `RegisterGlobalFunc`
+	for key, val := range cfg.globalFuncs {
+		funcMap[key] = val
+	}
+
+	if cfg.nilArgPolicy != "" && cfg.nilArgPolicy != NilArgPolicyZero {
+		for key, val := range nilArgFuncMap() {
+			funcMap[key] = val
+		}
+	}
+
+	// Report panics in template functions as `ErrRenderPanic`
+	for key, val := range funcMap {
+		funcMap[key] = recoverTemplateFunc(key, val)
+	}
+	return funcMap
+}
+
+// Helmfile's functions, created once per base dir, when a component first needs them,
+// as creating them is costly. See `helmfileFuncMap`.
+var helmfileFuncMaps sync.Map
+
+type lazyFuncMap struct {
+	once    sync.Once
+	funcMap template.FuncMap
+}
+
+// Get Helmfile's functions for the base dir.
+//
+// NOTE: File functions like `readFile` read relative to the base dir, which is CWD by default.
+func helmfileFuncMap(baseDir string) template.FuncMap {
+	if baseDir == "" {
+		baseDir = "."
+	}
+	val, _ := helmfileFuncMaps.LoadOrStore(baseDir, &lazyFuncMap{})
+	lazy := val.(*lazyFuncMap)
+	lazy.once.Do(func() {
+		helmfileCtx := helmfile.Context{}
+		helmfileCtx.SetFileSystem(filesystem.DefaultFileSystem())
+		helmfileCtx.SetBasePath(baseDir)
+		lazy.funcMap = helmfileCtx.CreateFuncMap()
+	})
+	return lazy.funcMap
+}
+
+// Internal configuration of a single render, set from the component's options
+type renderConfig struct {
+	randSeed     *int64
+	exposeValues bool
+	helmBuiltins *HelmBuiltins
+	baseDir      string
+	// Functions to disable, see `Options.Sandbox`
+	disabledFuncs []string
+	// See `Options.ExpandEnv`
+	expandEnv bool
+	// See `Options.DisableHelmfileFuncs`
+	disableHelmfileFuncs bool
+	// See `Options.NilArgPolicy`
+	nilArgPolicy NilArgPolicy
+	// Taken when the component is created, see `RegisterGlobalFunc`
+	globalFuncs template.FuncMap
+	// Created once from the fields above, see `libraryFuncMap`
+	libraryFuncs template.FuncMap
+	// Returned by `helpaComponent` in templates
+	componentInfo ComponentInfo
+	// Escaped Helm actions to put back after rendering, see `escapeHelmTemplateActions`
+	replMap map[string][]byte
+	// Called with each escaped action that was put back, in the order of the output
+	onEscapedAction func(action string)
+}
+
+// Buffers reused across renders, so large templates don't reallocate on each render
+var renderBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// Printed by `text/template` for missing values, see `missingkey=zero`
+var noValueBytes = []byte("<no value>")
+
+func newRenderConfig[TInput any](options Options[TInput]) renderConfig {
+	cfg := renderConfig{
+		randSeed:     options.RandSeed,
+		exposeValues: options.ExposeValues,
+		helmBuiltins: options.HelmBuiltins,
+		baseDir:      options.BaseDir,
+		// NOTE: Sandboxed templates must not read the environment
+		expandEnv:            options.ExpandEnv && !options.Sandbox,
+		disableHelmfileFuncs: options.DisableHelmfileFuncs,
+		nilArgPolicy:         options.NilArgPolicy,
+		globalFuncs:          globalFuncMap(),
+	}
+	if options.Sandbox {
+		cfg.disabledFuncs = SandboxDisabledFuncs
+		if options.DisabledFuncs != nil {
+			cfg.disabledFuncs = options.DisabledFuncs
+		}
+	}
+	cfg.libraryFuncs = libraryFuncMap(cfg)
+	return cfg
+}
+
+// Get the Helm-like `.Values` from the context. See `Options.ExposeValues`.
+func contextToValues(compName string, context any) (map[string]any, error) {
+	values := context
+	contextVal := reflect.ValueOf(context)
+	for contextVal.Kind() == reflect.Ptr && !contextVal.IsNil() {
+		contextVal = contextVal.Elem()
+	}
+	if contextVal.Kind() == reflect.Struct {
+		if field := contextVal.FieldByName("Values"); field.IsValid() && field.CanInterface() {
+			values = field.Interface()
+		}
+	}
+
+	// Values given as a map are used as they are
+	if valuesMap, ok := values.(map[string]any); ok {
+		return valuesMap, nil
+	}
+
+	valuesMap, err := utils.StructToMapWithOptions(values, utils.StructToMapOptions{FieldName: utils.LowerCamelCase})
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to convert context to .Values in %q", compName)
+	}
+	return valuesMap, nil
+}
+
 func Render[TContext any](
 	templateName string,
 	templateStr string,
 	context TContext,
+) (content string, err error) {
+	return doRender(templateName, templateStr, context, renderConfig{globalFuncs: globalFuncMap()})
+}
+
+func doRender(
+	templateName string,
+	templateStr string,
+	context any,
+	cfg renderConfig,
 ) (content string, err error) {
 	funcMap, dataStructInst, err := parseContext(templateName, context)
 	if err != nil {
 		return content, eris.Wrapf(err, "failed to process context in component %q", templateName)
 	}
+	contextFuncs := template.FuncMap{}
+	for key, val := range funcMap {
+		contextFuncs[key] = val
+	}
 
 	// "Namespace" all the variables from user's component under the "Helpa" key
 	// so they are accessed as:
@@ -228,56 +912,67 @@ func Render[TContext any](
 	data := map[string]any{}
 	data["Helpa"] = dataStructInst
 
-	// Using the Engine struct from Helm package ensures that we use all the same
-	// functions as they do (with a few exceptions).
-	// See https://helm.sh/docs/chart_template_guide/function_list/
-	engine := templateEngine.New()
-	for key, val := range engine.FuncMap {
-		funcMap[key] = val
+	// NOTE: Set outside of `.Helpa`, so it doesn't clash with the user's variables
+	data["ComponentName"] = templateName
+
+	if cfg.exposeValues {
+		data["Values"], err = contextToValues(templateName, context)
+		if err != nil {
+			return content, err
+		}
 	}
 
-	// Similarly we use generate FuncMap for Helmfile's functions
-	// See https://helmfile.readthedocs.io/en/latest/templating_funcs/#env
-	// and https://github.com/helmfile/helmfile/blob/main/pkg/tmpl/context_funcs.go
-	helmfileCtx := helmfile.Context{}
-	helmfileFuncMap := helmfileCtx.CreateFuncMap()
-	for key, val := range helmfileFuncMap {
-		funcMap[key] = val
+	if cfg.helmBuiltins != nil {
+		for key, val := range cfg.helmBuiltins.templateData() {
+			data[key] = val
+		}
 	}
 
-	// Set our own custom functions
-	customFuncs := genCustomFuncMap()
-	for key, val := range customFuncs {
-		funcMap[key] = val
+	// Report panics in template functions as `ErrRenderPanic`. The other
+	// functions are wrapped already, see `libraryFuncMap`.
+	for key, val := range funcMap {
+		funcMap[key] = recoverTemplateFunc(key, val)
 	}
+	addTemplateFuncs(funcMap, cfg)
 
 	tmpl := template.New(templateName)
 	tmpl.Funcs(funcMap)
 
-	// This section is based on Helm's code
-	if engine.Strict {
-		tmpl.Option("missingkey=error")
-	} else {
-		// Not that zero will attempt to add default values for types it knows,
-		// but will still emit <no value> for others. We mitigate that later.
-		tmpl.Option("missingkey=zero")
+	// This section is based on Helm's code, whose engine is not strict by default.
+	// Not that zero will attempt to add default values for types it knows,
+	// but will still emit <no value> for others. We mitigate that later.
+	tmpl.Option("missingkey=zero")
+
+	// NOTE: Expanded on each render, so changes to the environment take effect
+	if cfg.expandEnv {
+		templateStr = preprocess.ExpandEnv(templateStr)
 	}
 
 	_, err = tmpl.Parse(templateStr)
 	if err != nil {
 		return content, eris.Wrapf(err, "parse error in %q", templateName)
 	}
+	rewriteNilArgs(tmpl, contextFuncs, cfg.nilArgPolicy)
 
 	// Do the actual rendering
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, data)
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer renderBufferPool.Put(buf)
+
+	err = tmpl.Execute(buf, data)
+
+	// NOTE: Scrub and unescape on bytes, so we convert to string only once
+	out := bytes.ReplaceAll(buf.Bytes(), noValueBytes, nil)
+	if len(cfg.replMap) > 0 {
+		out = unescapeHelmTemplateActions(out, cfg.replMap, cfg.onEscapedAction)
+	}
+	content = string(out)
 	if err != nil {
+		// NOTE: We return also the content rendered up to the failure, so it can be inspected
 		err = eris.Wrapf(err, "render error in %q", templateName)
 		return content, err
 	}
 
-	content = strings.Replace(buf.String(), "<no value>", "", -1)
-
 	return content, nil
 }
 
@@ -286,7 +981,12 @@ func doUnmarshalOne[TType any, TInput any](
 	content string,
 	options Options[TInput],
 ) (out TType, err error) {
-	err = options.Unmarshal(content, &out, options)
+	outType := reflect.TypeOf(&out).Elem()
+	if isMultiDocSlice(outType) && strings.Contains(content, options.MultiDocSeparator) {
+		return doUnmarshalSlice[TType](templateName, content, options)
+	}
+
+	err = unmarshalFor(options, content, 0)(content, &out, options)
 	if err != nil {
 		err = eris.Wrapf(err, "render error in %q", templateName)
 		return out, err
@@ -295,29 +995,164 @@ func doUnmarshalOne[TType any, TInput any](
 	return out, nil
 }
 
-func doUnmarshalMulti[TType any, TInput any](
+// NOTE: Byte slices are left to be unmarshalled as a whole
+func isMultiDocSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// Unmarshal each document of the content into an element of the `TType` slice
+func doUnmarshalSlice[TType any, TInput any](
 	templateName string,
+	content string,
+	options Options[TInput],
+) (out TType, err error) {
+	outVal := reflect.ValueOf(&out).Elem()
+	elemType := outVal.Type().Elem()
+
+	docs, err := SplitDocs(content, options)
+	if err != nil {
+		return out, eris.Wrapf(err, "failed to split documents in %q", templateName)
+	}
+	for index, doc := range docs {
+		elem := reflect.New(elemType)
+		err = unmarshalFor(options, doc, index)(doc, elem.Interface(), options)
+		if err != nil {
+			err = eris.Wrapf(err, "render error for document at index %v in %q", index, templateName)
+			return out, err
+		}
+		outVal.Set(reflect.Append(outVal, elem.Elem()))
+	}
+
+	return out, nil
+}
+
+// Unmarshal the documents into copies of the instances. On error, returns
+// the index of the document that failed.
+//
+// `templateIndex` maps the index of the document to its position in the template.
+func doUnmarshalMulti[TType any, TInput any](
 	contentParts []string,
 	options Options[TInput],
 	instances []TType,
-) (out []TType, err error) {
+	templateIndex func(int) int,
+) (out []TType, docIndex int, err error) {
 	// Lastly, unmarshal the generated structured data to ensure
 	// that they are valid.
 	for index, doc := range contentParts {
 		// NOTE: We MUST make a copy of the instance, because the `instances` serve as blueprint.
 		// So we must be careful here not to accidentally change state of the `instances` array.
 		instance := instances[index]
-		err = options.Unmarshal(doc, &instance, options)
+		err = unmarshalFor(options, doc, templateIndex(index))(doc, &instance, options)
 		if err != nil {
-			err = eris.Wrapf(err, "render error in %q", templateName)
-			return out, err
+			return out, index, err
 		}
 		out = append(out, instance)
 	}
 
-	return out, nil
+	return out, -1, nil
 }
 
+// Create an instance for each of the documents, see `DefMulti.InstanceFor`
+func instancesForDocs[TType any](
+	count int,
+	instanceFor func(index int) (TType, error),
+	minDocs int,
+	maxDocs int,
+) ([]TType, error) {
+	if instanceFor == nil {
+		return nil, ErrMissingInstances
+	}
+	if minDocs > 0 && count < minDocs {
+		return nil, eris.Wrapf(ErrDocumentCountOutOfBounds, "found %v documents in the template, but expected at least %v", count, minDocs)
+	}
+	if maxDocs > 0 && count > maxDocs {
+		return nil, eris.Wrapf(ErrDocumentCountOutOfBounds, "found %v documents in the template, but expected at most %v", count, maxDocs)
+	}
+
+	instances := make([]TType, 0, count)
+	for index := 0; index < count; index++ {
+		instance, err := instanceFor(index)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to get instance for document at index %v", index)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// List the documents side by side with the instances, so it's easier to see
+// which of them are missing, e.g.:
+//
+//	document 0: Deployment "kuard" -> *v1.Deployment
+//	document 1: Service "kuard" -> <missing>
+func describeMismatch[TType any](contentParts []string, instances []TType) string {
+	lines := []string{}
+	for index := 0; index < max(len(contentParts), len(instances)); index++ {
+		doc := "<missing>"
+		if index < len(contentParts) {
+			doc = describeDocument(contentParts[index])
+		}
+		instance := "<missing>"
+		if index < len(instances) {
+			instance = fmt.Sprintf("%T", any(instances[index]))
+		}
+		lines = append(lines, fmt.Sprintf("  document %v: %s -> %s", index, doc, instance))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summarize the document by its kind and name, or its first line
+func describeDocument(doc string) string {
+	var meta struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(doc), &meta); err == nil && meta.Kind != "" {
+		return fmt.Sprintf("%s %q", meta.Kind, meta.Metadata.Name)
+	}
+
+	trimmed := strings.TrimSpace(doc)
+	if trimmed == "" {
+		return "<empty>"
+	}
+	firstLine, _, _ := strings.Cut(trimmed, "\n")
+	return fmt.Sprintf("%q", firstLine)
+}
+
+// Drop the instances that are nil, together with their documents. Returns also
+// the original indices of the kept documents, or nil if nothing can be dropped.
+func omitNilInstances[TType any](instances []TType, contentParts []string) ([]TType, []string, []int) {
+	// NOTE: Only interfaces with methods (e.g. `runtime.Object`) can be omitted.
+	// Nil pointers are allocated by the unmarshalling, and `any` is a valid blueprint
+	// for arbitrary data.
+	instanceType := reflect.TypeOf((*TType)(nil)).Elem()
+	if instanceType.Kind() != reflect.Interface || instanceType.NumMethod() == 0 {
+		return instances, contentParts, nil
+	}
+
+	keptInstances := []TType{}
+	keptParts := []string{}
+	docIndices := []int{}
+	for index, instance := range instances {
+		if any(instance) == nil {
+			continue
+		}
+		keptInstances = append(keptInstances, instance)
+		keptParts = append(keptParts, contentParts[index])
+		docIndices = append(docIndices, index)
+	}
+	return keptInstances, keptParts, docIndices
+}
+
+var (
+	// Helm actions escaped by the user as `{{! ... }}`
+	escapedActionRegex = regexp.MustCompile(`{{![^}]*}}`)
+	// Placeholders that replace the escaped actions, e.g. `__helpa__slot_1`
+	actionSlotRegex = regexp.MustCompile(`__helpa__slot_\d+`)
+)
+
 // Adds a way for users to access helm variables via go templates `{{ }}` without
 // having those commands lost when we "pre-render" templates.
 //
@@ -328,8 +1163,7 @@ func doUnmarshalMulti[TType any, TInput any](
 func escapeHelmTemplateActions(tmpl string) (string, map[string]string) {
 	replacementMap := map[string]string{}
 
-	re := regexp.MustCompile(`{{![^}]*}}`)
-	tmpl = re.ReplaceAllStringFunc(tmpl, func(match string) string {
+	tmpl = escapedActionRegex.ReplaceAllStringFunc(tmpl, func(match string) string {
 		// E.g. `__helpa__slot_1`
 		key := fmt.Sprintf("__helpa__slot_%v", len(replacementMap))
 		match = strings.Replace(match, "{{!", "{{", 1)
@@ -340,20 +1174,88 @@ func escapeHelmTemplateActions(tmpl string) (string, map[string]string) {
 	return tmpl, replacementMap
 }
 
-func unescapeHelmTemplateActions(tmpl string, replMap map[string]string) string {
-	re := regexp.MustCompile(`__helpa__slot_\d+`)
-	tmpl = re.ReplaceAllStringFunc(tmpl, func(match string) string {
-		return replMap[match]
+func unescapeHelmTemplateActions(tmpl []byte, replMap map[string][]byte, onAction func(action string)) []byte {
+	return actionSlotRegex.ReplaceAllFunc(tmpl, func(match []byte) []byte {
+		action := replMap[string(match)]
+		if onAction != nil {
+			onAction(string(action))
+		}
+		return action
 	})
-	return tmpl
+}
+
+// NOTE: Converted once per component, so we don't convert on each render
+func replMapToBytes(replMap map[string]string) map[string][]byte {
+	out := make(map[string][]byte, len(replMap))
+	for key, val := range replMap {
+		out[key] = []byte(val)
+	}
+	return out
+}
+
+// Get the directory of the Go source file from which this function is called.
+// Use it as `Options.TemplateBaseDir` to load templates that live next to
+// the component's source, e.g.:
+//
+//	Options: component.Options[Input]{
+//		TemplateBaseDir: component.CallerDir(),
+//	}
+//
+// NOTE: The path is recorded at compile time, so this works as long as the
+// sources are present where they were compiled, e.g. in tests or with `go run`.
+// It does NOT work in binaries built with `-trimpath` or run on another machine.
+func CallerDir() string {
+	_, file, _, ok := runtime.Caller(1)
+	if !ok {
+		return ""
+	}
+	return filepath.Dir(file)
+}
+
+// Directories searched for file templates of components that don't set
+// `Options.TemplateDirs`, see `SetTemplateDirs`.
+var defaultTemplateDirs []string
+
+// Set the directories in which to search for file templates, for all components
+// that don't set `Options.TemplateDirs`, e.g.:
+//
+//	component.SetTemplateDirs("./templates", "/etc/helpa/templates")
+//
+// NOTE: Not safe for concurrent use. Call it before creating the components, e.g. in `init()`.
+func SetTemplateDirs(dirs ...string) {
+	defaultTemplateDirs = slices.Clone(dirs)
+}
+
+// Find the template file in the template directories. If there are none,
+// the path is resolved from `baseDir`.
+func resolveTemplatePath(templatePath string, baseDir string, templateDirs []string) (string, error) {
+	if filepath.IsAbs(templatePath) {
+		return templatePath, nil
+	}
+	if len(templateDirs) == 0 {
+		return filepath.Join(baseDir, templatePath), nil
+	}
+
+	tried := []string{}
+	for _, dir := range templateDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		candidate := filepath.Join(dir, templatePath)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+		tried = append(tried, candidate)
+	}
+	return "", eris.Wrapf(ErrTemplateNotFound, "tried %q", tried)
 }
 
 func doPrepareComponentInput[TInput any](
 	templateName string,
 	templateStr string,
 	templateIsFile bool,
-	options *Options[TInput],
-) (outTemplateStr string, replacementMap map[string]string, err error) {
+	options Options[TInput],
+) (outTemplateStr string, replacementMap map[string]string, outOptions Options[TInput], err error) {
 	outTemplateStr = templateStr
 
 	// Set defaults
@@ -366,28 +1268,56 @@ func doPrepareComponentInput[TInput any](
 	if options.MultiDocSeparator == "" {
 		options.MultiDocSeparator = "---"
 	}
+	// NOTE: Unmarshal functions don't know the component name, so warnings
+	// emitted from there are attributed to the component here.
+	onWarning := options.OnWarning
+	options.OnWarning = func(warning Warning) {
+		if warning.Component == "" {
+			warning.Component = templateName
+		}
+		emitWarning(Options[TInput]{OnWarning: onWarning}, warning)
+	}
+
+	if err := checkNilArgPolicy(options.NilArgPolicy); err != nil {
+		return outTemplateStr, replacementMap, options, eris.Wrapf(err, "in %q", templateName)
+	}
 
 	// Load the template from file
 	if templateIsFile {
-		dat, err := os.ReadFile(outTemplateStr)
+		baseDir := options.BaseDir
+		if options.TemplateBaseDir != "" {
+			baseDir = options.TemplateBaseDir
+		}
+		templateDirs := options.TemplateDirs
+		if templateDirs == nil {
+			templateDirs = defaultTemplateDirs
+		}
+
+		templatePath, err := resolveTemplatePath(outTemplateStr, baseDir, templateDirs)
+		if err != nil {
+			err = eris.Wrapf(err, "error reading file in %q", templateName)
+			return outTemplateStr, replacementMap, options, err
+		}
+
+		dat, err := os.ReadFile(templatePath)
 		if err != nil {
 			err = eris.Wrapf(err, "error reading file in %q", templateName)
-			return outTemplateStr, replacementMap, err
+			return outTemplateStr, replacementMap, options, err
 		}
 		outTemplateStr = string(dat)
 	}
 
 	// Normalize the template
-	outTemplateStr, err = options.PreprocessTemplate(outTemplateStr, *options)
+	outTemplateStr, err = options.PreprocessTemplate(outTemplateStr, options)
 	if err != nil {
-		return outTemplateStr, replacementMap, eris.Wrapf(err, "failed to preprocess template in %q", templateName)
+		return outTemplateStr, replacementMap, options, eris.Wrapf(err, "failed to preprocess template in %q", templateName)
 	}
 
 	// Add a way for users to access helm variables via go templates `{{ }}` without
 	// having those commands lost when we "pre-render" templates.
 	outTemplateStr, replacementMap = escapeHelmTemplateActions(outTemplateStr)
 
-	return outTemplateStr, replacementMap, nil
+	return outTemplateStr, replacementMap, options, nil
 }
 
 func CreateComponent[
@@ -396,12 +1326,19 @@ func CreateComponent[
 	TContext any,
 ](comp Def[TType, TInput, TContext]) (Component[TType, TInput], error) {
 	comp = comp.Copy()
+	comp.Options = applyDefaultOptions(comp.Options)
 
-	if comp.Setup == nil {
-		comp.Setup = func(t TInput) (context TContext, err error) { return context, err }
+	if comp.Setup != nil && comp.SetupCtx != nil {
+		err := eris.Wrapf(ErrSetupConflict, "in %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		} else {
+			return Component[TType, TInput]{}, err
+		}
 	}
 
-	tmpl, replMap, err := doPrepareComponentInput(comp.Name, comp.Template, comp.TemplateIsFile, &comp.Options)
+	templateSrc := comp.Template
+	tmpl, replMap, options, err := doPrepareComponentInput(comp.Name, comp.Template, comp.TemplateIsFile, comp.Options)
 	if err != nil {
 		if comp.Options.PanicOnError {
 			panic(err)
@@ -410,6 +1347,30 @@ func CreateComponent[
 		}
 	}
 	comp.Template = tmpl
+	comp.Options = options
+
+	if comp.Options.SkipTemplate && comp.Render == nil {
+		err = eris.Wrapf(ErrSkipTemplateWithoutRender, "in %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		} else {
+			return Component[TType, TInput]{}, err
+		}
+	}
+
+	err = checkReservedContextFields[TContext]()
+	if err != nil {
+		err = eris.Wrapf(err, "in %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		} else {
+			return Component[TType, TInput]{}, err
+		}
+	}
+
+	renderCfg := newRenderConfig(comp.Options)
+	renderCfg.replMap = replMapToBytes(replMap)
+	renderCfg.componentInfo = newComponentInfo(comp.Name, templateSrc)
 
 	// Resulting function is wrapped in a Struct so it's easier to type,
 	// so we can use:
@@ -418,14 +1379,47 @@ func CreateComponent[
 	// Instead of manually typing:
 	// `func(input TInput) (instance TType, content string, err error)`
 	component := Component[TType, TInput]{
-		Render: func(input TInput) (instance TType, content string, err error) {
+		RenderWithValues: func(input TInput, overrides map[string]any) (instance TType, content string, err error) {
+			// Tell apart where the panic occurred. With `PanicOnError`, the panics are
+			// left to propagate, as that's what the user asked for.
+			stage := stageSetup
+			defer func() {
+				if comp.Options.PanicOnError {
+					return
+				}
+				if r := recover(); r != nil {
+					err = panicToError(comp.Name, stage, r)
+				}
+			}()
+
+			deadline, cancel := newRenderDeadline(comp.Options.Timeout)
+			defer cancel()
+			setup := newRenderSetup(deadline.ctx, comp.Setup, comp.SetupCtx, comp.SetupMiddleware, comp.PostSetup)
+
 			finalInput := input
 			if comp.Defaults != nil {
 				defaults := comp.Defaults()
-				utils.ApplyDefaults(&finalInput, defaults)
+				err = utils.ApplyDefaults(&finalInput, defaults)
+				if err != nil {
+					err = eris.Wrapf(err, "failed to apply defaults in %q", comp.Name)
+					if comp.Options.PanicOnError {
+						panic(err)
+					} else {
+						return instance, content, err
+					}
+				}
+			}
+
+			err = checkDeprecatedInput(comp.Name, finalInput, comp.Options)
+			if err != nil {
+				if comp.Options.PanicOnError {
+					panic(err)
+				} else {
+					return instance, content, err
+				}
 			}
 
-			context, err := comp.Setup(finalInput)
+			err = checkInput(comp.Name, finalInput, comp.Options)
 			if err != nil {
 				if comp.Options.PanicOnError {
 					panic(err)
@@ -434,7 +1428,9 @@ func CreateComponent[
 				}
 			}
 
-			content, err = Render(comp.Name, comp.Template, context)
+			context, err := runWithDeadline(deadline, comp.Name, stageSetup, func() (TContext, error) {
+				return setup(finalInput)
+			})
 			if err != nil {
 				if comp.Options.PanicOnError {
 					panic(err)
@@ -443,11 +1439,60 @@ func CreateComponent[
 				}
 			}
 
-			// Put back the bits that we've removed previously so that they get rendered by Helm
-			content = unescapeHelmTemplateActions(content, replMap)
+			context, err = applyOverrides(context, overrides)
+			if err != nil {
+				err = eris.Wrapf(err, "failed to apply overrides in %q", comp.Name)
+				if comp.Options.PanicOnError {
+					panic(err)
+				} else {
+					return instance, content, err
+				}
+			}
+
+			// NOTE: With `SkipTemplate`, the template is not even parsed
+			stage = stageRender
+			if !comp.Options.SkipTemplate {
+				content, err = runWithDeadline(deadline, comp.Name, stageRender, func() (string, error) {
+					return doRender(comp.Name, comp.Template, context, renderCfg)
+				})
+				if err != nil {
+					if comp.Options.PanicOnError {
+						panic(err)
+					} else {
+						return instance, content, err
+					}
+				}
+			}
+
+			stage = stageUnmarshal
+			if comp.Options.ValidateYaml {
+				err = checkYamlSyntax(content)
+				if err != nil {
+					err = eris.Wrapf(err, "render error in %q", comp.Name)
+					if comp.Options.PanicOnError {
+						panic(err)
+					} else {
+						return instance, content, err
+					}
+				}
+			}
+
+			if comp.Options.NormalizeSecrets {
+				_, err = checkSecretDocs(content, comp.Options)
+				if err != nil {
+					err = eris.Wrapf(err, "render error in %q", comp.Name)
+					if comp.Options.PanicOnError {
+						panic(err)
+					} else {
+						return instance, content, err
+					}
+				}
+			}
 
 			if comp.Render != nil {
-				instance, err = comp.Render(finalInput, context, content)
+				instance, err = runWithDeadline(deadline, comp.Name, stageCustomRender, func() (TType, error) {
+					return comp.Render(finalInput, context, content)
+				})
 			} else {
 				// Unmarshal the generated structured data to ensure that they are valid.
 				instance, err = doUnmarshalOne[TType](comp.Name, content, comp.Options)
@@ -460,8 +1505,67 @@ func CreateComponent[
 				}
 			}
 
+			if comp.Options.NormalizeSecrets {
+				normalizeSecretsIn(reflect.ValueOf(&instance))
+			}
+
+			if comp.Options.ContentFromInstance {
+				content, err = instanceToYaml(instance)
+				if err != nil {
+					err = eris.Wrapf(err, "render error in %q", comp.Name)
+					if comp.Options.PanicOnError {
+						panic(err)
+					} else {
+						return instance, content, err
+					}
+				}
+			}
+
 			return instance, content, nil
 		},
+		Analyze: func() (Analysis, error) {
+			return analyzeTemplate(comp.Name, comp.Template, replMap)
+		},
+	}
+
+	component.Render = func(input TInput) (instance TType, content string, err error) {
+		return component.RenderWithValues(input, nil)
+	}
+
+	if comp.Options.MemoizeRenders {
+		render := component.Render
+		memoized := memoizeRender(comp.Name, comp.Options, func(input TInput) (renderResult[TType], error) {
+			instance, content, err := render(input)
+			return renderResult[TType]{instance: instance, content: content}, err
+		})
+		component.Render = func(input TInput) (instance TType, content string, err error) {
+			result, err := memoized(input)
+			return result.instance, result.content, err
+		}
+	}
+
+	component.RenderJSON = func(input TInput) (instance TType, data []byte, err error) {
+		instance, content, err := component.Render(input)
+		if err != nil {
+			return instance, nil, err
+		}
+		data, err = toCanonicalJSON(comp.Name, instance, content, replMap)
+		return instance, data, err
+	}
+
+	component.Hash = func(input TInput) (string, error) {
+		_, content, err := component.Render(input)
+		if err != nil {
+			return "", err
+		}
+		return hashContent(content), nil
+	}
+
+	component.Validate = func(inputs ...TInput) error {
+		return validateInputs(comp.Name, func(input TInput) error {
+			_, _, err := component.Render(input)
+			return err
+		}, inputs)
 	}
 
 	// If frontloading is enabled, we will make a dummy call to the `component.Render`
@@ -478,6 +1582,15 @@ func CreateComponent[
 		}
 	}
 
+	if comp.Options.Register {
+		register(comp.Name, func() error {
+			return validateInput(func(input TInput) error {
+				_, _, err := component.Render(input)
+				return err
+			}, comp.Options.FrontloadInput)
+		})
+	}
+
 	return component, nil
 }
 
@@ -487,12 +1600,19 @@ func CreateComponentMulti[
 	TContext any,
 ](comp DefMulti[TType, TInput, TContext]) (ComponentMulti[TType, TInput], error) {
 	comp = comp.Copy()
+	comp.Options = applyDefaultOptions(comp.Options)
 
-	if comp.Setup == nil {
-		comp.Setup = func(t TInput) (context TContext, err error) { return context, err }
+	if comp.Setup != nil && comp.SetupCtx != nil {
+		err := eris.Wrapf(ErrSetupConflict, "in %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		} else {
+			return ComponentMulti[TType, TInput]{}, err
+		}
 	}
 
-	tmpl, replMap, err := doPrepareComponentInput(comp.Name, comp.Template, comp.TemplateIsFile, &comp.Options)
+	templateSrc := comp.Template
+	tmpl, replMap, options, err := doPrepareComponentInput(comp.Name, comp.Template, comp.TemplateIsFile, comp.Options)
 	if err != nil {
 		if comp.Options.PanicOnError {
 			panic(err)
@@ -501,41 +1621,130 @@ func CreateComponentMulti[
 		}
 	}
 	comp.Template = tmpl
+	comp.Options = options
 
-	// Resulting function is wrapped in a Struct so it's easier to type,
-	// so we can use:
-	// `ComponentMulti[TType, TInput].Render`
-	//
-	// Instead of manually typing:
-	// `func(input TInput) (instance TType, []contentParts string, err error)`
-	component := ComponentMulti[TType, TInput]{
-		Render: func(input TInput) (instances []TType, contentParts []string, err error) {
-			finalInput := input
-			if comp.Defaults != nil {
-				defaults := comp.Defaults()
-				utils.ApplyDefaults(&finalInput, defaults)
+	if comp.Options.SkipTemplate && comp.Render == nil {
+		err = eris.Wrapf(ErrSkipTemplateWithoutRender, "in %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		} else {
+			return ComponentMulti[TType, TInput]{}, err
+		}
+	}
+
+	err = checkReservedContextFields[TContext]()
+	if err != nil {
+		err = eris.Wrapf(err, "in %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		} else {
+			return ComponentMulti[TType, TInput]{}, err
+		}
+	}
+
+	renderCfg := newRenderConfig(comp.Options)
+	renderCfg.replMap = replMapToBytes(replMap)
+	renderCfg.componentInfo = newComponentInfo(comp.Name, templateSrc)
+
+	renderDetailedWithValues := func(input TInput, overrides map[string]any) (result RenderMultiResult[TType], err error) {
+		var instances []TType
+		var contentParts []string
+		var escapedActions []string
+
+		// Tell apart where the panic occurred. With `PanicOnError`, the panics are
+		// left to propagate, as that's what the user asked for.
+		stage := stageSetup
+		defer func() {
+			if comp.Options.PanicOnError {
+				return
+			}
+			if r := recover(); r != nil {
+				result = RenderMultiResult[TType]{Instances: instances, Contents: contentParts}
+				err = panicToError(comp.Name, stage, r)
 			}
+		}()
+
+		// All errors leave through here, so they all tell the component, stage
+		// and document they come from.
+		fail := func(err error, docIndex int) (RenderMultiResult[TType], error) {
+			err = wrapStageError(err, comp.Name, stage, docIndex)
+			if comp.Options.PanicOnError {
+				panic(err)
+			}
+			return RenderMultiResult[TType]{Instances: instances, Contents: contentParts}, err
+		}
 
-			context, err := comp.Setup(finalInput)
+		deadline, cancel := newRenderDeadline(comp.Options.Timeout)
+		defer cancel()
+		setup := newRenderSetup(deadline.ctx, comp.Setup, comp.SetupCtx, comp.SetupMiddleware, comp.PostSetup)
+
+		finalInput := input
+		if comp.Defaults != nil {
+			defaults := comp.Defaults()
+			err = utils.ApplyDefaults(&finalInput, defaults)
 			if err != nil {
-				if comp.Options.PanicOnError {
-					panic(err)
-				} else {
-					return instances, contentParts, err
-				}
+				return fail(eris.Wrap(err, "failed to apply defaults"), -1)
 			}
+		}
 
-			content, err := Render(comp.Name, comp.Template, context)
-			if err != nil {
-				if comp.Options.PanicOnError {
-					panic(err)
-				} else {
-					return instances, contentParts, err
-				}
+		err = checkDeprecatedInput(comp.Name, finalInput, comp.Options)
+		if err != nil {
+			return fail(err, -1)
+		}
+
+		err = checkInput(comp.Name, finalInput, comp.Options)
+		if err != nil {
+			return fail(err, -1)
+		}
+
+		context, err := runWithDeadline(deadline, comp.Name, stageSetup, func() (TContext, error) {
+			return setup(finalInput)
+		})
+		if err != nil {
+			return fail(err, -1)
+		}
+
+		context, err = applyOverrides(context, overrides)
+		if err != nil {
+			return fail(eris.Wrap(err, "failed to apply overrides"), -1)
+		}
+
+		// Maps the documents to their positions in the template, see `omitNilInstances`
+		var docIndices []int
+		templateIndex := func(index int) int {
+			if index >= 0 && index < len(docIndices) {
+				return docIndices[index]
 			}
+			return index
+		}
 
-			// Put back the bits that we've removed previously so that they get rendered by Helm
-			content = unescapeHelmTemplateActions(content, replMap)
+		// NOTE: With `SkipTemplate`, the template is not even parsed, and `Render`
+		// gets no documents.
+		if !comp.Options.SkipTemplate {
+			stage = stageRender
+			// NOTE: On timeout, the render is left running, so it collects
+			// the escaped actions for itself, see `runWithDeadline`.
+			type renderOutput struct {
+				content        string
+				escapedActions []string
+			}
+			out, err := runWithDeadline(deadline, comp.Name, stageRender, func() (renderOutput, error) {
+				var out renderOutput
+				cfg := renderCfg
+				cfg.onEscapedAction = func(action string) {
+					out.escapedActions = append(out.escapedActions, action)
+				}
+				content, err := doRender(comp.Name, comp.Template, context, cfg)
+				out.content = content
+				return out, err
+			})
+			content := out.content
+			escapedActions = out.escapedActions
+			if err != nil {
+				// Return what was rendered up to the failure, so it can be inspected
+				contentParts, _ = SplitDocs(content, comp.Options)
+				return fail(err, -1)
+			}
 
 			// In Helm files, it's common to use `---` to define multiple independent
 			// resources. To support that, we try to split the rendered file into an array
@@ -543,51 +1752,219 @@ func CreateComponentMulti[
 			//
 			// NOTE: In such case, the `TType` instance that the user provided should
 			// itself be an Array/Slice.
-			contentParts = strings.Split(content, comp.Options.MultiDocSeparator)
+			stage = stageSplit
+			contentParts, err = SplitDocs(content, comp.Options)
+			if err != nil {
+				return fail(err, -1)
+			}
 
 			// Allow the author of the component to specify exact instances that should be populated
 			// with the extracted data. This way, they can specify an interface for the instances' type,
 			// and then create homogenous array of specific length (assuming all elements implement
 			// the interface).
 			//
-			// But if author didn't specify this array,
-			instances, err = comp.GetInstances(finalInput, context)
+			// But if author didn't specify this array, we create an instance for each
+			// document with `InstanceFor`.
+			if comp.GetInstances == nil {
+				instances, err = instancesForDocs(len(contentParts), comp.InstanceFor, comp.MinDocs, comp.MaxDocs)
+			} else {
+				instances, err = comp.GetInstances(finalInput, context)
+			}
 			if err != nil {
-				if comp.Options.PanicOnError {
-					panic(err)
-				} else {
-					return instances, contentParts, err
-				}
+				return fail(err, -1)
 			}
 
 			if len(instances) != len(contentParts) {
-				err = eris.Wrapf(ErrComponentRenderResultMismatch, "found %v documents in the template, but there is %v instances to unmarshal the data to. These must match. Review the component's `GetInstances` method and the template", len(contentParts), len(instances))
-				return instances, contentParts, err
+				err = eris.Wrapf(ErrComponentRenderResultMismatch, "found %v documents in the template, but there is %v instances to unmarshal the data to. These must match. Review the component's `GetInstances` method and the template:\n%s", len(contentParts), len(instances), describeMismatch(contentParts, instances))
+				return fail(err, -1)
 			}
 
-			if comp.Render != nil {
-				instances, err = comp.Render(finalInput, context, contentParts)
-			} else {
-				// Unmarshal the generated structured data to ensure that they are valid.
-				instances, err = doUnmarshalMulti(comp.Name, contentParts, comp.Options, instances)
+			// Drop the documents that should be omitted. From here on, `docIndices` maps
+			// the remaining documents to their positions in the template.
+			instances, contentParts, docIndices = omitNilInstances(instances, contentParts)
+
+			if comp.Options.ValidateYaml {
+				stage = stageUnmarshal
+				for index, doc := range contentParts {
+					err = checkYamlSyntax(doc)
+					if err != nil {
+						return fail(err, templateIndex(index))
+					}
+				}
+			}
+
+			if comp.Options.NormalizeSecrets {
+				stage = stageUnmarshal
+				for index, doc := range contentParts {
+					err = checkSecretData(doc)
+					if err != nil {
+						return fail(err, templateIndex(index))
+					}
+				}
+			}
+		}
+
+		if comp.Render != nil {
+			stage = stageCustomRender
+			instances, err = runWithDeadline(deadline, comp.Name, stageCustomRender, func() ([]TType, error) {
+				return comp.Render(finalInput, context, contentParts)
+			})
+			if err != nil {
+				return fail(err, -1)
+			}
+		} else {
+			// Unmarshal the generated structured data to ensure that they are valid.
+			stage = stageUnmarshal
+			if err = deadline.check(comp.Name, stage); err != nil {
+				return fail(err, -1)
 			}
+			var docIndex int
+			instances, docIndex, err = doUnmarshalMulti(contentParts, comp.Options, instances, templateIndex)
 			if err != nil {
-				if comp.Options.PanicOnError {
-					panic(err)
-				} else {
-					return instances, contentParts, err
+				return fail(err, templateIndex(docIndex))
+			}
+		}
+
+		if comp.Options.NormalizeSecrets {
+			normalizeSecretsIn(reflect.ValueOf(instances))
+		}
+
+		// NOTE: One document per instance, even if `Render` returned a different
+		// number of instances than there were documents
+		if comp.Options.ContentFromInstance {
+			stage = stageUnmarshal
+			contents := []string{}
+			for index, instance := range instances {
+				doc, err := instanceToYaml(instance)
+				if err != nil {
+					return fail(err, templateIndex(index))
 				}
+				contents = append(contents, doc)
 			}
+			contentParts = contents
+		}
+
+		result = RenderMultiResult[TType]{Instances: instances, Contents: contentParts, EscapedActions: escapedActions}
+		if comp.Validate == nil {
+			return result, nil
+		}
 
-			return instances, contentParts, nil
+		// Validate the instances, and either fail or drop the invalid ones.
+		stage = stageValidate
+		result.Instances = []TType{}
+		result.Contents = []string{}
+		for index, instance := range instances {
+			// NOTE: Custom `Render` may return different number of instances than
+			// there are documents.
+			docContent := ""
+			if index < len(contentParts) {
+				docContent = contentParts[index]
+			}
+
+			docIndex := templateIndex(index)
+			if err = deadline.check(comp.Name, stage); err != nil {
+				return fail(err, docIndex)
+			}
+			err = comp.Validate(instance, docIndex)
+			if err == nil {
+				result.Instances = append(result.Instances, instance)
+				result.Contents = append(result.Contents, docContent)
+				continue
+			}
+
+			if !comp.Options.SkipInvalid {
+				return fail(err, docIndex)
+			}
+			err = wrapStageError(err, comp.Name, stage, docIndex)
+			result.Skipped = append(result.Skipped, SkippedDocument{Index: docIndex, Content: docContent, Err: err})
+		}
+
+		return result, nil
+	}
+
+	renderDetailed := func(input TInput) (result RenderMultiResult[TType], err error) {
+		return renderDetailedWithValues(input, nil)
+	}
+	if comp.Options.MemoizeRenders {
+		renderDetailed = memoizeRender(comp.Name, comp.Options, renderDetailed)
+	}
+
+	// Resulting function is wrapped in a Struct so it's easier to type,
+	// so we can use:
+	// `ComponentMulti[TType, TInput].Render`
+	//
+	// Instead of manually typing:
+	// `func(input TInput) (instance TType, []contentParts string, err error)`
+	component := ComponentMulti[TType, TInput]{
+		Render: func(input TInput) (instances []TType, contentParts []string, err error) {
+			result, err := renderDetailed(input)
+			return result.Instances, result.Contents, err
+		},
+		RenderDetailed: renderDetailed,
+		RenderWithValues: func(input TInput, overrides map[string]any) (instances []TType, contents []string, err error) {
+			result, err := renderDetailedWithValues(input, overrides)
+			return result.Instances, result.Contents, err
+		},
+		Analyze: func() (Analysis, error) {
+			return analyzeTemplate(comp.Name, comp.Template, replMap)
 		},
 	}
+	component.RenderIndex = func(input TInput, index int) (instance TType, content string, err error) {
+		instances, contents, err := component.Render(input)
+		if err != nil {
+			return instance, content, err
+		}
+		if index < 0 || index >= len(instances) {
+			err = eris.Wrapf(ErrDocumentIndexOutOfRange, "index %v in %q, which has %v documents", index, comp.Name, len(instances))
+			return instance, content, err
+		}
+		return instances[index], contents[index], nil
+	}
+
+	component.RenderJSON = func(input TInput) (instances []TType, data [][]byte, err error) {
+		instances, contents, err := component.Render(input)
+		if err != nil {
+			return instances, nil, err
+		}
+		for index, instance := range instances {
+			// NOTE: Custom `Render` may return different number of instances than there are documents
+			content := ""
+			if index < len(contents) {
+				content = contents[index]
+			}
+			instanceData, err := toCanonicalJSON(comp.Name, instance, content, replMap)
+			if err != nil {
+				return instances, data, eris.Wrapf(err, "failed to marshal instance at index %v", index)
+			}
+			data = append(data, instanceData)
+		}
+		return instances, data, nil
+	}
+
+	component.Hash = func(input TInput) (string, error) {
+		_, contents, err := component.Render(input)
+		if err != nil {
+			return "", err
+		}
+		return hashContents(contents), nil
+	}
+
+	component.Validate = func(inputs ...TInput) error {
+		return validateInputs(comp.Name, func(input TInput) error {
+			_, _, err := component.Render(input)
+			return err
+		}, inputs)
+	}
 
 	// If frontloading is enabled, we will make a dummy call to the `component.Render`
 	// method at component creation, to ensure that everything works correctly,
-	// especially the unmarshalling of a textual template.
+	// especially the unmarshalling of a textual template, and that the number
+	// of documents in the template matches the instances from `GetInstances`.
 	if comp.Options.FrontloadEnabled {
 		_, _, err = component.Render(comp.Options.FrontloadInput)
+		if err != nil {
+			err = eris.Wrapf(err, "frontload failed in %q", comp.Name)
+		}
 	}
 	if err != nil {
 		if comp.Options.PanicOnError {
@@ -597,5 +1974,14 @@ func CreateComponentMulti[
 		}
 	}
 
+	if comp.Options.Register {
+		register(comp.Name, func() error {
+			return validateInput(func(input TInput) error {
+				_, _, err := component.Render(input)
+				return err
+			}, comp.Options.FrontloadInput)
+		})
+	}
+
 	return component, nil
 }
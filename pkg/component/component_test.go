@@ -2,11 +2,23 @@ package component
 
 import (
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jurooravec/helpa/pkg/serializers"
 	"github.com/jurooravec/helpa/pkg/utils"
 	assert "github.com/stretchr/testify/assert"
 	k8s "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 type Input struct {
@@ -16,6 +28,7 @@ type Input struct {
 
 type Context struct {
 	Number string
+	Name   string
 	Catify func(s string) string
 }
 
@@ -28,12 +41,22 @@ func setupComponentInline[T any](
 	template string,
 	render func(Input, Context, string) (T, error),
 	defaults func() Input,
+) (Component[T, Input], error) {
+	return setupComponentInlineWithOptions(template, render, defaults, Options[Input]{})
+}
+
+func setupComponentInlineWithOptions[T any](
+	template string,
+	render func(Input, Context, string) (T, error),
+	defaults func() Input,
+	options Options[Input],
 ) (Component[T, Input], error) {
 	return CreateComponent(
 		Def[T, Input, Context]{
 			Setup: func(input Input) (Context, error) {
 				context := Context{
 					Number: fmt.Sprint(input.Number),
+					Name:   input.Name,
 					Catify: func(s string) string {
 						return fmt.Sprintf("🐈 %s 🐈", s)
 					},
@@ -43,6 +66,7 @@ func setupComponentInline[T any](
 			Defaults: defaults,
 			Template: template,
 			Render:   render,
+			Options:  options,
 		},
 	)
 }
@@ -52,12 +76,23 @@ func setupComponentMultiInline[T any](
 	makeInstances func(Input, Context) ([]T, error),
 	render func(Input, Context, []string) ([]T, error),
 	defaults func() Input,
+) (ComponentMulti[T, Input], error) {
+	return setupComponentMultiInlineWithOptions(template, makeInstances, render, defaults, Options[Input]{})
+}
+
+func setupComponentMultiInlineWithOptions[T any](
+	template string,
+	makeInstances func(Input, Context) ([]T, error),
+	render func(Input, Context, []string) ([]T, error),
+	defaults func() Input,
+	options Options[Input],
 ) (ComponentMulti[T, Input], error) {
 	return CreateComponentMulti(
 		DefMulti[T, Input, Context]{
 			Setup: func(input Input) (Context, error) {
 				context := Context{
 					Number: fmt.Sprint(input.Number),
+					Name:   input.Name,
 					Catify: func(s string) string {
 						return fmt.Sprintf("🐈 %s 🐈", s)
 					},
@@ -68,6 +103,7 @@ func setupComponentMultiInline[T any](
 			Defaults:     defaults,
 			Template:     template,
 			Render:       render,
+			Options:      options,
 		},
 	)
 }
@@ -238,6 +274,48 @@ func TestComponentInlineEscape(t *testing.T) {
 	assert.Equal("Hello: 🐈 2 🐈 {{ .Releases.Some.Path }}", content)
 }
 
+func TestComponentMultiEscapedActions(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[runtime.Object, Input, Input]{
+			Name: "Kuard",
+			Template: `
+			apiVersion: apps/v1
+			kind: Deployment
+			metadata:
+			  name: {{ .Helpa.Name }}
+			  namespace: "{{! .Values.namespace }}"
+			spec:
+			  template:
+			    spec:
+			      containers:
+			      - name: {{ .Helpa.Name }}
+			        image: "gcr.io/kuar-demo/kuard-amd64:{{! .Values.image.tag }}"
+			---
+			apiVersion: v1
+			kind: Service
+			metadata:
+			  name: {{ .Helpa.Name }}
+			`,
+			Setup: func(input Input) (Input, error) { return input, nil },
+			GetInstances: func(Input, Input) ([]runtime.Object, error) {
+				return []runtime.Object{&k8s.Deployment{}, &corev1.Service{}}, nil
+			},
+			Options: Options[Input]{TabSize: utils.PointerOf(2)},
+		},
+	)
+	assert.Nil(err)
+
+	result, err := comp.RenderDetailed(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal([]string{"{{ .Values.namespace }}", "{{ .Values.image.tag }}"}, result.EscapedActions)
+
+	report, err := serializers.ReportEscapedActions(map[string][]string{"Kuard": result.EscapedActions})
+	assert.Nil(err)
+	assert.Equal([]string{"image.tag", "namespace"}, report.ValuesPaths)
+}
+
 func TestComponentFrontloadFailsAtInit(t *testing.T) {
 	assert := assert.New(t)
 	inputAtInit := Input{}
@@ -308,6 +386,23 @@ func TestComponentMultiFrontloadFailsAtInit(t *testing.T) {
 	assert.Equal(3, inputAtInit.Number)
 }
 
+func TestComponentMultiFrontloadMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	// Unmarshalling into `any` would succeed, but the counts don't match
+	_, err := setupComponentMultiFrontload(
+		func(Input, Context) ([]any, error) {
+			return []any{nil, nil, nil}, nil
+		},
+		Input{Number: 3},
+	)
+	assert.ErrorIs(err, ErrComponentRenderResultMismatch)
+	assert.Contains(err.Error(), "frontload failed")
+	assert.Contains(err.Error(), "found 2 documents in the template, but there is 3 instances")
+	assert.Contains(err.Error(), `document 1: "my: cool" -> <nil>`)
+	assert.Contains(err.Error(), "document 2: <missing> -> <nil>")
+}
+
 func TestComponentRender(t *testing.T) {
 	assert := assert.New(t)
 
@@ -332,7 +427,7 @@ func TestComponentRender(t *testing.T) {
 
 	// NOTE: Currently, when user overrides the `Render` function, we still keep around the rendered
 	// content, so that user may work with it inside the `Render` function.
-	// So the `content` var should match the "old" spec.
+	// So the `content` var should match the "old" spec, see `Options.ContentFromInstance`.
 	assert.Contains(content, "cool")
 	// But the `instance` was was returned from the Render function, so that should match
 	// the the "new" spec.
@@ -373,7 +468,7 @@ func TestComponentMultiRender(t *testing.T) {
 
 	// NOTE: Currently, when user overrides the `Render` function, we still keep around the rendered
 	// content, so that user may work with it inside the `Render` function.
-	// So the `content` var should match the "old" spec.
+	// So the `content` var should match the "old" spec, see `Options.ContentFromInstance`.
 	assert.Contains(contents[0], "cool")
 	// But the `instance` was was returned from the Render function, so that should match
 	// the the "new" spec.
@@ -381,6 +476,50 @@ func TestComponentMultiRender(t *testing.T) {
 	assert.Equal([]string{"My super container", "gcr.io/wow-so-great:1"}, instances[0].Spec)
 }
 
+func TestComponentContentFromInstance(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[FromFileSpec, Input, Context]{
+			Template: `my: cool`,
+			Render: func(Input, Context, string) (FromFileSpec, error) {
+				return FromFileSpec{My: "changed", Spec: []string{"Hello"}}, nil
+			},
+			Options: Options[Input]{ContentFromInstance: true},
+		},
+	)
+	assert.Nil(err)
+
+	instance, content, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("changed", instance.My)
+	assert.Equal("my: changed\nspec:\n- Hello\n", content)
+}
+
+func TestComponentMultiContentFromInstance(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[FromFileSpec, Input, Context]{
+			Template: "my: cool\n---\nmy: cool",
+			GetInstances: func(Input, Context) ([]FromFileSpec, error) {
+				return []FromFileSpec{{}, {}}, nil
+			},
+			// Fewer instances than documents
+			Render: func(Input, Context, []string) ([]FromFileSpec, error) {
+				return []FromFileSpec{{My: "changed"}}, nil
+			},
+			Options: Options[Input]{ContentFromInstance: true},
+		},
+	)
+	assert.Nil(err)
+
+	instances, contents, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Len(instances, 1)
+	assert.Equal([]string{"my: changed\nspec: null\n"}, contents)
+}
+
 func TestComponentDefaults(t *testing.T) {
 	assert := assert.New(t)
 
@@ -415,6 +554,35 @@ func TestComponentMultiDefaults(t *testing.T) {
 	assert.Equal("\nHello: 🐈 13 🐈", contents[1])
 }
 
+func TestComponentDefaultsLeaveInput(t *testing.T) {
+	assert := assert.New(t)
+
+	type LabelsInput struct {
+		Labels map[string]string
+	}
+	comp, err := CreateComponent(
+		Def[any, LabelsInput, LabelsInput]{
+			Template: `labels: {{ toJson .Helpa.Labels }}`,
+			Setup:    func(input LabelsInput) (LabelsInput, error) { return input, nil },
+			Defaults: func() LabelsInput {
+				return LabelsInput{Labels: map[string]string{"b": "default"}}
+			},
+		},
+	)
+	assert.Nil(err)
+
+	input := LabelsInput{Labels: map[string]string{"a": "mine"}}
+	_, content, err := comp.Render(input)
+	assert.Nil(err)
+	assert.Equal(`labels: {"a":"mine","b":"default"}`, content)
+	assert.Equal(map[string]string{"a": "mine"}, input.Labels)
+
+	// Same input renders the same
+	_, content2, err := comp.Render(input)
+	assert.Nil(err)
+	assert.Equal(content, content2)
+}
+
 func BenchmarkCreateComponentFromMulti(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		comp, _ := setupComponentMulti(
@@ -427,6 +595,88 @@ func BenchmarkCreateComponentFromMulti(b *testing.B) {
 	}
 }
 
+// Template that renders to roughly 100KB, split across 400 documents
+var benchmarkLargeTemplate = `
+{{- range $i := until 400 }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-{{ $i }}
+  labels:
+    app.kubernetes.io/name: {{ Catify $.Helpa.Number }}
+    app.kubernetes.io/instance: {{! .Release.Name }}
+data:
+  index: "{{ $i }}"
+  missing: "{{ (dict).missing }}"
+  release: "{{! .Release.Namespace }}"
+  padding: "lorem ipsum dolor sit amet, consectetur adipiscing elit"
+{{- end }}
+`
+
+func benchmarkRender(b *testing.B, template string) {
+	comp, err := setupComponentInline(
+		template,
+		func(_ Input, _ Context, content string) (string, error) {
+			return content, nil
+		},
+		nil,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err = comp.Render(Input{Number: 2})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderSmall(b *testing.B) {
+	benchmarkRender(b, `Hello: {{ Catify .Helpa.Number }} {{! .Releases.Some.Path }} {{ (dict).missing }}`)
+}
+
+func BenchmarkRenderLarge(b *testing.B) {
+	benchmarkRender(b, benchmarkLargeTemplate)
+}
+
+func benchmarkRenderMinimal(b *testing.B, options Options[Input]) {
+	comp, err := CreateComponent(
+		Def[string, Input, Input]{
+			Template: "name: {{ .Helpa.Name }}",
+			Setup:    func(input Input) (Input, error) { return input, nil },
+			Render: func(_ Input, _ Input, content string) (string, error) {
+				return content, nil
+			},
+			Options: options,
+		},
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err = comp.Render(Input{Name: "kuard"})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderMinimal(b *testing.B) {
+	benchmarkRenderMinimal(b, Options[Input]{})
+}
+
+func BenchmarkRenderMinimalDisableHelmfileFuncs(b *testing.B) {
+	benchmarkRenderMinimal(b, Options[Input]{DisableHelmfileFuncs: true})
+}
+
 func TestRender(t *testing.T) {
 	assert := assert.New(t)
 	content, err := Render(
@@ -437,3 +687,1157 @@ func TestRender(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal("HelmFn: bo_b, HelmfileFn: false", content)
 }
+
+func TestComponentDisableHelmfileFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[any, Input, Input]{
+			Name:     "NoHelmfile",
+			Template: `HelmFn: {{ snakecase .Helpa.Name }}, HelmfileFn: {{ isFile "lol" }}`,
+			Setup:    func(input Input) (Input, error) { return input, nil },
+			Render: func(_ Input, _ Input, content string) (any, error) {
+				return content, nil
+			},
+			Options: Options[Input]{DisableHelmfileFuncs: true},
+		},
+	)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{Name: "BoB"})
+	assert.ErrorContains(err, `function "isFile" not defined`)
+
+	// Helm's functions are still available
+	content, err := doRender("NoHelmfile", `{{ snakecase .Helpa.Name }}`, Input{Name: "BoB"}, renderConfig{disableHelmfileFuncs: true})
+	assert.Nil(err)
+	assert.Equal("bo_b", content)
+}
+
+func TestComponentMultilineScript(t *testing.T) {
+	assert := assert.New(t)
+
+	type ScriptContext struct {
+		Script string
+	}
+
+	script := "#!/bin/sh\r\nset -e\r\n\r\necho \"Hello $1\"\r\n"
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, ScriptContext]{
+			Name: "ScriptConfigMap",
+			Template: `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: scripts
+			data:
+			  script.sh: {{ multiline 4 .Helpa.Script }}
+			  other.sh: {{ multiline 4 "exit 0" }}
+			`,
+			Setup: func(input Input) (ScriptContext, error) {
+				return ScriptContext{Script: script}, nil
+			},
+			Options: Options[Input]{
+				TabSize: utils.PointerOf(2),
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("#!/bin/sh\nset -e\n\necho \"Hello $1\"\n", instance.Data["script.sh"])
+	assert.Equal("exit 0", instance.Data["other.sh"])
+}
+
+func TestComponentBlockScalarConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	type NginxContext struct {
+		Config string
+	}
+
+	config := "server {\n  listen 80;\n\n  location / {\n    root /usr/share/nginx/html;\n  }\n}\n"
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, NginxContext]{
+			Name: "NginxConfigMap",
+			Template: `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: nginx
+			data:
+			  nginx.conf: {{ toBlockScalar 4 .Helpa.Config }}
+			`,
+			Setup: func(input Input) (NginxContext, error) {
+				return NginxContext{Config: config}, nil
+			},
+			Options: Options[Input]{
+				TabSize: utils.PointerOf(2),
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, content, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal(config, instance.Data["nginx.conf"])
+	assert.Contains(content, "  nginx.conf: |\n    server {\n      listen 80;\n\n")
+}
+
+func TestRenderServiceURL(t *testing.T) {
+	assert := assert.New(t)
+
+	content, err := Render(
+		"TestServiceURL",
+		`url: {{ svcURL "http" "kuard" "default" 8080 }}, custom: {{ svcDNS "kuard" "default" "my.cluster" }}`,
+		Input{},
+	)
+	assert.Nil(err)
+	assert.Equal("url: http://kuard.default.svc.cluster.local:8080, custom: kuard.default.svc.my.cluster", content)
+
+	_, err = Render("TestServiceURL", `url: {{ svcURL "http" "Kuard_1" "default" 8080 }}`, Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid service name \"Kuard_1\"")
+}
+
+func TestRenderQuantityDuration(t *testing.T) {
+	assert := assert.New(t)
+
+	type UnitsContext struct {
+		Memory  resource.Quantity
+		Timeout metav1.Duration
+	}
+
+	content, err := Render(
+		"TestUnits",
+		`memory: {{ quantity .Helpa.Memory }}, timeout: {{ duration .Helpa.Timeout }}`,
+		UnitsContext{
+			Memory:  resource.MustParse("500Mi"),
+			Timeout: metav1.Duration{Duration: 30 * time.Second},
+		},
+	)
+	assert.Nil(err)
+	assert.Equal("memory: 500Mi, timeout: 30s", content)
+}
+
+func TestRenderDget(t *testing.T) {
+	assert := assert.New(t)
+
+	type LookupContext struct {
+		Values map[string]any
+	}
+
+	context := LookupContext{
+		Values: map[string]any{
+			"ingress": map[string]any{
+				"hosts": []any{map[string]any{"host": "example.com"}},
+			},
+		},
+	}
+
+	content, err := Render(
+		"TestDget",
+		`host: {{ dget "ingress.hosts.0.host" .Helpa.Values }}, tls: {{ dget "ingress.tls" .Helpa.Values | default "none" }}`,
+		context,
+	)
+	assert.Nil(err)
+	assert.Equal("host: example.com, tls: none", content)
+
+	_, err = Render("TestDget", `{{ mustDget "ingress.tls" .Helpa.Values }}`, context)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "PathNotFound")
+}
+
+func TestComponentRandSeed(t *testing.T) {
+	assert := assert.New(t)
+
+	createComp := func(seed *int64) Component[corev1.ConfigMap, Input] {
+		comp, err := CreateComponent(
+			Def[corev1.ConfigMap, Input, struct{}]{
+				Name: "RandomConfigMap",
+				Template: `
+				apiVersion: v1
+				kind: ConfigMap
+				metadata:
+				  name: random-{{ randAlphaNum 6 | lower }}
+				data:
+				  id: {{ uuidv4 | quote }}
+				  port: {{ randInt 30000 32767 | quote }}
+				`,
+				Options: Options[Input]{
+					TabSize:  utils.PointerOf(2),
+					RandSeed: seed,
+				},
+			},
+		)
+		assert.Nil(err)
+		return comp
+	}
+
+	comp := createComp(utils.PointerOf(int64(42)))
+	_, first, err := comp.Render(Input{})
+	assert.Nil(err)
+	_, second, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal(first, second)
+
+	// Components with the same seed render the same output
+	_, other, err := createComp(utils.PointerOf(int64(42))).Render(Input{})
+	assert.Nil(err)
+	assert.Equal(first, other)
+
+	_, other, err = createComp(utils.PointerOf(int64(7))).Render(Input{})
+	assert.Nil(err)
+	assert.NotEqual(first, other)
+
+	// Without the seed, renders differ
+	unseeded := createComp(nil)
+	_, first, err = unseeded.Render(Input{})
+	assert.Nil(err)
+	_, second, err = unseeded.Render(Input{})
+	assert.Nil(err)
+	assert.NotEqual(first, second)
+}
+
+type chartImageValues struct {
+	Repository string
+	Tag        string
+	PullPolicy string
+}
+
+type chartValues struct {
+	ReplicaCount int32
+	Image        chartImageValues
+	NameOverride string `json:"nameOverride"`
+}
+
+func TestComponentExposeValues(t *testing.T) {
+	assert := assert.New(t)
+
+	type ValuesContext struct {
+		Values chartValues
+		Other  string
+	}
+
+	// Snippet vendored from a Helm chart, with one action escaped for Helm
+	comp, err := CreateComponent(
+		Def[k8s.Deployment, Input, ValuesContext]{
+			Name: "VendoredDeployment",
+			Template: `
+			apiVersion: apps/v1
+			kind: Deployment
+			metadata:
+			  name: {{ .Values.nameOverride | default "kuard" }}
+			  namespace: "{{! .Values.namespace }}"
+			spec:
+			  replicas: {{ .Values.replicaCount }}
+			  template:
+			    spec:
+			      containers:
+			        - name: {{ .Values.nameOverride | default "kuard" }}
+			          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+			          imagePullPolicy: {{ .Values.image.pullPolicy }}
+			`,
+			Setup: func(input Input) (ValuesContext, error) {
+				return ValuesContext{
+					Values: chartValues{
+						ReplicaCount: 2,
+						Image:        chartImageValues{Repository: "gcr.io/kuar-demo/kuard-amd64", Tag: "1", PullPolicy: "Always"},
+					},
+					Other: "other",
+				}, nil
+			},
+			Options: Options[Input]{
+				TabSize:      utils.PointerOf(2),
+				ExposeValues: true,
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, content, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+	assert.Equal(int32(2), *instance.Spec.Replicas)
+	assert.Equal("gcr.io/kuar-demo/kuard-amd64:1", instance.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(corev1.PullAlways, instance.Spec.Template.Spec.Containers[0].ImagePullPolicy)
+	// Escaped actions are left for Helm
+	assert.Equal("{{ .Values.namespace }}", instance.Namespace)
+	assert.Contains(content, `namespace: "{{ .Values.namespace }}"`)
+}
+
+func TestRenderExposeValuesWholeContext(t *testing.T) {
+	assert := assert.New(t)
+
+	content, err := doRender(
+		"TestValues",
+		`replicas: {{ .Values.replicaCount }}, image: {{ .Values.image.repository }}, same: {{ .Helpa.ReplicaCount }}`,
+		chartValues{ReplicaCount: 3, Image: chartImageValues{Repository: "nginx"}},
+		renderConfig{exposeValues: true},
+	)
+	assert.Nil(err)
+	assert.Equal("replicas: 3, image: nginx, same: 3", content)
+
+	// `.Values` is not exposed by default
+	_, err = Render("TestValues", `replicas: {{ .Values.replicaCount }}`, chartValues{ReplicaCount: 3})
+	assert.NotNil(err)
+}
+
+func setupComponentMultiValidate(skipInvalid bool) (ComponentMulti[corev1.ConfigMap, Input], error) {
+	return CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name: "ValidatedConfigMaps",
+			Template: `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: first
+			---
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: Invalid_Name
+			---
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: third
+			`,
+			Setup: func(input Input) (struct{}, error) {
+				return struct{}{}, nil
+			},
+			GetInstances: func(Input, struct{}) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 3), nil
+			},
+			Validate: func(instance corev1.ConfigMap, index int) error {
+				if strings.ToLower(instance.Name) != instance.Name {
+					return fmt.Errorf("name %q must be lowercase", instance.Name)
+				}
+				return nil
+			},
+			Options: Options[Input]{
+				TabSize:     utils.PointerOf(2),
+				SkipInvalid: skipInvalid,
+			},
+		},
+	)
+}
+
+func TestComponentMultiValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := setupComponentMultiValidate(false)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "validate failed for document at index 1")
+	assert.Contains(err.Error(), `name "Invalid_Name" must be lowercase`)
+}
+
+func TestComponentMultiSkipInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := setupComponentMultiValidate(true)
+	assert.Nil(err)
+
+	instances, contents, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Len(instances, 2)
+	assert.Len(contents, 2)
+	assert.Equal("first", instances[0].Name)
+	assert.Equal("third", instances[1].Name)
+	assert.Contains(contents[1], "name: third")
+
+	result, err := comp.RenderDetailed(Input{})
+	assert.Nil(err)
+	assert.Len(result.Instances, 2)
+	assert.Len(result.Skipped, 1)
+	assert.Equal(1, result.Skipped[0].Index)
+	assert.Contains(result.Skipped[0].Content, "name: Invalid_Name")
+	assert.Contains(result.Skipped[0].Err.Error(), "must be lowercase")
+}
+
+func TestComponentMultiErrorDocIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	def := DefMulti[corev1.ConfigMap, Input, struct{}]{
+		Name: "BrokenConfigMaps",
+		Template: `
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: first
+		---
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: [not, a, string]
+		`,
+		GetInstances: func(Input, struct{}) ([]corev1.ConfigMap, error) {
+			return make([]corev1.ConfigMap, 2), nil
+		},
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+
+	comp, err := CreateComponentMulti(def)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), `unmarshal failed for document at index 1 in "BrokenConfigMaps"`)
+
+	// Errors from custom `Render` tell the component too
+	def.Render = func(Input, struct{}, []string) ([]corev1.ConfigMap, error) {
+		return nil, fmt.Errorf("custom failure")
+	}
+	comp, err = CreateComponentMulti(def)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), `custom-render failed in "BrokenConfigMaps"`)
+	assert.Contains(err.Error(), "custom failure")
+}
+
+func TestComponentMultiRenderIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name: "ConfigMaps",
+			Template: `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: first
+			---
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: second
+			`,
+			GetInstances: func(Input, struct{}) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 2), nil
+			},
+			Options: Options[Input]{TabSize: utils.PointerOf(2)},
+		},
+	)
+	assert.Nil(err)
+
+	instance, content, err := comp.RenderIndex(Input{}, 1)
+	assert.Nil(err)
+	assert.Equal("second", instance.Name)
+	assert.Contains(content, "name: second")
+	assert.NotContains(content, "name: first")
+
+	_, _, err = comp.RenderIndex(Input{}, 2)
+	assert.ErrorIs(err, ErrDocumentIndexOutOfRange)
+}
+
+func TestComponentSliceMultiDoc(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[[]FromFileSpec, Input, Input]{
+			Template: `
+			my: first
+			spec:
+			- {{ .Helpa.Number | quote }}
+			---
+			my: second
+			spec:
+			- There
+			`,
+			Setup:   func(input Input) (Input, error) { return input, nil },
+			Options: Options[Input]{TabSize: utils.PointerOf(2)},
+		},
+	)
+	assert.Nil(err)
+
+	instances, _, err := comp.Render(Input{Number: 2})
+	assert.Nil(err)
+	assert.Equal([]FromFileSpec{
+		{My: "first", Spec: []string{"2"}},
+		{My: "second", Spec: []string{"There"}},
+	}, instances)
+}
+
+func TestComponentMultiOmitNilInstances(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[runtime.Object, Input, struct{}]{
+			Name: "OptionalResources",
+			Template: `
+			apiVersion: apps/v1
+			kind: Deployment
+			metadata:
+			  name: kuard
+			---
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: Optional_Config
+			---
+			apiVersion: v1
+			kind: Service
+			metadata:
+			  name: kuard
+			`,
+			GetInstances: func(input Input, _ struct{}) ([]runtime.Object, error) {
+				var configMap runtime.Object
+				if input.Number > 0 {
+					configMap = &corev1.ConfigMap{}
+				}
+				return []runtime.Object{&k8s.Deployment{}, configMap, &corev1.Service{}}, nil
+			},
+			Validate: func(instance runtime.Object, index int) error {
+				if _, ok := instance.(*corev1.ConfigMap); ok {
+					return fmt.Errorf("invalid config map at index %v", index)
+				}
+				return nil
+			},
+			Options: Options[Input]{TabSize: utils.PointerOf(2)},
+		},
+	)
+	assert.Nil(err)
+
+	instances, contents, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Len(instances, 2)
+	assert.Len(contents, 2)
+	assert.IsType(&k8s.Deployment{}, instances[0])
+	assert.IsType(&corev1.Service{}, instances[1])
+	assert.Contains(contents[1], "kind: Service")
+
+	// Errors still tell the position of the document in the template
+	_, _, err = comp.Render(Input{Number: 1})
+	assert.Contains(err.Error(), "invalid config map at index 1")
+}
+
+func TestComponentName(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Name: "kuard-config",
+			Template: `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: {{ .ComponentName }}-main
+			`,
+			Options: Options[Input]{
+				TabSize: utils.PointerOf(2),
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard-config-main", instance.Name)
+}
+
+func TestCreateComponentFromFileTemplateBaseDir(t *testing.T) {
+	assert := assert.New(t)
+
+	// Run from a different working directory, so the relative path doesn't resolve from CWD
+	cwd, err := os.Getwd()
+	assert.Nil(err)
+	err = os.Chdir(t.TempDir())
+	assert.Nil(err)
+	defer os.Chdir(cwd)
+
+	createComp := func(baseDir string) (Component[FromFileSpec, Input], error) {
+		return CreateComponent(
+			Def[FromFileSpec, Input, Context]{
+				Template:       `../../examples/fromfile/fromfile.yaml`,
+				TemplateIsFile: true,
+				Setup: func(input Input) (Context, error) {
+					context := Context{
+						Catify: func(s string) string {
+							return fmt.Sprintf("🐈 %s 🐈", s)
+						},
+					}
+					return context, nil
+				},
+				Options: Options[Input]{
+					TemplateBaseDir: baseDir,
+				},
+			},
+		)
+	}
+
+	_, err = createComp("")
+	assert.NotNil(err)
+
+	comp, err := createComp(CallerDir())
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal([]string{"Hello", "There", "", "🐈 I LOVE CATS 🐈"}, instance.Spec)
+}
+
+func TestCreateComponentBaseDir(t *testing.T) {
+	assert := assert.New(t)
+
+	baseDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(baseDir, "configmap.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: kuard\ndata:\n  greeting: {{ readFile \"greeting.txt\" | trim | quote }}\n"), 0o644)
+	assert.Nil(err)
+	err = os.WriteFile(filepath.Join(baseDir, "greeting.txt"), []byte("Hello from the base dir\n"), 0o644)
+	assert.Nil(err)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template:       `configmap.yaml`,
+			TemplateIsFile: true,
+			Options: Options[Input]{
+				BaseDir: baseDir,
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+	assert.Equal("Hello from the base dir", instance.Data["greeting"])
+}
+
+func TestCreateComponentTemplateDirs(t *testing.T) {
+	assert := assert.New(t)
+
+	sharedDir := t.TempDir()
+	overrideDir := t.TempDir()
+	for dir, name := range map[string]string{sharedDir: "shared", overrideDir: "override"} {
+		err := os.MkdirAll(filepath.Join(dir, "kuard"), 0o755)
+		assert.Nil(err)
+		err = os.WriteFile(filepath.Join(dir, "kuard", "kuard.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: "+name+"\n"), 0o644)
+		assert.Nil(err)
+	}
+
+	createComp := func(templateDirs []string) (Component[corev1.ConfigMap, Input], error) {
+		return CreateComponent(
+			Def[corev1.ConfigMap, Input, struct{}]{
+				Name:           "Kuard",
+				Template:       "kuard/kuard.yaml",
+				TemplateIsFile: true,
+				Options:        Options[Input]{TemplateDirs: templateDirs},
+			},
+		)
+	}
+
+	// First match wins
+	comp, err := createComp([]string{t.TempDir(), overrideDir, sharedDir})
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("override", instance.Name)
+
+	// Package-level dirs are used when the options don't set any
+	SetTemplateDirs(sharedDir)
+	t.Cleanup(func() { SetTemplateDirs() })
+	comp, err = createComp(nil)
+	assert.Nil(err)
+	instance, _, err = comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("shared", instance.Name)
+
+	// All tried paths are reported
+	emptyDir := t.TempDir()
+	_, err = createComp([]string{emptyDir, "missing"})
+	assert.ErrorIs(err, ErrTemplateNotFound)
+	assert.Contains(err.Error(), filepath.Join(emptyDir, "kuard", "kuard.yaml"))
+	assert.Contains(err.Error(), filepath.Join("missing", "kuard", "kuard.yaml"))
+
+	// Absolute paths bypass the search
+	comp, err = CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template:       filepath.Join(sharedDir, "kuard", "kuard.yaml"),
+			TemplateIsFile: true,
+			Options:        Options[Input]{TemplateDirs: []string{emptyDir}},
+		},
+	)
+	assert.Nil(err)
+	instance, _, err = comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("shared", instance.Name)
+}
+
+func TestDefCopy(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Options: Options[Input]{
+			TabSize:      utils.PointerOf(2),
+			RandSeed:     utils.PointerOf(int64(42)),
+			HelmBuiltins: &HelmBuiltins{Capabilities: HelmCapabilities{APIVersions: []string{"v1"}}},
+			TemplateDirs: []string{"templates"},
+		},
+	}
+
+	copied := def.Copy()
+	*copied.Options.TabSize = 4
+	*copied.Options.RandSeed = 7
+	copied.Options.HelmBuiltins.Capabilities.APIVersions[0] = "apps/v1"
+	copied.Options.TemplateDirs[0] = "other"
+
+	assert.Equal(2, *def.Options.TabSize)
+	assert.Equal(int64(42), *def.Options.RandSeed)
+	assert.Equal(HelmAPIVersions{"v1"}, def.Options.HelmBuiltins.Capabilities.APIVersions)
+	assert.Equal([]string{"templates"}, def.Options.TemplateDirs)
+
+	defMulti := DefMulti[corev1.ConfigMap, Input, struct{}]{
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+	copiedMulti := defMulti.Copy()
+	*copiedMulti.Options.TabSize = 4
+	assert.Equal(2, *defMulti.Options.TabSize)
+}
+
+type platformContext struct {
+	Cluster string
+	Region  string
+}
+
+func TestComponentSetupMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := []string{}
+	record := func(name string) func(SetupFunc[Input, platformContext]) SetupFunc[Input, platformContext] {
+		return func(next SetupFunc[Input, platformContext]) SetupFunc[Input, platformContext] {
+			return func(input Input) (platformContext, error) {
+				calls = append(calls, name+":before")
+				context, err := next(input)
+				calls = append(calls, name+":after")
+				return context, err
+			}
+		}
+	}
+	injectPlatform := func(next SetupFunc[Input, platformContext]) SetupFunc[Input, platformContext] {
+		return func(input Input) (platformContext, error) {
+			context, err := next(input)
+			context.Cluster = "prod"
+			return context, err
+		}
+	}
+
+	// Setup is not set, so the middleware wraps the identity default
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, platformContext]{
+			Template:        "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Helpa.Cluster }}",
+			SetupMiddleware: []func(SetupFunc[Input, platformContext]) SetupFunc[Input, platformContext]{record("outer"), record("inner"), injectPlatform},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("prod", instance.Name)
+	assert.Equal([]string{"outer:before", "inner:before", "inner:after", "outer:after"}, calls)
+
+	// Errors short-circuit the rest of the chain
+	calls = []string{}
+	failing := func(next SetupFunc[Input, platformContext]) SetupFunc[Input, platformContext] {
+		return func(input Input) (platformContext, error) {
+			return platformContext{}, fmt.Errorf("no platform")
+		}
+	}
+	compMulti, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, platformContext]{
+			Template: "apiVersion: v1\nkind: ConfigMap",
+			Setup: func(input Input) (platformContext, error) {
+				calls = append(calls, "setup")
+				return platformContext{}, nil
+			},
+			SetupMiddleware: []func(SetupFunc[Input, platformContext]) SetupFunc[Input, platformContext]{record("outer"), failing, record("inner")},
+			GetInstances: func(Input, platformContext) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 1), nil
+			},
+		},
+	)
+	assert.Nil(err)
+
+	_, _, err = compMulti.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "no platform")
+	assert.Equal([]string{"outer:before", "outer:after"}, calls)
+}
+
+func TestComponentPostSetup(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[corev1.ConfigMap, Input, platformContext]{
+		Template: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Helpa.Cluster }}-{{ .Helpa.Region }}",
+		Setup: func(input Input) (platformContext, error) {
+			return platformContext{Cluster: "prod", Region: "eu"}, nil
+		},
+		PostSetup: func(context *platformContext) error {
+			context.Region = "us"
+			return nil
+		},
+	}
+
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("prod-us", instance.Name)
+
+	def.PostSetup = func(context *platformContext) error {
+		return fmt.Errorf("no region")
+	}
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.ErrorContains(err, "post-setup failed: no region")
+
+	compMulti, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, platformContext]{
+			Template: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Helpa.Cluster }}",
+			PostSetup: func(context *platformContext) error {
+				context.Cluster = "staging"
+				return nil
+			},
+			GetInstances: func(Input, platformContext) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 1), nil
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instances, _, err := compMulti.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("staging", instances[0].Name)
+}
+
+func TestCreateComponentOptionsIsolated(t *testing.T) {
+	assert := assert.New(t)
+
+	seed := int64(42)
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Name: "IsolatedConfigMap",
+		Template: `
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: {{ .Release.Name }}-{{ randAlphaNum 6 | lower }}
+		`,
+		Options: Options[Input]{
+			TabSize:      utils.PointerOf(2),
+			RandSeed:     &seed,
+			HelmBuiltins: &HelmBuiltins{Release: HelmRelease{Name: "first"}},
+		},
+	}
+
+	first, err := CreateComponent(def)
+	assert.Nil(err)
+	_, firstContent, err := first.Render(Input{})
+	assert.Nil(err)
+
+	// Creating the component does not write the resolved defaults back
+	assert.Nil(def.Options.PreprocessTemplate)
+	assert.Nil(def.Options.Unmarshal)
+	assert.Equal("", def.Options.MultiDocSeparator)
+
+	// Change the options between the creations, incl. the values behind pointers
+	seed = 7
+	def.Options.HelmBuiltins.Release.Name = "second"
+	second, err := CreateComponent(def)
+	assert.Nil(err)
+
+	firstInstance, firstContentAfter, err := first.Render(Input{})
+	assert.Nil(err)
+	assert.Equal(firstContent, firstContentAfter)
+	assert.True(strings.HasPrefix(firstInstance.Name, "first-"))
+
+	secondInstance, _, err := second.Render(Input{})
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(secondInstance.Name, "second-"))
+	// Different seed
+	assert.NotEqual(strings.TrimPrefix(firstInstance.Name, "first-"), strings.TrimPrefix(secondInstance.Name, "second-"))
+}
+
+func TestComponentExpandEnv(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("HELPA_TEST_NAME", "kuard")
+	t.Setenv("HELPA_TEST_PORT", "8080")
+
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Template: `
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: ${HELPA_TEST_NAME}
+		data:
+		  {{- $port := "9090" }}
+		  port: "$HELPA_TEST_PORT"
+		  other: "{{ $port }}"
+		`,
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+
+	// Not expanded by default
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("${HELPA_TEST_NAME}", instance.Name)
+
+	def.Options.ExpandEnv = true
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	instance, _, err = comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+	assert.Equal("8080", instance.Data["port"])
+	// Template variables are left alone
+	assert.Equal("9090", instance.Data["other"])
+}
+
+func TestComponentMultiInstanceFor(t *testing.T) {
+	assert := assert.New(t)
+
+	def := DefMulti[runtime.Object, Input, Input]{
+		Name: "RoleBindings",
+		Template: `
+		kind: CronJob
+		metadata:
+		  name: certbot
+		---
+		kind: ServiceAccount
+		metadata:
+		  name: certbot
+		---
+		kind: ClusterRole
+		metadata:
+		  name: certbot
+		{{- range $i := until .Helpa.Number }}
+		---
+		kind: RoleBinding
+		metadata:
+		  name: certbot
+		  namespace: ns-{{ $i }}
+		{{- end }}
+		`,
+		Setup: func(input Input) (Input, error) {
+			return input, nil
+		},
+		// One RoleBinding per namespace after the CronJob, ServiceAccount and ClusterRole
+		InstanceFor: func(index int) (runtime.Object, error) {
+			switch index {
+			case 0:
+				return &batchv1.CronJob{}, nil
+			case 1:
+				return &corev1.ServiceAccount{}, nil
+			case 2:
+				return &rbacv1.ClusterRole{}, nil
+			}
+			return &rbacv1.RoleBinding{}, nil
+		},
+		MinDocs: 4,
+		MaxDocs: 13,
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+
+	comp, err := CreateComponentMulti(def)
+	assert.Nil(err)
+
+	for _, count := range []int{1, 3, 10} {
+		instances, _, err := comp.Render(Input{Number: count})
+		assert.Nil(err)
+		assert.Len(instances, 3+count)
+		assert.Equal(fmt.Sprintf("ns-%d", count-1), instances[len(instances)-1].(*rbacv1.RoleBinding).Namespace)
+	}
+
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrDocumentCountOutOfBounds)
+	assert.Contains(err.Error(), "found 3 documents in the template, but expected at least 4")
+
+	def.MaxDocs = 5
+	comp, err = CreateComponentMulti(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{Number: 3})
+	assert.ErrorIs(err, ErrDocumentCountOutOfBounds)
+	assert.Contains(err.Error(), "found 6 documents in the template, but expected at most 5")
+
+	// Neither `GetInstances` nor `InstanceFor`
+	def.InstanceFor = nil
+	comp, err = CreateComponentMulti(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrMissingInstances)
+}
+
+func TestComponentSkipTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[FromFileSpec, Input, Context]{
+			Name: "RenderOnly",
+			// Invalid template, which would fail to parse if rendered
+			Template: `my: {{ .Helpa.Number `,
+			Setup: func(input Input) (Context, error) {
+				return Context{Number: fmt.Sprint(input.Number)}, nil
+			},
+			Render: func(input Input, context Context, content string) (FromFileSpec, error) {
+				assert.Equal("", content)
+				return FromFileSpec{My: context.Number}, nil
+			},
+			Options: Options[Input]{SkipTemplate: true},
+		},
+	)
+	assert.Nil(err)
+
+	instance, content, err := comp.Render(Input{Number: 2})
+	assert.Nil(err)
+	assert.Equal("2", instance.My)
+	assert.Equal("", content)
+}
+
+func TestComponentMultiSkipTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[FromFileSpec, Input, Context]{
+			Name:     "RenderOnlyMulti",
+			Template: `my: {{ .Helpa.Number `,
+			Setup: func(input Input) (Context, error) {
+				return Context{Number: fmt.Sprint(input.Number)}, nil
+			},
+			Render: func(input Input, context Context, contentParts []string) ([]FromFileSpec, error) {
+				assert.Empty(contentParts)
+				return []FromFileSpec{{My: context.Number}, {My: "other"}}, nil
+			},
+			Options: Options[Input]{SkipTemplate: true},
+		},
+	)
+	assert.Nil(err)
+
+	instances, contents, err := comp.Render(Input{Number: 2})
+	assert.Nil(err)
+	assert.Equal([]FromFileSpec{{My: "2"}, {My: "other"}}, instances)
+	assert.Empty(contents)
+}
+
+func TestComponentSkipTemplateRequiresRender(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := CreateComponent(
+		Def[FromFileSpec, Input, Context]{
+			Template: `my: value`,
+			Options:  Options[Input]{SkipTemplate: true},
+		},
+	)
+	assert.ErrorIs(err, ErrSkipTemplateWithoutRender)
+
+	_, err = CreateComponentMulti(
+		DefMulti[FromFileSpec, Input, Context]{
+			Template: `my: value`,
+			Options:  Options[Input]{SkipTemplate: true},
+		},
+	)
+	assert.ErrorIs(err, ErrSkipTemplateWithoutRender)
+}
+
+type manyFieldsContext struct {
+	Zeta    string
+	Alpha   int
+	Mu      []string
+	Beta    bool
+	Omega   map[string]string
+	Gamma   float64
+	Epsilon string
+	Catify  func(s string) string
+}
+
+func TestComponentContextFieldOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[string, Input, manyFieldsContext]{
+			Template: `{{ printf "%+v" .Helpa }}`,
+			Setup: func(Input) (manyFieldsContext, error) {
+				return manyFieldsContext{Zeta: "z", Alpha: 1, Mu: []string{"m"}, Epsilon: "e"}, nil
+			},
+			Render: func(_ Input, _ manyFieldsContext, content string) (string, error) {
+				return content, nil
+			},
+		},
+	)
+	assert.Nil(err)
+
+	// Fields are sorted, and functions are left out
+	expected := "&{Alpha:1 Beta:false Epsilon:e Gamma:0 Mu:[m] Omega:map[] Zeta:z}"
+	for range 10 {
+		_, content, err := comp.Render(Input{})
+		assert.Nil(err)
+		assert.Equal(expected, content)
+	}
+}
+
+type ambiguousContext struct {
+	Name string
+	NAME string
+}
+
+func TestComponentContextFieldsDifferByCase(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[string, Input, ambiguousContext]{
+			Template: `{{ .Helpa.Name }}`,
+			Render: func(_ Input, _ ambiguousContext, content string) (string, error) {
+				return content, nil
+			},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrAmbiguousContextField)
+	assert.Contains(err.Error(), `fields "NAME" and "Name"`)
+}
+
+type clientContext struct {
+	client  *http.Client
+	counter int
+	Name    string
+	Timeout func() string
+}
+
+func TestComponentContextUnexportedFields(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, clientContext]{
+			Template: "kind: ConfigMap\ndata:\n  name: {{ .Helpa.Name }}\n  timeout: {{ Timeout | quote }}\n",
+			Setup: func(input Input) (clientContext, error) {
+				context := clientContext{client: &http.Client{Timeout: 5 * time.Second}, Name: input.Name}
+				// Functions may use the unexported fields
+				context.Timeout = func() string { return context.client.Timeout.String() }
+				return context, nil
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal(map[string]string{"name": "kuard", "timeout": "5s"}, instance.Data)
+
+	// Unexported fields are invisible to templates
+	comp, err = CreateComponent(
+		Def[corev1.ConfigMap, Input, clientContext]{
+			Template: "kind: ConfigMap\ndata:\n  counter: {{ .Helpa.counter }}\n",
+		},
+	)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "counter")
+}
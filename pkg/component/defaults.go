@@ -0,0 +1,35 @@
+package component
+
+import (
+	"reflect"
+)
+
+// Options applied to all components created after they are set, for the fields
+// that the component leaves unset (zero). E.g. to set the same `TabSize` on
+// all components:
+//
+//	func init() {
+//		component.DefaultOptions.TabSize = utils.PointerOf(2)
+//	}
+//
+// NOTE: Fields that depend on the input type (`FrontloadInput`, `PreprocessTemplate`,
+// `Unmarshal` and `UnmarshalFor`) cannot be set here, and are ignored.
+//
+// NOTE: Components cannot unset the defaults, e.g. set a default `true` back to `false`.
+var DefaultOptions Options[any]
+
+// Fill in the unset fields of the options from `DefaultOptions`
+func applyDefaultOptions[TInput any](options Options[TInput]) Options[TInput] {
+	defaults := reflect.ValueOf(DefaultOptions.Copy())
+	target := reflect.ValueOf(&options).Elem()
+	for index := 0; index < target.NumField(); index++ {
+		field := target.Field(index)
+		defaultField := defaults.Field(index)
+		// NOTE: Fields that depend on `TInput` have different types
+		if field.Type() != defaultField.Type() || !field.IsZero() || defaultField.IsZero() {
+			continue
+		}
+		field.Set(defaultField)
+	}
+	return options
+}
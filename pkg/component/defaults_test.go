@@ -0,0 +1,64 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Set the default options for the duration of the test
+func setDefaultOptions(t *testing.T, options Options[any]) {
+	previous := DefaultOptions
+	DefaultOptions = options
+	t.Cleanup(func() { DefaultOptions = previous })
+}
+
+// Indented with tabs, so it's valid YAML only with `Options.TabSize`
+const indentedConfigMap = `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: kuard
+			`
+
+func TestDefaultOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	// Without the default, the tabs make the template invalid YAML
+	comp, err := setupComponentInline[corev1.ConfigMap](indentedConfigMap, nil, nil)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+
+	setDefaultOptions(t, Options[any]{TabSize: utils.PointerOf(2)})
+
+	comp, err = setupComponentInline[corev1.ConfigMap](indentedConfigMap, nil, nil)
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+}
+
+func TestDefaultOptionsDontOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	setDefaultOptions(t, Options[any]{
+		TabSize:           utils.PointerOf(4),
+		MultiDocSeparator: "+++",
+		// Depends on the input type, so it's ignored
+		FrontloadInput: Input{Name: "ignored"},
+	})
+
+	options := applyDefaultOptions(Options[Input]{TabSize: utils.PointerOf(2)})
+	assert.Equal(2, *options.TabSize)
+	assert.Equal("+++", options.MultiDocSeparator)
+	assert.Equal(Input{}, options.FrontloadInput)
+
+	// Defaults are copied, so components don't share them
+	options = applyDefaultOptions(Options[Input]{})
+	assert.Equal(4, *options.TabSize)
+	*options.TabSize = 8
+	assert.Equal(4, *DefaultOptions.TabSize)
+}
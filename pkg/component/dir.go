@@ -0,0 +1,114 @@
+package component
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrEmptyTemplateDir = eris.New("template directory has no files")
+	ErrSeparatorInFile  = eris.New("template file contains the document separator")
+)
+
+// Create a `ComponentMulti` from a directory with one template file per resource,
+// e.g. `deployment.yaml` and `service.yaml`, which is how Helm charts are usually
+// laid out. Files are read in the order of their names, and each is one document.
+//
+// `instanceFor` returns the instance to unmarshal the file into, given the file
+// name, e.g.:
+//
+//	component.CreateComponentMultiFromDir("./kuard", def, func(file string) (runtime.Object, error) {
+//		switch file {
+//		case "deployment.yaml":
+//			return &appsv1.Deployment{}, nil
+//		...
+//	})
+//
+// It replaces the `Template` and `GetInstances` of the definition. Relative `dir`
+// is resolved from `Options.TemplateBaseDir` or `Options.BaseDir`.
+//
+// NOTE: Subdirectories, and files whose name starts with `.` or `_`, are skipped.
+func CreateComponentMultiFromDir[
+	TType any,
+	TInput any,
+	TContext any,
+](
+	dir string,
+	comp DefMulti[TType, TInput, TContext],
+	instanceFor func(file string) (TType, error),
+) (ComponentMulti[TType, TInput], error) {
+	tmpl, files, err := readTemplateDir(dir, comp.Options)
+	if err != nil {
+		err = eris.Wrapf(err, "failed to read templates of %q", comp.Name)
+		if comp.Options.PanicOnError {
+			panic(err)
+		}
+		return ComponentMulti[TType, TInput]{}, err
+	}
+
+	comp.Template = tmpl
+	comp.TemplateIsFile = false
+	comp.GetInstances = func(TInput, TContext) ([]TType, error) {
+		instances := []TType{}
+		for _, file := range files {
+			instance, err := instanceFor(file)
+			if err != nil {
+				return nil, eris.Wrapf(err, "failed to get instance for file %q", file)
+			}
+			instances = append(instances, instance)
+		}
+		return instances, nil
+	}
+
+	return CreateComponentMulti(comp)
+}
+
+// Join the template files into a single multi-document template
+func readTemplateDir[TInput any](dir string, options Options[TInput]) (string, []string, error) {
+	baseDir := options.BaseDir
+	if options.TemplateBaseDir != "" {
+		baseDir = options.TemplateBaseDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(baseDir, dir)
+	}
+	separator := options.MultiDocSeparator
+	if separator == "" {
+		separator = "---"
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	files := []string{}
+	docs := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", nil, err
+		}
+		// NOTE: The documents are split by the separator, so the file would become
+		// multiple documents.
+		if strings.Contains(string(content), separator) {
+			return "", nil, eris.Wrapf(ErrSeparatorInFile, "file %q contains %q", name, separator)
+		}
+
+		files = append(files, name)
+		docs = append(docs, strings.TrimRight(string(content), "\n"))
+	}
+	if len(files) == 0 {
+		return "", nil, eris.Wrapf(ErrEmptyTemplateDir, "directory %q", dir)
+	}
+
+	return strings.Join(docs, "\n"+separator+"\n"), files, nil
+}
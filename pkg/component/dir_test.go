@@ -0,0 +1,61 @@
+package component
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	k8s "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func kuardInstanceFor(file string) (runtime.Object, error) {
+	switch file {
+	case "deployment.yaml":
+		return &k8s.Deployment{}, nil
+	case "service.yaml":
+		return &corev1.Service{}, nil
+	}
+	return nil, fmt.Errorf("unknown file %q", file)
+}
+
+func TestCreateComponentMultiFromDir(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "service.yaml"), []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Helpa.Name }}\n"), 0o644)
+	assert.Nil(err)
+	err = os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ .Helpa.Name }}\n"), 0o644)
+	assert.Nil(err)
+	err = os.WriteFile(filepath.Join(dir, "_helpers.tpl"), []byte("not a resource"), 0o644)
+	assert.Nil(err)
+
+	comp, err := CreateComponentMultiFromDir(
+		dir,
+		DefMulti[runtime.Object, Input, Input]{
+			Name:  "Kuard",
+			Setup: func(input Input) (Input, error) { return input, nil },
+		},
+		kuardInstanceFor,
+	)
+	assert.Nil(err)
+
+	instances, contents, err := comp.Render(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Len(instances, 2)
+	assert.Equal("kuard", instances[0].(*k8s.Deployment).Name)
+	assert.Equal("kuard", instances[1].(*corev1.Service).Name)
+	assert.Contains(contents[1], "kind: Service")
+
+	// Files with multiple documents are rejected
+	err = os.WriteFile(filepath.Join(dir, "service.yaml"), []byte("kind: Service\n---\nkind: Service\n"), 0o644)
+	assert.Nil(err)
+	_, err = CreateComponentMultiFromDir(dir, DefMulti[runtime.Object, Input, Input]{}, kuardInstanceFor)
+	assert.ErrorIs(err, ErrSeparatorInFile)
+
+	_, err = CreateComponentMultiFromDir(t.TempDir(), DefMulti[runtime.Object, Input, Input]{}, kuardInstanceFor)
+	assert.ErrorIs(err, ErrEmptyTemplateDir)
+}
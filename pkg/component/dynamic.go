@@ -0,0 +1,86 @@
+package component
+
+import (
+	eris "github.com/rotisserie/eris"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Definition of a component whose template is known only at runtime, e.g.
+// uploaded by users. Unlike `Def`, it needs no types, input and context
+// are maps, and the documents are unmarshalled to maps.
+type DynamicDef struct {
+	Name     string
+	Template string
+	// Create the context from the input. Keys of the context are available
+	// in the template as `.Helpa.key`, and functions as template functions.
+	//
+	// Default: the input is used as the context
+	Setup func(input map[string]any) (map[string]any, error)
+	// If true, each document must be a K8s object, i.e. have `apiVersion` and `kind`,
+	// otherwise the render fails with `ErrNotK8sObject`. The instances can then
+	// be used as `unstructured.Unstructured{Object: instance}`.
+	K8s     bool
+	Options Options[map[string]any]
+}
+
+type DynamicComponent struct {
+	Render func(input map[string]any) (instances []map[string]any, contents []string, err error)
+	// Same as `ComponentMulti.RenderDetailed`
+	RenderDetailed func(input map[string]any) (result RenderMultiResult[map[string]any], err error)
+	// Find out which functions and variables the template uses. See `Analyze`.
+	Analyze func() (Analysis, error)
+}
+
+// Create a component from `DynamicDef`. It's rendered the same way as `ComponentMulti`,
+// so the template may have several documents, and gets the same functions.
+//
+//	comp, err := component.CreateDynamic(component.DynamicDef{
+//		Name:     "Upload",
+//		Template: userTemplate,
+//	})
+//	instances, contents, err := comp.Render(map[string]any{"name": "kuard"})
+func CreateDynamic(def DynamicDef) (DynamicComponent, error) {
+	setup := def.Setup
+	if setup == nil {
+		setup = func(input map[string]any) (map[string]any, error) { return input, nil }
+	}
+
+	options := def.Options.Copy()
+	if def.K8s && options.Unmarshal == nil {
+		options.Unmarshal = unstructuredUnmarshal
+	}
+
+	comp, err := CreateComponentMulti(DefMulti[map[string]any, map[string]any, map[string]any]{
+		Name:     def.Name,
+		Template: def.Template,
+		Setup:    setup,
+		// NOTE: Nil maps would be dropped as omitted documents
+		InstanceFor: func(int) (map[string]any, error) { return map[string]any{}, nil },
+		Options:     options,
+	})
+	if err != nil {
+		return DynamicComponent{}, err
+	}
+
+	return DynamicComponent{
+		Render:         comp.Render,
+		RenderDetailed: comp.RenderDetailed,
+		Analyze:        comp.Analyze,
+	}, nil
+}
+
+// Unmarshal the document as a map, and check that it's a K8s object. See `DynamicDef.K8s`.
+func unstructuredUnmarshal(rendered string, container any, options Options[map[string]any]) error {
+	if err := defaultUnmarshaller(rendered, container, options); err != nil {
+		return err
+	}
+
+	obj := unstructured.Unstructured{}
+	if objMap, ok := container.(*map[string]any); ok {
+		obj.Object = *objMap
+	}
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return eris.Wrap(ErrNotK8sObject, "document must have `apiVersion` and `kind`")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package component
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+// As if uploaded by a user
+const dynamicTemplate = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Helpa.name | snakecase }}
+data:
+  replicas: {{ .Helpa.replicas | quote }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ shout .Helpa.name }}
+  annotations:
+    checksum: "{{! .Values.checksum }}"
+`
+
+func TestCreateDynamic(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateDynamic(DynamicDef{
+		Name:     "Upload",
+		Template: dynamicTemplate,
+		Setup: func(input map[string]any) (map[string]any, error) {
+			return map[string]any{
+				"name":     input["name"],
+				"replicas": 2,
+				"shout":    strings.ToUpper,
+			}, nil
+		},
+		K8s: true,
+	})
+	assert.Nil(err)
+
+	instances, contents, err := comp.Render(map[string]any{"name": "KuardApp"})
+	assert.Nil(err)
+	assert.Len(instances, 2)
+	assert.Len(contents, 2)
+	assert.Equal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "kuard_app"},
+		"data":       map[string]any{"replicas": "2"},
+	}, instances[0])
+	assert.Equal("KUARDAPP", instances[1]["metadata"].(map[string]any)["name"])
+	assert.Contains(contents[1], `checksum: "{{ .Values.checksum }}"`)
+
+	// Without `Setup`, the input is the context
+	comp, err = CreateDynamic(DynamicDef{Template: "name: {{ .Helpa.name }}\n---\nitems: [1, 2]\n"})
+	assert.Nil(err)
+	instances, _, err = comp.Render(map[string]any{"name": "kuard"})
+	assert.Nil(err)
+	assert.Equal([]map[string]any{{"name": "kuard"}, {"items": []any{float64(1), float64(2)}}}, instances)
+}
+
+func TestCreateDynamicErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	// Bad template. Same as with `CreateComponentMulti`, it's parsed when rendered
+	comp, err := CreateDynamic(DynamicDef{Name: "Upload", Template: "name: {{ .Helpa.name "})
+	assert.Nil(err)
+	_, _, err = comp.Render(nil)
+	assert.NotNil(err)
+	_, err = comp.Analyze()
+	assert.NotNil(err)
+
+	comp, err = CreateDynamic(DynamicDef{Name: "Upload", Template: "name: {{ missingFunc }}\n"})
+	assert.Nil(err)
+	_, _, err = comp.Render(nil)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), `function "missingFunc" not defined`)
+
+	// Bad YAML
+	comp, err = CreateDynamic(DynamicDef{Name: "Upload", Template: "kind: ConfigMap\n---\n" + malformedYaml})
+	assert.Nil(err)
+	_, _, err = comp.Render(nil)
+	assert.ErrorIs(err, ErrInvalidYaml)
+	assert.Contains(err.Error(), "document at index 1")
+
+	// Not K8s objects
+	comp, err = CreateDynamic(DynamicDef{Name: "Upload", Template: "name: kuard\n", K8s: true})
+	assert.Nil(err)
+	_, _, err = comp.Render(nil)
+	assert.ErrorIs(err, ErrNotK8sObject)
+}
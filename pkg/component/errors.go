@@ -0,0 +1,106 @@
+package component
+
+import (
+	"fmt"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// Format the error, including the whole chain of wrapped errors and
+// their stack traces. Useful for debugging render errors, e.g.:
+//
+//	_, _, err := Component.Render(input)
+//	if err != nil {
+//		fmt.Println(component.FormatError(err))
+//	}
+//
+// NOTE: `err.Error()` gives only the messages of the wrapped errors,
+// without the stack traces.
+func FormatError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return eris.ToString(err, true)
+}
+
+// Decorate an error that leaves the render of a component with the component
+// name, the stage where it happened, and the document index (if `docIndex >= 0`),
+// e.g. `unmarshal failed for document at index 1 in "Kuard": ...`
+func wrapStageError(err error, compName string, stage renderStage, docIndex int) error {
+	where := ""
+	if docIndex >= 0 {
+		where = fmt.Sprintf(" for document at index %v", docIndex)
+	}
+	return eris.Wrapf(err, "%s failed%s in %q", stage, where, compName)
+}
+
+// Multiple errors collected together, e.g. when validating several inputs,
+// or rendering several components. Use `errors.Is` and `errors.As` to find
+// a specific error among them.
+//
+// Build it with `AppendError`, and return it with `ErrorOrNil`:
+//
+//	var errs component.Errors
+//	for index, input := range inputs {
+//		_, _, err := Component.Render(input)
+//		component.AppendError(&errs, fmt.Sprintf("input at index %d", index), err)
+//	}
+//	return errs.ErrorOrNil()
+type Errors struct {
+	Entries []ErrorEntry
+}
+
+type ErrorEntry struct {
+	// What the error relates to, e.g. component name, input index, file or
+	// document index. May be empty.
+	Label string
+	Err   error
+}
+
+// Add the error with given label to the errors. Nil errors are ignored.
+func AppendError(errs *Errors, label string, err error) {
+	if err == nil {
+		return
+	}
+	errs.Entries = append(errs.Entries, ErrorEntry{Label: label, Err: err})
+}
+
+// Return the errors as `error`, or nil if there are none.
+//
+// NOTE: Returning `*Errors` with no entries as `error` would make it non-nil.
+func (e *Errors) ErrorOrNil() error {
+	if e == nil || len(e.Entries) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) Error() string {
+	if len(e.Entries) == 1 {
+		return e.Entries[0].String()
+	}
+
+	lines := []string{fmt.Sprintf("%d errors occurred:", len(e.Entries))}
+	for _, entry := range e.Entries {
+		// NOTE: Multi-line messages are indented, so it's clear where each entry ends
+		lines = append(lines, "  - "+strings.ReplaceAll(entry.String(), "\n", "\n    "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Support for `errors.Is` and `errors.As`
+func (e *Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e.Entries))
+	for _, entry := range e.Entries {
+		errs = append(errs, entry.Err)
+	}
+	return errs
+}
+
+func (e ErrorEntry) String() string {
+	if e.Label == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Label, e.Err.Error())
+}
@@ -0,0 +1,74 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	eris "github.com/rotisserie/eris"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestFormatError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Render("BrokenTemplate", `{{ .Helpa.Missing.Field }}`, Input{})
+	assert.NotNil(err)
+
+	formatted := FormatError(err)
+	assert.Contains(formatted, `render error in "BrokenTemplate"`)
+	// Stack traces are included
+	assert.Contains(formatted, "component.doRender")
+	assert.Greater(len(formatted), len(err.Error()))
+
+	// Errors not created by eris are formatted too
+	assert.Contains(FormatError(errors.New("plain error")), "plain error")
+	assert.Equal("", FormatError(nil))
+}
+
+type labeledError struct {
+	Code int
+}
+
+func (e *labeledError) Error() string {
+	return fmt.Sprintf("code %d", e.Code)
+}
+
+func TestErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	var errs Errors
+	assert.Nil(errs.ErrorOrNil())
+
+	AppendError(&errs, "Kuard", nil)
+	assert.Nil(errs.ErrorOrNil())
+
+	AppendError(&errs, "Kuard", eris.Wrap(ErrTemplateNotFound, "kuard.yaml"))
+	err := errs.ErrorOrNil()
+	assert.Equal("Kuard: kuard.yaml: template file not found in any of the template directories", err.Error())
+
+	AppendError(&errs, "document at index 2", &labeledError{Code: 42})
+	AppendError(&errs, "", errors.New("first line\nsecond line"))
+	err = errs.ErrorOrNil()
+	assert.Equal(
+		"3 errors occurred:\n"+
+			"  - Kuard: kuard.yaml: template file not found in any of the template directories\n"+
+			"  - document at index 2: code 42\n"+
+			"  - first line\n    second line",
+		err.Error(),
+	)
+
+	// Errors are found through the aggregate, also when it's wrapped
+	for _, wrapped := range []error{err, eris.Wrap(err, "render failed"), fmt.Errorf("render failed: %w", err)} {
+		assert.ErrorIs(wrapped, ErrTemplateNotFound)
+		assert.NotErrorIs(wrapped, ErrDocumentIndexOutOfRange)
+
+		var target *labeledError
+		assert.ErrorAs(wrapped, &target)
+		assert.Equal(42, target.Code)
+
+		var aggregate *Errors
+		assert.ErrorAs(wrapped, &aggregate)
+		assert.Len(aggregate.Entries, 3)
+	}
+}
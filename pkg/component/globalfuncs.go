@@ -0,0 +1,106 @@
+package component
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	template "text/template"
+
+	eris "github.com/rotisserie/eris"
+	templateEngine "k8s.io/helm/pkg/engine"
+)
+
+// Errors of `RegisterGlobalFunc`
+var (
+	ErrInvalidGlobalFunc  = eris.New("global template function is not a valid template function")
+	ErrGlobalFuncConflict = eris.New("global template function has the same name as an existing function")
+)
+
+// Functions added with `RegisterGlobalFunc`
+var globalFuncs struct {
+	mu      sync.RWMutex
+	funcMap template.FuncMap
+}
+
+// Functions that `text/template` defines itself
+var templateBuiltinFuncs = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or",
+	"print", "printf", "println", "urlquery", "eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// Add a template function that's available to all components created after this,
+// e.g. for an application-wide function library:
+//
+//	func init() {
+//		component.RegisterGlobalFunc("appName", func() string { return "kuard" })
+//	}
+//
+// The function must be valid for `template.FuncMap`, and must not have the same
+// name as a built-in function (Helm's, Helmfile's, ours, or `text/template`'s),
+// nor as another global function.
+//
+// NOTE: Components take the global functions when created. Functions registered
+// later are not available to the components created earlier.
+func RegisterGlobalFunc(name string, fn any) error {
+	if err := checkTemplateFunc(name, fn); err != nil {
+		return err
+	}
+	if isBuiltinFunc(name) {
+		return eris.Wrapf(ErrGlobalFuncConflict, "%q is a built-in function", name)
+	}
+
+	globalFuncs.mu.Lock()
+	defer globalFuncs.mu.Unlock()
+	if _, ok := globalFuncs.funcMap[name]; ok {
+		return eris.Wrapf(ErrGlobalFuncConflict, "%q is already registered", name)
+	}
+	if globalFuncs.funcMap == nil {
+		globalFuncs.funcMap = template.FuncMap{}
+	}
+	globalFuncs.funcMap[name] = fn
+	return nil
+}
+
+// Copy of the global functions, see `RegisterGlobalFunc`
+func globalFuncMap() template.FuncMap {
+	globalFuncs.mu.RLock()
+	defer globalFuncs.mu.RUnlock()
+
+	funcMap := template.FuncMap{}
+	for key, val := range globalFuncs.funcMap {
+		funcMap[key] = val
+	}
+	return funcMap
+}
+
+// NOTE: `text/template` panics on invalid functions, e.g. those that return
+// no value, so we let it check the function for us.
+func checkTemplateFunc(name string, fn any) (err error) {
+	if fn == nil || !isFunc(fn) {
+		return eris.Wrapf(ErrInvalidGlobalFunc, "%q must be a function, got %T", name, fn)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = eris.Wrapf(ErrInvalidGlobalFunc, "%q: %v", name, fmt.Sprint(r))
+		}
+	}()
+	template.New("").Funcs(template.FuncMap{name: fn})
+	return nil
+}
+
+func isBuiltinFunc(name string) bool {
+	if name == componentInfoFunc || slices.Contains(templateBuiltinFuncs, name) {
+		return true
+	}
+	if _, ok := genCustomFuncMap()[name]; ok {
+		return true
+	}
+	if _, ok := nilArgFuncMap()[name]; ok {
+		return true
+	}
+	if _, ok := templateEngine.New().FuncMap[name]; ok {
+		return true
+	}
+	_, ok := helmfileFuncMap("")[name]
+	return ok
+}
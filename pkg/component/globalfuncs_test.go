@@ -0,0 +1,85 @@
+package component
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Isolate the global functions of the test from the other tests
+func resetGlobalFuncs(t *testing.T) {
+	globalFuncs.mu.Lock()
+	funcMap := globalFuncs.funcMap
+	globalFuncs.funcMap = nil
+	globalFuncs.mu.Unlock()
+
+	t.Cleanup(func() {
+		globalFuncs.mu.Lock()
+		globalFuncs.funcMap = funcMap
+		globalFuncs.mu.Unlock()
+	})
+}
+
+func TestRegisterGlobalFunc(t *testing.T) {
+	assert := assert.New(t)
+	resetGlobalFuncs(t)
+
+	// Created before the function is registered
+	early, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\ndata:\n  name: {{ shout \"kuard\" }}\n",
+		},
+	)
+	assert.Nil(err)
+
+	err = RegisterGlobalFunc("shout", strings.ToUpper)
+	assert.Nil(err)
+
+	first, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\ndata:\n  name: {{ shout \"kuard\" }}\n",
+		},
+	)
+	assert.Nil(err)
+	instance, _, err := first.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("KUARD", instance.Data["name"])
+
+	second, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\ndata:\n  ns: {{ shout \"default\" }}\n",
+			InstanceFor: func(int) (corev1.ConfigMap, error) {
+				return corev1.ConfigMap{}, nil
+			},
+		},
+	)
+	assert.Nil(err)
+	instances, _, err := second.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("DEFAULT", instances[0].Data["ns"])
+
+	_, _, err = early.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), `function "shout" not defined`)
+
+	assert.Contains(AvailableFuncs(Def[corev1.ConfigMap, Input, struct{}]{}), "shout")
+}
+
+func TestRegisterGlobalFuncInvalid(t *testing.T) {
+	assert := assert.New(t)
+	resetGlobalFuncs(t)
+
+	assert.ErrorIs(RegisterGlobalFunc("toYaml", strings.ToUpper), ErrGlobalFuncConflict)
+	assert.ErrorIs(RegisterGlobalFunc("printf", strings.ToUpper), ErrGlobalFuncConflict)
+	assert.ErrorIs(RegisterGlobalFunc("indentRest", strings.ToUpper), ErrGlobalFuncConflict)
+	assert.ErrorIs(RegisterGlobalFunc("env", strings.ToUpper), ErrGlobalFuncConflict)
+
+	assert.Nil(RegisterGlobalFunc("shout", strings.ToUpper))
+	assert.ErrorIs(RegisterGlobalFunc("shout", strings.ToLower), ErrGlobalFuncConflict)
+
+	assert.ErrorIs(RegisterGlobalFunc("notFunc", "text"), ErrInvalidGlobalFunc)
+	assert.ErrorIs(RegisterGlobalFunc("noResult", func() {}), ErrInvalidGlobalFunc)
+	assert.ErrorIs(RegisterGlobalFunc("not-identifier", strings.ToUpper), ErrInvalidGlobalFunc)
+}
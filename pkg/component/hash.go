@@ -0,0 +1,57 @@
+package component
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	yaml "sigs.k8s.io/yaml"
+)
+
+// Hex-encoded sha256 digest of the rendered content, after it's normalized with
+// `normalizeContent`, so that formatting of the template doesn't affect the hash.
+func hashContent(content string) string {
+	hash := sha256.Sum256([]byte(normalizeContent(content)))
+	return hex.EncodeToString(hash[:])
+}
+
+// Hash of multiple documents, which doesn't depend on their order
+func hashContents(contents []string) string {
+	hashes := []string{}
+	for _, content := range contents {
+		hashes = append(hashes, hashContent(content))
+	}
+	sort.Strings(hashes)
+
+	hash := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+// Bring the content to a canonical form. YAML (and JSON) is re-marshalled to JSON,
+// which drops the comments, and sorts the keys.
+//
+// NOTE: Content that is not YAML is only stripped of trailing whitespace and empty lines.
+func normalizeContent(content string) string {
+	var data any
+	if err := yaml.Unmarshal([]byte(content), &data); err == nil {
+		if normalized, err := json.Marshal(data); err == nil {
+			return string(normalized)
+		}
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(normalizeNewlines(content), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalizeNewlines(v string) string {
+	v = strings.ReplaceAll(v, "\r\n", "\n")
+	return strings.ReplaceAll(v, "\r", "\n")
+}
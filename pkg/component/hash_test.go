@@ -0,0 +1,72 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestComponentHash(t *testing.T) {
+	assert := assert.New(t)
+
+	options := Options[Input]{TabSize: utils.PointerOf(2)}
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](`
+	apiVersion: v1
+	kind: ConfigMap
+	metadata:
+	  name: {{ .Helpa.Name }}
+	data:
+	  replicas: {{ .Helpa.Number | quote }}
+	`, nil, nil, options)
+	assert.Nil(err)
+
+	// Same content, but with comments, different key order and whitespace
+	reformatted, err := setupComponentInlineWithOptions[corev1.ConfigMap](`
+	# Config of kuard
+	kind:   ConfigMap
+	apiVersion: v1
+	data: { replicas: "{{ .Helpa.Number }}" }
+
+	metadata:
+	  name: {{ .Helpa.Name }}   # the name
+	`, nil, nil, options)
+	assert.Nil(err)
+
+	hash, err := comp.Hash(Input{Name: "kuard", Number: 2})
+	assert.Nil(err)
+	assert.Len(hash, 64)
+
+	reformattedHash, err := reformatted.Hash(Input{Name: "kuard", Number: 2})
+	assert.Nil(err)
+	assert.Equal(hash, reformattedHash)
+
+	changedHash, err := comp.Hash(Input{Name: "kuard", Number: 3})
+	assert.Nil(err)
+	assert.NotEqual(hash, changedHash)
+}
+
+func TestComponentMultiHash(t *testing.T) {
+	assert := assert.New(t)
+
+	makeInstances := func(Input, Context) ([]corev1.ConfigMap, error) {
+		return make([]corev1.ConfigMap, 2), nil
+	}
+
+	comp, err := setupComponentMultiInline("kind: ConfigMap\nmetadata: {name: first}\n---\nkind: ConfigMap\nmetadata: {name: {{ .Helpa.Name }}}", makeInstances, nil, nil)
+	assert.Nil(err)
+	// Documents in different order
+	reordered, err := setupComponentMultiInline("kind: ConfigMap\nmetadata:\n  name: {{ .Helpa.Name }}\n---\nkind: ConfigMap\nmetadata:\n  name: first", makeInstances, nil, nil)
+	assert.Nil(err)
+
+	hash, err := comp.Hash(Input{Name: "second"})
+	assert.Nil(err)
+	reorderedHash, err := reordered.Hash(Input{Name: "second"})
+	assert.Nil(err)
+	assert.Equal(hash, reorderedHash)
+
+	changedHash, err := comp.Hash(Input{Name: "third"})
+	assert.Nil(err)
+	assert.NotEqual(hash, changedHash)
+}
@@ -0,0 +1,91 @@
+package component
+
+import (
+	"strings"
+)
+
+// Emulation of Helm's built-in objects, so that templates which reference
+// `.Release`, `.Chart` or `.Capabilities` can be rendered by Helpa directly,
+// without handing off to Helm. See `Options.HelmBuiltins`.
+//
+// See https://helm.sh/docs/chart_template_guide/builtin_objects/
+type HelmBuiltins struct {
+	Release      HelmRelease
+	Chart        HelmChart
+	Capabilities HelmCapabilities
+}
+
+// Emulates Helm's `.Release`
+type HelmRelease struct {
+	Name      string
+	Namespace string
+	Revision  int
+	IsInstall bool
+	IsUpgrade bool
+	// Default: `Helm`
+	Service string
+}
+
+// Emulates Helm's `.Chart`
+type HelmChart struct {
+	Name       string
+	Version    string
+	AppVersion string
+}
+
+// Emulates Helm's `.Capabilities`
+type HelmCapabilities struct {
+	KubeVersion HelmKubeVersion
+	APIVersions HelmAPIVersions
+}
+
+// Emulates Helm's `.Capabilities.KubeVersion`
+type HelmKubeVersion struct {
+	// E.g. `v1.29.0`
+	Version string
+	Major   string
+	Minor   string
+}
+
+// So that `{{ .Capabilities.KubeVersion }}` renders the version, same as in Helm.
+func (v HelmKubeVersion) String() string {
+	return v.Version
+}
+
+// Same as `Version`, kept for compatibility with older Helm templates.
+func (v HelmKubeVersion) GitVersion() string {
+	return v.Version
+}
+
+// Emulates Helm's `.Capabilities.APIVersions`. Entries are either
+// group versions (`apps/v1`), or group versions with kind (`apps/v1/Deployment`).
+type HelmAPIVersions []string
+
+// Whether the API version, e.g. `batch/v1`, or resource, e.g. `batch/v1/CronJob`,
+// is available. Used in templates as `{{ if .Capabilities.APIVersions.Has "batch/v1" }}`.
+func (v HelmAPIVersions) Has(apiVersion string) bool {
+	for _, version := range v {
+		if version == apiVersion {
+			return true
+		}
+		// Resources imply their group version, e.g. `apps/v1/Deployment` implies `apps/v1`
+		kind, ok := strings.CutPrefix(version, apiVersion+"/")
+		if ok && !strings.Contains(kind, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Template data under the conventional Helm keys
+func (b HelmBuiltins) templateData() map[string]any {
+	release := b.Release
+	if release.Service == "" {
+		release.Service = "Helm"
+	}
+	return map[string]any{
+		"Release":      release,
+		"Chart":        b.Chart,
+		"Capabilities": b.Capabilities,
+	}
+}
@@ -0,0 +1,83 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestHelmAPIVersionsHas(t *testing.T) {
+	assert := assert.New(t)
+
+	versions := HelmAPIVersions{"v1", "apps/v1", "batch/v1/CronJob"}
+	assert.True(versions.Has("v1"))
+	assert.True(versions.Has("apps/v1"))
+	assert.True(versions.Has("batch/v1/CronJob"))
+	assert.True(versions.Has("batch/v1"))
+	assert.False(versions.Has("batch/v1beta1"))
+	assert.False(versions.Has("batch"))
+	assert.False(versions.Has("apps/v1/Deployment"))
+}
+
+func TestComponentHelmBuiltins(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Name: "BuiltinsConfigMap",
+			Template: `
+			apiVersion: v1
+			kind: ConfigMap
+			metadata:
+			  name: {{ .Release.Name }}-{{ .Chart.Name }}
+			  namespace: {{ .Release.Namespace }}
+			  labels:
+			    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version }}
+			    app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
+			    app.kubernetes.io/managed-by: {{ .Release.Service }}
+			data:
+			  revision: {{ .Release.Revision | quote }}
+			  kubeVersion: {{ .Capabilities.KubeVersion | quote }}
+			  kubeMinor: {{ .Capabilities.KubeVersion.Minor | quote }}
+			  {{- if .Capabilities.APIVersions.Has "batch/v1/CronJob" }}
+			  cronJob: "batch/v1"
+			  {{- end }}
+			  {{- if .Capabilities.APIVersions.Has "monitoring.coreos.com/v1" }}
+			  serviceMonitor: "yes"
+			  {{- end }}
+			  escaped: "{{! .Release.Name }}"
+			`,
+			Options: Options[Input]{
+				TabSize: utils.PointerOf(2),
+				HelmBuiltins: &HelmBuiltins{
+					Release: HelmRelease{Name: "my-release", Namespace: "apps", Revision: 3},
+					Chart:   HelmChart{Name: "kuard", Version: "0.1.0", AppVersion: "1.0"},
+					Capabilities: HelmCapabilities{
+						KubeVersion: HelmKubeVersion{Version: "v1.29.0", Major: "1", Minor: "29"},
+						APIVersions: HelmAPIVersions{"v1", "apps/v1", "batch/v1/CronJob"},
+					},
+				},
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("my-release-kuard", instance.Name)
+	assert.Equal("apps", instance.Namespace)
+	assert.Equal(map[string]string{
+		"helm.sh/chart":                "kuard-0.1.0",
+		"app.kubernetes.io/version":    "1.0",
+		"app.kubernetes.io/managed-by": "Helm",
+	}, instance.Labels)
+	assert.Equal(map[string]string{
+		"revision":    "3",
+		"kubeVersion": "v1.29.0",
+		"kubeMinor":   "29",
+		"cronJob":     "batch/v1",
+		"escaped":     "{{ .Release.Name }}",
+	}, instance.Data)
+}
@@ -0,0 +1,77 @@
+package component
+
+import (
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var ErrReservedContextField = eris.New("context field has a name reserved by Helpa")
+
+// Name of the template function that returns `ComponentInfo`
+const componentInfoFunc = "helpaComponent"
+
+const helpaModulePath = "github.com/jurooravec/helpa"
+
+// Metadata of the component that renders the template. Available in templates
+// as `{{ helpaComponent.Name }}`, e.g. for labels like `app.kubernetes.io/managed-by`:
+//
+//	metadata:
+//	  annotations:
+//	    example.com/generated-by: {{ helpaComponent.Name }}@{{ helpaComponent.Version }}
+type ComponentInfo struct {
+	Name string
+	// Template as given in `Def.Template`, so a file path if `TemplateIsFile`
+	Template string
+	// Version of Helpa that the binary was built with, e.g. `v0.7.0`.
+	// `(devel)` when built from within Helpa, `(unknown)` if not known.
+	Version string
+}
+
+// Version of the Helpa module, read from the build info
+var helpaVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(unknown)"
+	}
+	if info.Main.Path == helpaModulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == helpaModulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "(unknown)"
+})
+
+func newComponentInfo(name string, template string) ComponentInfo {
+	return ComponentInfo{Name: name, Template: template, Version: helpaVersion()}
+}
+
+// Context must not have a field named like `helpaComponent`, as e.g. a function
+// `HelpaComponent` would be easily confused with it in templates.
+//
+// NOTE: Checked on the type, so it's done once, at component creation.
+func checkReservedContextFields[TContext any]() error {
+	contextType := reflect.TypeOf((*TContext)(nil)).Elem()
+	for contextType.Kind() == reflect.Pointer {
+		contextType = contextType.Elem()
+	}
+	if contextType.Kind() != reflect.Struct {
+		return nil
+	}
+	for index := 0; index < contextType.NumField(); index++ {
+		field := contextType.Field(index)
+		if strings.EqualFold(field.Name, componentInfoFunc) {
+			return eris.Wrapf(ErrReservedContextField, "field %q clashes with template function %q", field.Name, componentInfoFunc)
+		}
+	}
+	return nil
+}
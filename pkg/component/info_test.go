@@ -0,0 +1,63 @@
+package component
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestComponentInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	template := `
+kind: ConfigMap
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: {{ helpaComponent.Name }}
+  annotations:
+    version: {{ helpaComponent.Version | quote }}
+`
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{Name: "Kuard", Template: template},
+	)
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("Kuard", instance.Labels["app.kubernetes.io/managed-by"])
+	assert.NotEmpty(instance.Annotations["version"])
+
+	compMulti, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name:     "KuardMulti",
+			Template: "kind: ConfigMap\ndata:\n  source: {{ helpaComponent.Template | quote }}\n",
+			InstanceFor: func(int) (corev1.ConfigMap, error) {
+				return corev1.ConfigMap{}, nil
+			},
+		},
+	)
+	assert.Nil(err)
+	instances, _, err := compMulti.Render(Input{})
+	assert.Nil(err)
+	assert.Contains(instances[0].Data["source"], "helpaComponent.Template")
+}
+
+type shadowingContext struct {
+	HelpaComponent func() string
+}
+
+func TestComponentInfoShadowing(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, shadowingContext]{Name: "Kuard", Template: "kind: ConfigMap\n"},
+	)
+	assert.ErrorIs(err, ErrReservedContextField)
+
+	_, err = CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, *shadowingContext]{Name: "Kuard", Template: "kind: ConfigMap\n"},
+	)
+	assert.ErrorIs(err, ErrReservedContextField)
+
+	assert.ErrorIs(RegisterGlobalFunc(componentInfoFunc, func() string { return "" }), ErrGlobalFuncConflict)
+}
@@ -0,0 +1,38 @@
+package component
+
+import (
+	"encoding/json"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrEscapedActionInJSON = eris.New("escaped Helm actions cannot be represented in JSON")
+)
+
+// Marshal the instance to canonical JSON, with sorted keys and without
+// whitespace. Types are marshalled with their JSON field names, so K8s objects
+// use their API field names.
+//
+// Fails with `ErrEscapedActionInJSON` if the content contains escaped Helm
+// actions, as these are meant to be rendered by Helm, which JSON output skips.
+func toCanonicalJSON(compName string, instance any, content string, replMap map[string]string) ([]byte, error) {
+	for _, action := range replMap {
+		if strings.Contains(content, action) {
+			return nil, eris.Wrapf(ErrEscapedActionInJSON, "found %q in %q. Render the component to YAML, and let Helm render it", action, compName)
+		}
+	}
+
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to marshal instance to JSON in %q", compName)
+	}
+
+	// NOTE: Maps are marshalled with sorted keys, so we go through one
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, eris.Wrapf(err, "failed to normalize JSON in %q", compName)
+	}
+	return json.Marshal(generic)
+}
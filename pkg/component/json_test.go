@@ -0,0 +1,81 @@
+package component
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	k8s "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+type jsonKuardInput struct {
+	Name      string
+	Container corev1.Container
+	Port      corev1.ContainerPort
+}
+
+type jsonKuardContext struct {
+	Input jsonKuardInput
+}
+
+func TestComponentMultiRenderJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[runtime.Object, jsonKuardInput, jsonKuardContext]{
+			Name:           "Kuard",
+			Template:       `../../examples/helm/helm.yaml`,
+			TemplateIsFile: true,
+			Setup: func(input jsonKuardInput) (jsonKuardContext, error) {
+				return jsonKuardContext{Input: input}, nil
+			},
+			GetInstances: func(jsonKuardInput, jsonKuardContext) ([]runtime.Object, error) {
+				return []runtime.Object{&k8s.Deployment{}, &corev1.Service{}}, nil
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instances, data, err := comp.RenderJSON(jsonKuardInput{
+		Name:      "kuard",
+		Container: corev1.Container{Name: "kuard", Image: "gcr.io/kuar-demo/kuard-amd64:1", ImagePullPolicy: "Always"},
+		Port:      corev1.ContainerPort{ContainerPort: 8080, Protocol: "TCP"},
+	})
+	assert.Nil(err)
+	assert.Len(instances, 2)
+	assert.Len(data, 2)
+
+	golden, err := os.ReadFile("testdata/kuard_deployment.json")
+	assert.Nil(err)
+	var compacted bytes.Buffer
+	err = json.Compact(&compacted, golden)
+	assert.Nil(err)
+	assert.Equal(compacted.String(), string(data[0]))
+}
+
+func TestComponentRenderJSONEscapedActions(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Name:     "ReleaseConfig",
+		Template: "apiVersion: v1\nkind: ConfigMap\ndata:\n  release: kuard",
+	}
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	_, data, err := comp.RenderJSON(Input{})
+	assert.Nil(err)
+	assert.Equal(`{"apiVersion":"v1","data":{"release":"kuard"},"kind":"ConfigMap","metadata":{"creationTimestamp":null}}`, string(data))
+
+	def.Template = "apiVersion: v1\nkind: ConfigMap\ndata:\n  release: \"{{! .Release.Name }}\""
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+
+	_, _, err = comp.RenderJSON(Input{})
+	assert.ErrorIs(err, ErrEscapedActionInJSON)
+	assert.Contains(err.Error(), `{{ .Release.Name }}`)
+}
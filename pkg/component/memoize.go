@@ -0,0 +1,254 @@
+package component
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// Max number of cached renders per component, unless set with `Options.MemoizeMaxEntries`
+const DefaultMemoizeMaxEntries = 128
+
+// Cache of render results, keyed by the hash of the input. When full,
+// the least recently used entry is evicted. See `Options.MemoizeRenders`.
+type renderCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// Most recently used entries are at the front
+	order      *list.List
+	maxEntries int
+	// Zero means the entries don't expire
+	ttl time.Duration
+	now func() time.Time
+}
+
+type renderCacheEntry[T any] struct {
+	key     string
+	value   T
+	expires time.Time
+}
+
+func newRenderCache[T any](maxEntries int, ttl time.Duration) *renderCache[T] {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMemoizeMaxEntries
+	}
+	return &renderCache[T]{
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+	}
+}
+
+func (c *renderCache[T]) get(key string) (value T, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return value, false
+	}
+	entry := elem.Value.(*renderCacheEntry[T])
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return value, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *renderCache[T]) set(key string, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &renderCacheEntry[T]{key: key, value: value, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderCacheEntry[T]).key)
+	}
+}
+
+func (c *renderCache[T]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Hex-encoded sha256 digest of the input.
+//
+// NOTE: Unlike JSON, the digest covers all fields of the input, also unexported
+// ones and those tagged `json:"-"`, as `Setup` can read them all.
+func hashInput(input any) (string, error) {
+	hash := sha256.New()
+	err := writeHashValue(hash, reflect.ValueOf(input), map[visitedPointer]bool{})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Pointer on the path from the input to the current value, see `writeHashValue`
+type visitedPointer struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// Write the value to `w`, so that values of the same type write the same bytes
+// only if they are equal. Pointers are followed, and map entries are sorted.
+//
+// Functions, channels and pointer cycles cannot be compared, so they fail.
+func writeHashValue(w io.Writer, v reflect.Value, visited map[visitedPointer]bool) error {
+	if !v.IsValid() {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	// NOTE: Type is written for every value, so e.g. interfaces holding
+	// values of different types don't collide.
+	writeHashString(w, v.Type().String())
+
+	switch v.Kind() {
+	case reflect.Bool:
+		writeHashUint(w, boolToUint(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeHashUint(w, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeHashUint(w, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeHashUint(w, math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		writeHashUint(w, math.Float64bits(real(v.Complex())))
+		writeHashUint(w, math.Float64bits(imag(v.Complex())))
+	case reflect.String:
+		writeHashString(w, v.String())
+	case reflect.Pointer, reflect.Interface:
+		writeHashUint(w, boolToUint(v.IsNil()))
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Interface {
+			return writeHashValue(w, v.Elem(), visited)
+		}
+		key := visitedPointer{ptr: v.Pointer(), typ: v.Type()}
+		if visited[key] {
+			return eris.Errorf("%s contains a pointer cycle", v.Type())
+		}
+		visited[key] = true
+		defer delete(visited, key)
+		return writeHashValue(w, v.Elem(), visited)
+	case reflect.Array, reflect.Slice:
+		if v.Kind() == reflect.Slice {
+			writeHashUint(w, boolToUint(v.IsNil()))
+		}
+		writeHashUint(w, uint64(v.Len()))
+		for index := 0; index < v.Len(); index++ {
+			if err := writeHashValue(w, v.Index(index), visited); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		writeHashUint(w, boolToUint(v.IsNil()))
+		writeHashUint(w, uint64(v.Len()))
+		// Entries are written in the order of their bytes, as maps have no order
+		entries := [][]byte{}
+		iter := v.MapRange()
+		for iter.Next() {
+			var buf bytes.Buffer
+			if err := writeHashValue(&buf, iter.Key(), visited); err != nil {
+				return err
+			}
+			if err := writeHashValue(&buf, iter.Value(), visited); err != nil {
+				return err
+			}
+			entries = append(entries, buf.Bytes())
+		}
+		slices.SortFunc(entries, bytes.Compare)
+		for _, entry := range entries {
+			writeHashString(w, string(entry))
+		}
+	case reflect.Struct:
+		for index := 0; index < v.NumField(); index++ {
+			writeHashString(w, v.Type().Field(index).Name)
+			if err := writeHashValue(w, v.Field(index), visited); err != nil {
+				return err
+			}
+		}
+	default:
+		return eris.Errorf("%s cannot be hashed", v.Type())
+	}
+	return nil
+}
+
+// NOTE: Strings are prefixed with their length, so e.g. "ab"+"c" differs from "a"+"bc"
+func writeHashString(w io.Writer, s string) {
+	writeHashUint(w, uint64(len(s)))
+	io.WriteString(w, s)
+}
+
+func writeHashUint(w io.Writer, n uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	w.Write(buf[:])
+}
+
+func boolToUint(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Wrap the render function, so that the results of successful renders are cached
+// by the input. Inputs that cannot be hashed, e.g. with functions, are rendered
+// without the cache, and emit a `WarningNotMemoizable` warning.
+func memoizeRender[TInput any, TResult any](
+	compName string,
+	options Options[TInput],
+	render func(input TInput) (TResult, error),
+) func(input TInput) (TResult, error) {
+	cache := newRenderCache[TResult](options.MemoizeMaxEntries, options.MemoizeTTL)
+
+	return func(input TInput) (TResult, error) {
+		key, err := hashInput(input)
+		if err != nil {
+			emitWarning(options, Warning{
+				Component: compName,
+				Code:      WarningNotMemoizable,
+				Message:   fmt.Sprintf("input cannot be used as cache key: %v", err),
+			})
+			return render(input)
+		}
+
+		if result, ok := cache.get(key); ok {
+			return result, nil
+		}
+
+		result, err := render(input)
+		// NOTE: Failed renders are not cached, so they are retried
+		if err != nil {
+			return result, err
+		}
+		cache.set(key, result)
+		return result, nil
+	}
+}
@@ -0,0 +1,243 @@
+package component
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Fails for negative numbers, so failed renders can be tested too
+const memoizedTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Helpa.Name }}
+{{- if hasPrefix "-" .Helpa.Number }}{{ fail "negative number" }}{{ end }}`
+
+// Count the renders that were not served from the cache, as `Defaults`
+// is called only by those
+func countRenders(renders *int) func() Input {
+	return func() Input {
+		*renders++
+		return Input{}
+	}
+}
+
+func TestComponentMemoizeRenders(t *testing.T) {
+	assert := assert.New(t)
+
+	renders := 0
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](memoizedTemplate, nil, countRenders(&renders), Options[Input]{MemoizeRenders: true})
+	assert.Nil(err)
+
+	instance, content, err := comp.Render(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+	assert.Equal(1, renders)
+
+	// Same input hits the cache
+	cachedInstance, cachedContent, err := comp.Render(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal(instance, cachedInstance)
+	assert.Equal(content, cachedContent)
+	assert.Equal(1, renders)
+
+	// Other methods use the cache too
+	_, err = comp.Hash(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal(1, renders)
+
+	// Different input is rendered
+	instance, _, err = comp.Render(Input{Name: "other"})
+	assert.Nil(err)
+	assert.Equal("other", instance.Name)
+	assert.Equal(2, renders)
+}
+
+func TestComponentMemoizeRendersSkipsErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	renders := 0
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](memoizedTemplate, nil, countRenders(&renders), Options[Input]{MemoizeRenders: true})
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{Number: -1})
+	assert.NotNil(err)
+	_, _, err = comp.Render(Input{Number: -1})
+	assert.NotNil(err)
+	assert.Equal(2, renders)
+}
+
+func TestComponentMemoizeRendersMaxEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	renders := 0
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](memoizedTemplate, nil, countRenders(&renders), Options[Input]{MemoizeRenders: true, MemoizeMaxEntries: 1})
+	assert.Nil(err)
+
+	comp.Render(Input{Name: "first"})
+	comp.Render(Input{Name: "second"})
+	// First was dropped when second was cached
+	comp.Render(Input{Name: "first"})
+	assert.Equal(3, renders)
+}
+
+func TestComponentMultiMemoizeRenders(t *testing.T) {
+	assert := assert.New(t)
+
+	setupCalls := 0
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, Input]{
+			Name:     "MemoizedConfigMaps",
+			Template: "metadata:\n  name: {{ .Helpa.Name }}-a\n---\nmetadata:\n  name: {{ .Helpa.Name }}-b",
+			Setup: func(input Input) (Input, error) {
+				setupCalls++
+				return input, nil
+			},
+			GetInstances: func(Input, Input) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 2), nil
+			},
+			Options: Options[Input]{MemoizeRenders: true},
+		},
+	)
+	assert.Nil(err)
+
+	instances, _, err := comp.Render(Input{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal("kuard-b", instances[1].Name)
+
+	instance, _, err := comp.RenderIndex(Input{Name: "kuard"}, 0)
+	assert.Nil(err)
+	assert.Equal("kuard-a", instance.Name)
+	assert.Equal(1, setupCalls)
+}
+
+func TestComponentMemoizeRendersNotMemoizable(t *testing.T) {
+	assert := assert.New(t)
+
+	type funcInput struct {
+		Name  string
+		Hooks func()
+	}
+
+	setupCalls := 0
+	warnings := []Warning{}
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, funcInput, Input]{
+			Name:     "UnhashableConfigMap",
+			Template: "metadata:\n  name: {{ .Helpa.Name }}",
+			Setup: func(input funcInput) (Input, error) {
+				setupCalls++
+				return Input{Name: input.Name}, nil
+			},
+			Options: Options[funcInput]{
+				MemoizeRenders: true,
+				OnWarning:      func(w Warning) { warnings = append(warnings, w) },
+			},
+		},
+	)
+	assert.Nil(err)
+
+	comp.Render(funcInput{Name: "kuard"})
+	instance, _, err := comp.Render(funcInput{Name: "kuard"})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+	assert.Equal(2, setupCalls)
+	assert.Len(warnings, 2)
+	assert.Equal(WarningNotMemoizable, warnings[0].Code)
+	assert.Equal("UnhashableConfigMap", warnings[0].Component)
+}
+
+func TestHashInput(t *testing.T) {
+	assert := assert.New(t)
+
+	type hashedInput struct {
+		Name    string
+		Secret  string   `json:"-"`
+		Tags    []string `json:",omitempty"`
+		Labels  map[string]string
+		private int
+	}
+
+	hash := func(input any) string {
+		key, err := hashInput(input)
+		assert.Nil(err)
+		return key
+	}
+
+	base := hash(hashedInput{Name: "kuard"})
+	// Fields that JSON skips still change the key
+	assert.NotEqual(base, hash(hashedInput{Name: "kuard", Secret: "s3cr3t"}))
+	assert.NotEqual(base, hash(hashedInput{Name: "kuard", private: 1}))
+	assert.NotEqual(base, hash(hashedInput{Name: "kuard", Tags: []string{}}))
+	// Map entries have no order
+	labels := map[string]string{}
+	for i := 0; i < 20; i++ {
+		labels[fmt.Sprint(i)] = fmt.Sprint(i)
+	}
+	assert.Equal(hash(hashedInput{Labels: labels}), hash(hashedInput{Labels: labels}))
+	// Same values of different types differ
+	assert.NotEqual(hash(int32(1)), hash(int64(1)))
+
+	type cycle struct{ Next *cycle }
+	looped := &cycle{}
+	looped.Next = looped
+	_, err := hashInput(looped)
+	assert.NotNil(err)
+}
+
+func TestRenderCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newRenderCache[string](0, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.set("key", "value")
+	value, ok := cache.get("key")
+	assert.True(ok)
+	assert.Equal("value", value)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.get("key")
+	assert.False(ok)
+	assert.Equal(0, cache.len())
+}
+
+func TestRenderCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newRenderCache[string](2, 0)
+	cache.set("a", "1")
+	cache.set("b", "2")
+	// Using "a" makes "b" the least recently used
+	cache.get("a")
+	cache.set("c", "3")
+
+	_, ok := cache.get("b")
+	assert.False(ok)
+	_, ok = cache.get("a")
+	assert.True(ok)
+	_, ok = cache.get("c")
+	assert.True(ok)
+	assert.Equal(2, cache.len())
+}
+
+func BenchmarkRenderMemoized(b *testing.B) {
+	renders := 0
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](memoizedTemplate, nil, countRenders(&renders), Options[Input]{MemoizeRenders: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err = comp.Render(Input{Name: "kuard"})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
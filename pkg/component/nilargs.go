@@ -0,0 +1,285 @@
+package component
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	template "text/template"
+	"text/template/parse"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrMissingKeyArg       = eris.New("value from a missing key was passed to a context function")
+	ErrInvalidNilArgPolicy = eris.New("invalid `Options.NilArgPolicy`")
+)
+
+// What happens when a context function receives a value from a missing key,
+// e.g. `{{ Catify .Helpa.Labels.missing }}`. See `Options.NilArgPolicy`.
+type NilArgPolicy string
+
+const (
+	// The function receives the zero value, as with plain `missingkey=zero`
+	NilArgPolicyZero NilArgPolicy = "zero"
+	// The render fails with `ErrMissingKeyArg`
+	NilArgPolicyError NilArgPolicy = "error"
+	// The action that calls the function renders empty
+	NilArgPolicySkip NilArgPolicy = "skip"
+)
+
+// Names of the template functions that check the arguments of context functions.
+// They are not meant to be called by users, see `rewriteNilArgs`.
+const (
+	nilArgFuncLookup = "__helpaLookupArg"
+	nilArgFuncHas    = "__helpaHasArg"
+)
+
+func checkNilArgPolicy(policy NilArgPolicy) error {
+	switch policy {
+	case "", NilArgPolicyZero, NilArgPolicyError, NilArgPolicySkip:
+		return nil
+	}
+	return eris.Wrapf(ErrInvalidNilArgPolicy, "got %q", policy)
+}
+
+// Template functions used by the rewritten templates, see `rewriteNilArgs`
+func nilArgFuncMap() template.FuncMap {
+	return template.FuncMap{
+		// Same as evaluating the field chain, but fails if a key is missing
+		nilArgFuncLookup: func(funcName string, path string, receiver reflect.Value, fields ...string) (reflect.Value, error) {
+			val, missing, err := lookupFieldChain(receiver, fields)
+			if err == nil && missing {
+				err = eris.Wrapf(ErrMissingKeyArg, "%q passed to %q", path, funcName)
+			}
+			return val, err
+		},
+		// NOTE: Other errors are left for the action to report
+		nilArgFuncHas: func(receiver reflect.Value, fields ...string) bool {
+			_, missing, _ := lookupFieldChain(receiver, fields)
+			return !missing
+		},
+	}
+}
+
+// Evaluate the fields on the receiver like `text/template` does, but tell
+// if a map key along the way is missing, or if a value is nil.
+func lookupFieldChain(receiver reflect.Value, fields []string) (val reflect.Value, missing bool, err error) {
+	val = receiver
+	for _, field := range fields {
+		// Methods may be defined on the pointer
+		ptr := val
+		if ptr.Kind() != reflect.Interface && ptr.Kind() != reflect.Pointer && ptr.CanAddr() {
+			ptr = ptr.Addr()
+		}
+		if method := ptr.MethodByName(field); method.IsValid() && method.Type().NumIn() == 0 {
+			out := method.Call(nil)
+			if len(out) == 2 && !out[1].IsNil() {
+				return val, false, out[1].Interface().(error)
+			}
+			val = out[0]
+			continue
+		}
+
+		for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+			if val.IsNil() {
+				return val, true, nil
+			}
+			val = val.Elem()
+		}
+
+		switch val.Kind() {
+		case reflect.Struct:
+			fieldVal := val.FieldByName(field)
+			if !fieldVal.IsValid() {
+				return val, false, fmt.Errorf("can't evaluate field %s in type %s", field, val.Type())
+			}
+			val = fieldVal
+		case reflect.Map:
+			key := reflect.ValueOf(field)
+			if !key.Type().AssignableTo(val.Type().Key()) {
+				return val, false, fmt.Errorf("can't evaluate field %s in type %s", field, val.Type())
+			}
+			item := val.MapIndex(key)
+			if !item.IsValid() {
+				return reflect.Zero(val.Type().Elem()), true, nil
+			}
+			val = item
+		default:
+			return val, false, fmt.Errorf("can't evaluate field %s in type %s", field, val.Type())
+		}
+	}
+	return val, false, nil
+}
+
+// Rewrite the parsed templates, so that field chains passed to context functions
+// (e.g. `.Helpa.Labels.app` in `{{ Catify .Helpa.Labels.app }}`) are checked
+// for missing keys, as set by the policy.
+//
+// NOTE: `text/template` has no hook for when a key is missing. So instead,
+// the field chains are evaluated by our own functions, see `nilArgFuncMap`.
+func rewriteNilArgs(tmpl *template.Template, contextFuncs template.FuncMap, policy NilArgPolicy) {
+	if policy == "" || policy == NilArgPolicyZero || len(contextFuncs) == 0 {
+		return
+	}
+	rewriter := nilArgRewriter{policy: policy, contextFuncs: contextFuncs}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Tree.Root != nil {
+			rewriter.rewriteList(t.Tree.Root)
+		}
+	}
+}
+
+type nilArgRewriter struct {
+	policy       NilArgPolicy
+	contextFuncs template.FuncMap
+}
+
+func (r nilArgRewriter) rewriteList(list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for index, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.ActionNode:
+			// NOTE: Actions that declare variables cannot be wrapped in `if`,
+			// as the variables would go out of scope. These fail instead.
+			if r.policy == NilArgPolicySkip && len(node.Pipe.Decl) == 0 {
+				list.Nodes[index] = r.wrapInChecks(node)
+			} else {
+				r.rewritePipe(node.Pipe)
+			}
+		case *parse.IfNode:
+			r.rewriteBranch(&node.BranchNode)
+		case *parse.RangeNode:
+			r.rewriteBranch(&node.BranchNode)
+		case *parse.WithNode:
+			r.rewriteBranch(&node.BranchNode)
+		case *parse.TemplateNode:
+			r.rewritePipe(node.Pipe)
+		case *parse.ListNode:
+			r.rewriteList(node)
+		}
+	}
+}
+
+// NOTE: Conditions of `if`, `with` and `range` don't render anything, so
+// with `NilArgPolicySkip`, they fail as with `NilArgPolicyError`.
+func (r nilArgRewriter) rewriteBranch(branch *parse.BranchNode) {
+	r.rewritePipe(branch.Pipe)
+	r.rewriteList(branch.List)
+	r.rewriteList(branch.ElseList)
+}
+
+// Replace the field chains with calls to `nilArgFuncLookup`
+func (r nilArgRewriter) rewritePipe(pipe *parse.PipeNode) {
+	r.eachChainArg(pipe, func(funcName string, args []parse.Node, index int) {
+		receiver, fields := splitFieldChain(args[index])
+		pos := args[index].Position()
+		args[index] = newPipe(pos, append(
+			[]parse.Node{
+				parse.NewIdentifier(nilArgFuncLookup).SetPos(pos),
+				newString(pos, funcName),
+				newString(pos, args[index].String()),
+				receiver,
+			},
+			newStrings(pos, fields)...,
+		))
+	})
+}
+
+// Wrap the action in `{{ if __helpaHasArg ... }}` for each field chain,
+// so the action renders only if none of the keys are missing.
+func (r nilArgRewriter) wrapInChecks(action *parse.ActionNode) parse.Node {
+	var node parse.Node = action
+	r.eachChainArg(action.Pipe, func(_ string, args []parse.Node, index int) {
+		receiver, fields := splitFieldChain(args[index])
+		pos := args[index].Position()
+		check := newPipe(pos, append(
+			[]parse.Node{parse.NewIdentifier(nilArgFuncHas).SetPos(pos), receiver},
+			newStrings(pos, fields)...,
+		))
+		node = &parse.IfNode{BranchNode: parse.BranchNode{
+			NodeType: parse.NodeIf,
+			Pos:      action.Pos,
+			Line:     action.Line,
+			Pipe:     check,
+			List:     &parse.ListNode{NodeType: parse.NodeList, Pos: action.Pos, Nodes: []parse.Node{node}},
+		}}
+	})
+	return node
+}
+
+// Call `fn` for each argument of a context function in the pipeline that is
+// a field chain, e.g. `.Helpa.Name` or `$x.Name`.
+func (r nilArgRewriter) eachChainArg(pipe *parse.PipeNode, fn func(funcName string, args []parse.Node, index int)) {
+	if pipe == nil {
+		return
+	}
+	for cmdIndex, cmd := range pipe.Cmds {
+		// Nested pipelines, e.g. `(Catify .Helpa.Name)`
+		for _, arg := range cmd.Args {
+			if nested, ok := arg.(*parse.PipeNode); ok {
+				r.eachChainArg(nested, fn)
+			}
+		}
+
+		ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+		if !ok || r.contextFuncs[ident.Ident] == nil {
+			continue
+		}
+		for index := 1; index < len(cmd.Args); index++ {
+			if isFieldChain(cmd.Args[index]) {
+				fn(ident.Ident, cmd.Args, index)
+			}
+		}
+		// Piped value, e.g. `.Helpa.Name | Catify`
+		if cmdIndex > 0 {
+			prev := pipe.Cmds[cmdIndex-1]
+			if len(prev.Args) == 1 && isFieldChain(prev.Args[0]) {
+				fn(ident.Ident, prev.Args, 0)
+			}
+		}
+	}
+}
+
+func isFieldChain(node parse.Node) bool {
+	switch node := node.(type) {
+	case *parse.FieldNode:
+		return true
+	case *parse.VariableNode:
+		return len(node.Ident) > 1
+	}
+	return false
+}
+
+// Split e.g. `$x.Name.First` to `$x` and `["Name", "First"]`
+func splitFieldChain(node parse.Node) (receiver parse.Node, fields []string) {
+	switch node := node.(type) {
+	case *parse.FieldNode:
+		return &parse.DotNode{NodeType: parse.NodeDot, Pos: node.Pos}, node.Ident
+	case *parse.VariableNode:
+		return &parse.VariableNode{NodeType: parse.NodeVariable, Pos: node.Pos, Ident: node.Ident[:1]}, node.Ident[1:]
+	}
+	return node, nil
+}
+
+func newPipe(pos parse.Pos, args []parse.Node) *parse.PipeNode {
+	return &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      pos,
+		Cmds:     []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: pos, Args: args}},
+	}
+}
+
+func newString(pos parse.Pos, text string) *parse.StringNode {
+	return &parse.StringNode{NodeType: parse.NodeString, Pos: pos, Quoted: strconv.Quote(text), Text: text}
+}
+
+func newStrings(pos parse.Pos, texts []string) []parse.Node {
+	nodes := []parse.Node{}
+	for _, text := range texts {
+		nodes = append(nodes, newString(pos, text))
+	}
+	return nodes
+}
@@ -0,0 +1,94 @@
+package component
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+type nilArgContext struct {
+	Labels map[string]string
+	Catify func(s string) string
+}
+
+func renderNilArgTemplate(template string, policy NilArgPolicy) (string, error) {
+	comp, err := CreateComponent(
+		Def[string, Input, nilArgContext]{
+			Name:     "NilArgs",
+			Template: template,
+			Setup: func(input Input) (nilArgContext, error) {
+				return nilArgContext{
+					Labels: map[string]string{"app": "kuard"},
+					Catify: func(s string) string { return fmt.Sprintf("🐈 %s 🐈", s) },
+				}, nil
+			},
+			Render: func(_ Input, _ nilArgContext, content string) (string, error) {
+				return content, nil
+			},
+			Options: Options[Input]{NilArgPolicy: policy},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	_, content, err := comp.Render(Input{})
+	return content, err
+}
+
+func TestComponentNilArgPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	template := "name: {{ Catify .Helpa.Labels.app }}\n" +
+		"team: {{ Catify .Helpa.Labels.team }}\n" +
+		"{{- $labels := .Helpa.Labels }}\n" +
+		"owner: {{ $labels.owner | Catify }}\n" +
+		"plain: {{ .Helpa.Labels.team }}"
+
+	content, err := renderNilArgTemplate(template, "")
+	assert.Nil(err)
+	assert.Equal("name: 🐈 kuard 🐈\nteam: 🐈  🐈\nowner: 🐈  🐈\nplain: ", content)
+
+	content, err = renderNilArgTemplate(template, NilArgPolicyZero)
+	assert.Nil(err)
+	assert.Equal("name: 🐈 kuard 🐈\nteam: 🐈  🐈\nowner: 🐈  🐈\nplain: ", content)
+
+	_, err = renderNilArgTemplate(template, NilArgPolicyError)
+	assert.ErrorIs(err, ErrMissingKeyArg)
+	assert.Contains(err.Error(), `".Helpa.Labels.team" passed to "Catify"`)
+
+	// Only the actions that pass missing keys to functions are affected
+	content, err = renderNilArgTemplate(template, NilArgPolicySkip)
+	assert.Nil(err)
+	assert.Equal("name: 🐈 kuard 🐈\nteam: \nowner: \nplain: ", content)
+}
+
+func TestComponentNilArgPolicyPresentKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	template := `{{ $labels := .Helpa.Labels }}{{ Catify .Helpa.Labels.app }} {{ $labels.app | Catify }} {{ (Catify $.Helpa.Labels.app) }}`
+	for _, policy := range []NilArgPolicy{NilArgPolicyZero, NilArgPolicyError, NilArgPolicySkip} {
+		content, err := renderNilArgTemplate(template, policy)
+		assert.Nil(err, policy)
+		assert.Equal("🐈 kuard 🐈 🐈 kuard 🐈 🐈 kuard 🐈", content, policy)
+	}
+}
+
+func TestComponentNilArgPolicySkipInCondition(t *testing.T) {
+	assert := assert.New(t)
+
+	// Conditions don't render anything, so these fail instead
+	_, err := renderNilArgTemplate(`{{ if Catify .Helpa.Labels.team }}yes{{ end }}`, NilArgPolicySkip)
+	assert.ErrorIs(err, ErrMissingKeyArg)
+
+	// Same for actions that declare variables
+	_, err = renderNilArgTemplate(`{{ $team := Catify .Helpa.Labels.team }}{{ $team }}`, NilArgPolicySkip)
+	assert.ErrorIs(err, ErrMissingKeyArg)
+}
+
+func TestComponentNilArgPolicyInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := renderNilArgTemplate(`{{ Catify .Helpa.Labels.app }}`, "fail")
+	assert.ErrorIs(err, ErrInvalidNilArgPolicy)
+}
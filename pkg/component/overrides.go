@@ -0,0 +1,189 @@
+package component
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	yaml "sigs.k8s.io/yaml"
+)
+
+// Errors of `Component.RenderWithValues`
+var (
+	ErrUnknownOverride  = eris.New("override sets a field that the context doesn't have")
+	ErrOverrideFunction = eris.New("functions of the context cannot be overridden")
+	ErrInvalidOverride  = eris.New("override value cannot be converted to the type of the field")
+)
+
+// Set the overrides on a copy of the context. Keys are dotted paths to the fields,
+// e.g. `Image.Tag`, and may also set keys of maps.
+//
+// NOTE: Maps and pointers are copied along the path, so the values that
+// the context shares with the input are not modified.
+func applyOverrides[TContext any](context TContext, overrides map[string]any) (TContext, error) {
+	if len(overrides) == 0 {
+		return context, nil
+	}
+
+	// Sorted, so the errors are deterministic
+	paths := []string{}
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	current := reflect.ValueOf(&context).Elem()
+	for _, path := range paths {
+		updated, err := withOverride(current, strings.Split(path, "."), path, overrides[path])
+		if err != nil {
+			return context, err
+		}
+		current.Set(updated)
+	}
+	return context, nil
+}
+
+// Return a copy of `current` with the value set at the path
+func withOverride(current reflect.Value, path []string, fullPath string, value any) (reflect.Value, error) {
+	if len(path) == 0 {
+		return coerceOverride(value, current.Type(), fullPath)
+	}
+	switch current.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(current.Type().Elem()).Elem()
+		if !current.IsNil() {
+			elem.Set(current.Elem())
+		}
+		updated, err := withOverride(elem, path, fullPath, value)
+		if err != nil {
+			return current, err
+		}
+		ptr := reflect.New(current.Type().Elem())
+		ptr.Elem().Set(updated)
+		return ptr, nil
+
+	case reflect.Interface:
+		if current.IsNil() {
+			return current, eris.Wrapf(ErrUnknownOverride, "cannot set %q, %q is nil", fullPath, path[0])
+		}
+		updated, err := withOverride(current.Elem(), path, fullPath, value)
+		if err != nil {
+			return current, err
+		}
+		out := reflect.New(current.Type()).Elem()
+		out.Set(updated)
+		return out, nil
+
+	case reflect.Struct:
+		copy := reflect.New(current.Type()).Elem()
+		copy.Set(current)
+
+		structField, ok := current.Type().FieldByName(path[0])
+		if !ok || !structField.IsExported() {
+			return current, eris.Wrapf(ErrUnknownOverride, "cannot set %q, field %q not found, available fields: %s", fullPath, path[0], strings.Join(overridableFields(current.Type()), ", "))
+		}
+		if structField.Type.Kind() == reflect.Func {
+			return current, eris.Wrapf(ErrOverrideFunction, "cannot set %q", fullPath)
+		}
+
+		field := copy.FieldByIndex(structField.Index)
+		updated, err := withOverride(field, path[1:], fullPath, value)
+		if err != nil {
+			return current, err
+		}
+		field.Set(updated)
+		return copy, nil
+
+	case reflect.Map:
+		if current.Type().Key().Kind() != reflect.String {
+			return current, eris.Wrapf(ErrUnknownOverride, "cannot set %q, keys of %s are not strings", fullPath, current.Type())
+		}
+		copy := reflect.MakeMapWithSize(current.Type(), current.Len()+1)
+		iter := current.MapRange()
+		for iter.Next() {
+			copy.SetMapIndex(iter.Key(), iter.Value())
+		}
+
+		key := reflect.ValueOf(path[0]).Convert(current.Type().Key())
+		item := current.MapIndex(key)
+		if !item.IsValid() {
+			item = reflect.Zero(current.Type().Elem())
+		}
+		updated, err := withOverride(item, path[1:], fullPath, value)
+		if err != nil {
+			return current, err
+		}
+		copy.SetMapIndex(key, updated)
+		return copy, nil
+	}
+
+	return current, eris.Wrapf(ErrUnknownOverride, "cannot set %q, %s has no fields", fullPath, current.Type())
+}
+
+// Convert the override value to the type of the field. Strings are parsed
+// as YAML (e.g. from `--set Replicas=3`), other values are converted via JSON.
+func coerceOverride(value any, t reflect.Type, fullPath string) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	if t.Kind() == reflect.Func {
+		return reflect.Value{}, eris.Wrapf(ErrOverrideFunction, "cannot set %q", fullPath)
+	}
+
+	val := reflect.ValueOf(value)
+	if val.Type().AssignableTo(t) {
+		out := reflect.New(t).Elem()
+		out.Set(val)
+		return out, nil
+	}
+	// E.g. `type ImageTag string`
+	if val.Kind() == reflect.String && t.Kind() == reflect.String {
+		return val.Convert(t), nil
+	}
+	// E.g. `Tag: 2` for a string tag, like Helm's `--set`
+	if t.Kind() == reflect.String && isScalarKind(val.Kind()) {
+		return reflect.ValueOf(fmt.Sprint(value)).Convert(t), nil
+	}
+
+	out := reflect.New(t)
+	var err error
+	if str, ok := value.(string); ok {
+		err = yaml.Unmarshal([]byte(str), out.Interface())
+	} else {
+		var data []byte
+		data, err = json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(data, out.Interface())
+		}
+	}
+	if err != nil {
+		return reflect.Value{}, eris.Wrapf(ErrInvalidOverride, "cannot use %#v as %s for %q: %v", value, t, fullPath, err)
+	}
+	return out.Elem(), nil
+}
+
+// Exported fields of the struct that may be overridden, i.e. that are not functions
+func overridableFields(t reflect.Type) []string {
+	names := []string{}
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		if field.IsExported() && field.Type.Kind() != reflect.Func {
+			names = append(names, field.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
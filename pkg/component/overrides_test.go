@@ -0,0 +1,116 @@
+package component
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type overrideImage struct {
+	Repository string
+	Tag        string
+}
+
+type overrideContext struct {
+	Image    overrideImage
+	Replicas int
+	Labels   map[string]string
+	Catify   func(s string) string
+}
+
+// Labels of `overrideDef`, to check that the overrides don't modify them
+var overrideLabels = map[string]string{"app": "kuard"}
+
+var overrideDef = Def[corev1.ConfigMap, Input, overrideContext]{
+	Name: "OverriddenConfigMap",
+	Template: `
+	apiVersion: v1
+	kind: ConfigMap
+	metadata:
+	  name: {{ Catify "kuard" }}
+	  labels: {{ .Helpa.Labels | toJson }}
+	data:
+	  image: {{ .Helpa.Image.Repository }}:{{ .Helpa.Image.Tag }}
+	  replicas: "{{ .Helpa.Replicas }}"
+	`,
+	Setup: func(input Input) (overrideContext, error) {
+		return overrideContext{
+			Image:    overrideImage{Repository: "kuard", Tag: "1"},
+			Replicas: 1,
+			Labels:   overrideLabels,
+			Catify:   func(s string) string { return fmt.Sprintf("cat-%s", s) },
+		}, nil
+	},
+	Options: Options[Input]{TabSize: utils.PointerOf(2)},
+}
+
+func TestComponentRenderWithValues(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(overrideDef)
+	assert.Nil(err)
+
+	instance, _, err := comp.RenderWithValues(Input{}, map[string]any{
+		"Image.Tag":        "2",
+		"Replicas":         "3",
+		"Labels.team":      "infra",
+		"Image.Repository": "registry/kuard",
+	})
+	assert.Nil(err)
+	assert.Equal("registry/kuard:2", instance.Data["image"])
+	assert.Equal("3", instance.Data["replicas"])
+	assert.Equal(map[string]string{"app": "kuard", "team": "infra"}, instance.Labels)
+
+	// Values shared with the context are not modified
+	assert.Equal(map[string]string{"app": "kuard"}, overrideLabels)
+
+	// Without overrides, it's same as `Render`
+	instance, _, err = comp.RenderWithValues(Input{}, nil)
+	assert.Nil(err)
+	assert.Equal("kuard:1", instance.Data["image"])
+}
+
+func TestComponentRenderWithValuesErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(overrideDef)
+	assert.Nil(err)
+
+	_, _, err = comp.RenderWithValues(Input{}, map[string]any{"Catify": "meow"})
+	assert.ErrorIs(err, ErrOverrideFunction)
+
+	_, _, err = comp.RenderWithValues(Input{}, map[string]any{"Imag.Tag": "2"})
+	assert.ErrorIs(err, ErrUnknownOverride)
+	assert.Contains(err.Error(), "available fields: Image, Labels, Replicas")
+
+	_, _, err = comp.RenderWithValues(Input{}, map[string]any{"Image.Tag.Major": "2"})
+	assert.ErrorIs(err, ErrUnknownOverride)
+
+	_, _, err = comp.RenderWithValues(Input{}, map[string]any{"Replicas": "many"})
+	assert.ErrorIs(err, ErrInvalidOverride)
+	assert.Contains(err.Error(), `"Replicas"`)
+}
+
+func TestComponentMultiRenderWithValues(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, overrideContext]{
+			Name:     "OverriddenConfigMaps",
+			Template: "metadata:\n  name: {{ .Helpa.Image.Tag }}-a\n---\nmetadata:\n  name: {{ .Helpa.Image.Tag }}-b",
+			Setup: func(input Input) (overrideContext, error) {
+				return overrideContext{Image: overrideImage{Tag: "1"}}, nil
+			},
+			InstanceFor: func(int) (corev1.ConfigMap, error) { return corev1.ConfigMap{}, nil },
+		},
+	)
+	assert.Nil(err)
+
+	instances, _, err := comp.RenderWithValues(Input{}, map[string]any{"Image.Tag": 2})
+	assert.Nil(err)
+	assert.Equal("2-a", instances[0].Name)
+	assert.Equal("2-b", instances[1].Name)
+}
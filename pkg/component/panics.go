@@ -0,0 +1,73 @@
+package component
+
+import (
+	"fmt"
+	"reflect"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// Panics during rendering are returned as errors (unless `Options.PanicOnError`
+// is set), wrapping one of these, depending on where the panic occurred.
+var (
+	// Panic in `Defaults` or `Setup`
+	ErrSetupPanic = eris.New("panic while setting up the context")
+	// Panic in a template function
+	ErrRenderPanic = eris.New("panic while rendering the template")
+	// Panic in `GetInstances`, `Render`, `Options.Unmarshal` or `Validate`
+	ErrUnmarshalPanic = eris.New("panic while unmarshalling the rendered template")
+)
+
+// Stage of the rendering that's currently executing
+type renderStage string
+
+const (
+	stageSetup        renderStage = "setup"
+	stageRender       renderStage = "render"
+	stageSplit        renderStage = "split"
+	stageUnmarshal    renderStage = "unmarshal"
+	stageCustomRender renderStage = "custom-render"
+	stageValidate     renderStage = "validate"
+)
+
+// Convert a recovered panic to an error that tells the stage where it happened.
+func panicToError(compName string, stage renderStage, recovered any) error {
+	// Template functions already report the panic, see `recoverTemplateFunc`
+	if err, ok := recovered.(error); ok && eris.Is(err, ErrRenderPanic) {
+		return eris.Wrapf(err, "panic in %q", compName)
+	}
+
+	sentinel := ErrSetupPanic
+	switch stage {
+	case stageRender:
+		sentinel = ErrRenderPanic
+	case stageSplit, stageUnmarshal, stageCustomRender, stageValidate:
+		sentinel = ErrUnmarshalPanic
+	}
+	return eris.Wrapf(sentinel, "panic in %q: %v", compName, recovered)
+}
+
+// Wrap a template function, so that its panic is reported as `ErrRenderPanic`.
+//
+// NOTE: `text/template` recovers panics in functions and turns them into plain
+// errors. So we panic with an error, which `text/template` then wraps.
+func recoverTemplateFunc(name string, fn any) any {
+	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		return fn
+	}
+	fnType := fnVal.Type()
+
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		defer func() {
+			if r := recover(); r != nil {
+				panic(eris.Wrapf(ErrRenderPanic, "template function %q panicked: %s", name, fmt.Sprint(r)))
+			}
+		}()
+
+		if fnType.IsVariadic() {
+			return fnVal.CallSlice(args)
+		}
+		return fnVal.Call(args)
+	}).Interface()
+}
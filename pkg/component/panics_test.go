@@ -0,0 +1,165 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type panicContext struct {
+	Explode func(string) string
+}
+
+var panicDef = Def[corev1.ConfigMap, Input, panicContext]{
+	Name: "PanickingConfigMap",
+	Template: `
+	apiVersion: v1
+	kind: ConfigMap
+	metadata:
+	  name: {{ Explode "kuard" }}
+	`,
+	Options: Options[Input]{
+		TabSize: utils.PointerOf(2),
+	},
+}
+
+func TestComponentPanicInSetup(t *testing.T) {
+	assert := assert.New(t)
+
+	def := panicDef.Copy()
+	def.Setup = func(input Input) (panicContext, error) {
+		panic("setup exploded")
+	}
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrSetupPanic)
+	assert.NotErrorIs(err, ErrRenderPanic)
+	assert.Contains(err.Error(), "setup exploded")
+}
+
+func TestComponentPanicInTemplateFunc(t *testing.T) {
+	assert := assert.New(t)
+
+	def := panicDef.Copy()
+	def.Setup = func(input Input) (panicContext, error) {
+		return panicContext{
+			Explode: func(s string) string { panic("template func exploded") },
+		}, nil
+	}
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	_, content, err := comp.Render(Input{})
+	assert.ErrorIs(err, ErrRenderPanic)
+	assert.NotErrorIs(err, ErrSetupPanic)
+	assert.Contains(err.Error(), "template func exploded")
+	assert.Contains(err.Error(), `"Explode"`)
+	// Content rendered up to the panic is returned
+	assert.Contains(content, "kind: ConfigMap")
+}
+
+func TestComponentPanicInLibraryFunc(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Name:     "DividingConfigMap",
+			Template: `value: {{ div 1 0 }}`,
+		},
+	)
+	assert.Nil(err)
+
+	// Library functions are wrapped once per component, so each render reports the panic
+	for i := 0; i < 2; i++ {
+		_, _, err = comp.Render(Input{})
+		assert.ErrorIs(err, ErrRenderPanic)
+		assert.Contains(err.Error(), `"div"`)
+	}
+}
+
+func TestComponentPanicInRender(t *testing.T) {
+	assert := assert.New(t)
+
+	def := panicDef.Copy()
+	def.Setup = func(input Input) (panicContext, error) {
+		return panicContext{Explode: func(s string) string { return s }}, nil
+	}
+	def.Render = func(Input, panicContext, string) (corev1.ConfigMap, error) {
+		panic("render exploded")
+	}
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	_, content, err := comp.Render(Input{})
+	assert.ErrorIs(err, ErrUnmarshalPanic)
+	assert.Contains(err.Error(), "render exploded")
+	assert.Contains(content, "name: kuard")
+}
+
+func TestComponentMultiPanicInRender(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name:     "PanickingConfigMaps",
+			Template: "name: first\n---\nname: second",
+			GetInstances: func(Input, struct{}) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 2), nil
+			},
+			Render: func(Input, struct{}, []string) ([]corev1.ConfigMap, error) {
+				panic("render exploded")
+			},
+		},
+	)
+	assert.Nil(err)
+
+	_, contents, err := comp.Render(Input{})
+	assert.ErrorIs(err, ErrUnmarshalPanic)
+	assert.Len(contents, 2)
+	assert.Contains(contents[1], "name: second")
+}
+
+func TestComponentMultiPanicInSetup(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name:     "PanickingConfigMaps",
+			Template: `apiVersion: v1`,
+			Setup: func(input Input) (struct{}, error) {
+				panic("setup exploded")
+			},
+			GetInstances: func(Input, struct{}) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 1), nil
+			},
+		},
+	)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrSetupPanic)
+}
+
+func TestComponentPanicOnError(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: `apiVersion: v1`,
+			Setup: func(input Input) (struct{}, error) {
+				panic("setup exploded")
+			},
+			Options: Options[Input]{PanicOnError: true},
+		},
+	)
+	assert.Nil(err)
+
+	// With `PanicOnError`, panics are not recovered
+	assert.PanicsWithValue("setup exploded", func() {
+		comp.Render(Input{})
+	})
+}
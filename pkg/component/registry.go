@@ -0,0 +1,64 @@
+package component
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Components created with `Options.Register`, see `ValidateAll`
+var registry struct {
+	mu      sync.Mutex
+	entries []registryEntry
+}
+
+type registryEntry struct {
+	name string
+	// Render the component with its `Options.FrontloadInput`
+	validate func() error
+}
+
+func register(name string, validate func() error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.entries = append(registry.entries, registryEntry{name: name, validate: validate})
+}
+
+// Names of the components created with `Options.Register`, in the order they were created.
+func RegisteredComponents() []string {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	names := []string{}
+	for _, entry := range registry.entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+// Render each component created with `Options.Register` with its `Options.FrontloadInput`,
+// and return the errors of all that failed as `Errors`, labelled by the component
+// name. Use it in a test to check all components of a package, without listing them:
+//
+//	func TestComponents(t *testing.T) {
+//		if err := component.ValidateAll(); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+//
+// NOTE: Components register when they are created, so only components of
+// the imported packages are validated.
+func ValidateAll() error {
+	registry.mu.Lock()
+	entries := append([]registryEntry{}, registry.entries...)
+	registry.mu.Unlock()
+
+	var errs Errors
+	for index, entry := range entries {
+		label := entry.name
+		if label == "" {
+			label = fmt.Sprintf("unnamed component at index %d", index)
+		}
+		AppendError(&errs, label, entry.validate())
+	}
+	return errs.ErrorOrNil()
+}
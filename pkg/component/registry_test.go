@@ -0,0 +1,87 @@
+package component
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Restore the registry after the test, so the registered components don't leak
+func resetRegistry(t *testing.T) {
+	registry.mu.Lock()
+	entries := registry.entries
+	registry.entries = nil
+	registry.mu.Unlock()
+
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		registry.entries = entries
+		registry.mu.Unlock()
+	})
+}
+
+func TestValidateAll(t *testing.T) {
+	assert := assert.New(t)
+	resetRegistry(t)
+
+	_, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, Input]{
+			Name:     "RegisteredConfigMap",
+			Template: "metadata:\n  name: {{ .Helpa.Name }}",
+			Setup:    func(input Input) (Input, error) { return input, nil },
+			Options:  Options[Input]{Register: true, FrontloadInput: Input{Name: "kuard"}},
+		},
+	)
+	assert.Nil(err)
+
+	_, err = CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, Input]{
+			Name:     "RegisteredConfigMaps",
+			Template: "metadata:\n  name: {{ .Helpa.Name }}-a\n---\nmetadata:\n  name: {{ .Helpa.Name }}-b",
+			Setup:    func(input Input) (Input, error) { return input, nil },
+			GetInstances: func(Input, Input) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 2), nil
+			},
+			Options: Options[Input]{Register: true},
+		},
+	)
+	assert.Nil(err)
+
+	// Not registered
+	_, err = CreateComponent(
+		Def[corev1.ConfigMap, Input, Input]{
+			Name:     "UnregisteredConfigMap",
+			Template: "metadata:\n  name: {{ .Helpa.Name }}",
+		},
+	)
+	assert.Nil(err)
+
+	assert.Equal([]string{"RegisteredConfigMap", "RegisteredConfigMaps"}, RegisteredComponents())
+	assert.Nil(ValidateAll())
+}
+
+func TestValidateAllFails(t *testing.T) {
+	assert := assert.New(t)
+	resetRegistry(t)
+
+	for _, name := range []string{"BrokenConfigMap", "OtherBrokenConfigMap"} {
+		_, err := CreateComponent(
+			Def[corev1.ConfigMap, Input, Input]{
+				Name: name,
+				// Number is not a string
+				Template: "metadata:\n  name: {{ .Helpa.Number }}",
+				Setup:    func(input Input) (Input, error) { return input, nil },
+				Options:  Options[Input]{Register: true},
+			},
+		)
+		assert.Nil(err)
+	}
+
+	err := ValidateAll()
+	var errs *Errors
+	assert.ErrorAs(err, &errs)
+	assert.Len(errs.Entries, 2)
+	assert.Equal("BrokenConfigMap", errs.Entries[0].Label)
+	assert.Equal("OtherBrokenConfigMap", errs.Entries[1].Label)
+}
@@ -0,0 +1,50 @@
+package component
+
+import (
+	"text/template"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrFuncDisabled = eris.New("template function is disabled")
+)
+
+// Template functions disabled by `Options.Sandbox`, unless overridden with
+// `Options.DisabledFuncs`. These access the filesystem, environment, network
+// or run commands.
+//
+// NOTE: `tpl` is disabled too, as it renders with Helmfile's functions, incl. `exec`.
+var SandboxDisabledFuncs = []string{
+	// Helmfile
+	"exec",
+	"envExec",
+	"readFile",
+	"readDir",
+	"readDirEntries",
+	"isFile",
+	"requiredEnv",
+	"fetchSecretValue",
+	"expandSecretRefs",
+	"tpl",
+	// Sprig
+	"env",
+	"expandenv",
+	"getHostByName",
+}
+
+// Replace the functions with stubs that fail the render when called.
+//
+// NOTE: Stubs are used instead of removing the functions, so templates that
+// use them still parse, and the error tells what happened.
+func disableTemplateFuncs(funcMap template.FuncMap, names []string) {
+	for _, name := range names {
+		funcMap[name] = disabledFunc(name)
+	}
+}
+
+func disabledFunc(name string) func(...any) (any, error) {
+	return func(...any) (any, error) {
+		return nil, eris.Wrapf(ErrFuncDisabled, "function %q is disabled in sandbox mode", name)
+	}
+}
@@ -0,0 +1,60 @@
+package component
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Template functions are called in the value of `out`
+const sandboxTemplate = "apiVersion: v1\nkind: ConfigMap\ndata:\n  out: "
+
+func TestComponentSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](sandboxTemplate+`{{ exec "echo" (list "hello") | trim | quote }}`, nil, nil, Options[Input]{})
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("hello", instance.Data["out"])
+
+	comp, err = setupComponentInlineWithOptions[corev1.ConfigMap](sandboxTemplate+`{{ exec "ls" }}`, nil, nil, Options[Input]{Sandbox: true})
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrFuncDisabled)
+	assert.Contains(err.Error(), `function "exec" is disabled in sandbox mode`)
+
+	comp, err = setupComponentInlineWithOptions[corev1.ConfigMap](sandboxTemplate+`{{ env "HOME" | quote }}`, nil, nil, Options[Input]{Sandbox: true})
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrFuncDisabled)
+}
+
+func TestComponentSandboxDisabledFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	// Custom list replaces the default one
+	options := Options[Input]{Sandbox: true, DisabledFuncs: []string{"upper"}}
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](sandboxTemplate+`{{ env "HELPA_SANDBOX_TEST" | quote }}`, nil, nil, options)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.Nil(err)
+
+	comp, err = setupComponentInlineWithOptions[corev1.ConfigMap](sandboxTemplate+`{{ "kuard" | upper }}`, nil, nil, options)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrFuncDisabled)
+	assert.Contains(err.Error(), `function "upper" is disabled in sandbox mode`)
+}
+
+func TestComponentExpandEnvSandbox(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("HELPA_TEST_NAME", "kuard")
+
+	comp, err := setupComponentInlineWithOptions[corev1.ConfigMap](sandboxTemplate+`"${HELPA_TEST_NAME}"`, nil, nil, Options[Input]{ExpandEnv: true, Sandbox: true})
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("${HELPA_TEST_NAME}", instance.Data["out"])
+}
@@ -0,0 +1,88 @@
+package component
+
+import (
+	"encoding/base64"
+	"reflect"
+
+	eris "github.com/rotisserie/eris"
+	corev1 "k8s.io/api/core/v1"
+	yaml "sigs.k8s.io/yaml"
+
+	"github.com/jurooravec/helpa/pkg/serializers"
+)
+
+var (
+	ErrInvalidSecretData = eris.New("Secret data is not valid base64")
+)
+
+// Check that the values of `data` of the rendered Secret are valid base64,
+// so that raw strings are caught at render time, and not when applied.
+// Documents that are not Secrets are ignored.
+func checkSecretData(doc string) error {
+	var secret struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Data map[string]any `json:"data"`
+	}
+	// NOTE: Invalid documents are left for the unmarshalling to report
+	if err := yaml.Unmarshal([]byte(doc), &secret); err != nil {
+		return nil
+	}
+	if secret.APIVersion != "v1" || secret.Kind != "Secret" {
+		return nil
+	}
+
+	for key, val := range secret.Data {
+		str, ok := val.(string)
+		if !ok {
+			return eris.Wrapf(ErrInvalidSecretData, "value of key %q in Secret %q is not a string", key, secret.Metadata.Name)
+		}
+		if _, err := base64.StdEncoding.DecodeString(str); err != nil {
+			return eris.Wrapf(ErrInvalidSecretData, "value of key %q in Secret %q is not valid base64 (use `b64enc`, or `stringData` for raw values)", key, secret.Metadata.Name)
+		}
+	}
+	return nil
+}
+
+// Check `data` of the Secrets among the documents, see `checkSecretData`.
+// Returns the index of the invalid document.
+func checkSecretDocs[TInput any](content string, options Options[TInput]) (int, error) {
+	docs, err := SplitDocs(content, options)
+	if err != nil {
+		return -1, err
+	}
+	for index, doc := range docs {
+		if err := checkSecretData(doc); err != nil {
+			return index, err
+		}
+	}
+	return -1, nil
+}
+
+// Apply `serializers.NormalizeSecret` to the Secrets in the value, which may
+// be a Secret, a pointer or interface that holds it, or a slice of those.
+func normalizeSecretsIn(val reflect.Value) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		if secret, ok := val.Interface().(*corev1.Secret); ok {
+			serializers.NormalizeSecret(secret)
+			return
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() == reflect.Slice {
+		for i := 0; i < val.Len(); i++ {
+			elem := val.Index(i)
+			if elem.Kind() == reflect.Struct {
+				elem = elem.Addr()
+			}
+			normalizeSecretsIn(elem)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+var secretOptions = Options[Input]{
+	TabSize:          utils.PointerOf(2),
+	NormalizeSecrets: true,
+}
+
+func TestComponentNormalizeSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := setupComponentInlineWithOptions[corev1.Secret](`
+	apiVersion: v1
+	kind: Secret
+	metadata:
+	  name: db
+	data:
+	  user: YWRtaW4=
+	stringData:
+	  password: s3cr3t
+	`, nil, nil, secretOptions)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Nil(instance.StringData)
+	assert.Equal(map[string][]byte{"user": []byte("admin"), "password": []byte("s3cr3t")}, instance.Data)
+}
+
+func TestComponentNormalizeSecretsInvalidData(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := setupComponentInlineWithOptions[corev1.Secret](`
+	apiVersion: v1
+	kind: Secret
+	metadata:
+	  name: db
+	data:
+	  user: YWRtaW4=
+	  password: s3cr3t!
+	`, nil, nil, secretOptions)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidSecretData)
+	assert.Contains(err.Error(), `value of key "password" in Secret "db" is not valid base64`)
+
+	// Same in multi components, with the index of the document
+	compMulti, err := CreateComponentMulti(
+		DefMulti[runtime.Object, Input, struct{}]{
+			Name:     "Secrets",
+			Template: "apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nkind: Secret\ndata:\n  token: not base64",
+			GetInstances: func(Input, struct{}) ([]runtime.Object, error) {
+				return []runtime.Object{&corev1.ConfigMap{}, &corev1.Secret{}}, nil
+			},
+			Options: Options[Input]{NormalizeSecrets: true},
+		},
+	)
+	assert.Nil(err)
+
+	_, _, err = compMulti.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidSecretData)
+	assert.Contains(err.Error(), `for document at index 1`)
+	assert.Contains(err.Error(), `value of key "token"`)
+}
+
+func TestComponentB64encMap(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := setupComponentInlineWithOptions[corev1.Secret](`
+	apiVersion: v1
+	kind: Secret
+	metadata:
+	  name: db
+	data:
+	  {{- b64encMap (dict "user" "admin" "password" "s3cr3t") | toYaml | nindent 2 }}
+	`, nil, nil, secretOptions)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal(map[string][]byte{"user": []byte("admin"), "password": []byte("s3cr3t")}, instance.Data)
+}
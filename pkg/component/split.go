@@ -0,0 +1,16 @@
+package component
+
+import (
+	"strings"
+)
+
+// Split the rendered content into documents at `Options.MultiDocSeparator`,
+// the same way as `ComponentMulti` does. Use it in custom `Render` functions
+// or post-render hooks, so they split the content the same as the component.
+func SplitDocs[TInput any](content string, options Options[TInput]) ([]string, error) {
+	separator := options.MultiDocSeparator
+	if separator == "" {
+		separator = "---"
+	}
+	return strings.Split(content, separator), nil
+}
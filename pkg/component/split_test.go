@@ -0,0 +1,54 @@
+package component
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+var splitDocsCases = []struct {
+	name      string
+	content   string
+	separator string
+	expected  []string
+}{
+	{"single", "a: 1", "", []string{"a: 1"}},
+	{"multiple", "a: 1\n---\nb: 2", "", []string{"a: 1\n", "\nb: 2"}},
+	{"empty document", "a: 1\n---\n---\nb: 2", "", []string{"a: 1\n", "\n", "\nb: 2"}},
+	{"custom separator", "a: 1\n===\nb: 2", "===", []string{"a: 1\n", "\nb: 2"}},
+}
+
+// The cases are run against both `SplitDocs` and `ComponentMulti`, so the two don't diverge
+func TestSplitDocs(t *testing.T) {
+	for _, tc := range splitDocsCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			options := Options[Input]{
+				MultiDocSeparator: tc.separator,
+				PreprocessTemplate: func(tmpl string, options Options[Input]) (string, error) {
+					return tmpl, nil
+				},
+			}
+
+			docs, err := SplitDocs(tc.content, options)
+			assert.Nil(err)
+			assert.Equal(tc.expected, docs)
+
+			comp, err := CreateComponentMulti(
+				DefMulti[map[string]any, Input, struct{}]{
+					Template: tc.content,
+					GetInstances: func(Input, struct{}) ([]map[string]any, error) {
+						return make([]map[string]any, len(tc.expected)), nil
+					},
+					Options: options,
+				},
+			)
+			assert.Nil(err)
+
+			_, contents, err := comp.Render(Input{})
+			assert.Nil(err)
+			assert.Equal(docs, contents)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package component
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrStructTagMissing   = eris.New("field is missing a json tag")
+	ErrStructTagDuplicate = eris.New("json tag is used by multiple fields")
+)
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Statically check the struct tags of `TType`, without rendering anything.
+//
+// Rendered templates are unmarshalled into `TType` via JSON, so the `json` tags
+// decide which keys the template may use. This reports struct definition
+// mistakes, namely:
+//   - Exported fields without a `json` tag (`ErrStructTagMissing`).
+//   - Multiple fields that map to the same JSON key (`ErrStructTagDuplicate`).
+//
+// Nested structs are checked too. Types that implement their own JSON
+// unmarshalling (e.g. `resource.Quantity`) are skipped.
+//
+// All issues found are returned as `Errors`. Returns `nil` if there are none.
+func CheckStructTags[TType any]() error {
+	return checkStructTags(reflect.TypeOf((*TType)(nil)).Elem())
+}
+
+func checkStructTags(t reflect.Type) error {
+	checker := structTagChecker{visited: map[reflect.Type]bool{}}
+	checker.checkType(t, "")
+	return checker.issues.ErrorOrNil()
+}
+
+type structTagChecker struct {
+	visited map[reflect.Type]bool
+	issues  Errors
+}
+
+// Check the struct type and its nested structs. `path` is the path of the field
+// that holds the type, or empty for the root.
+func (c *structTagChecker) checkType(t reflect.Type, path string) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || c.visited[t] || hasCustomJSON(t) {
+		return
+	}
+	c.visited[t] = true
+	if path == "" {
+		path = typeName(t)
+	}
+
+	// Map of JSON keys to the fields that use them
+	keys := map[string][]string{}
+	keyOrder := []string{}
+	c.collectKeys(t, path, keys, &keyOrder)
+
+	for _, key := range keyOrder {
+		fields := keys[key]
+		if len(fields) > 1 {
+			AppendError(&c.issues, "", eris.Wrapf(ErrStructTagDuplicate, "key %q in %s is used by fields %s", key, path, strings.Join(fields, ", ")))
+		}
+	}
+}
+
+// Gather the JSON keys of the struct's fields. Fields of embedded structs
+// without a JSON name are inlined, same as `encoding/json` does.
+func (c *structTagChecker) collectKeys(t reflect.Type, path string, keys map[string][]string, keyOrder *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := path + "." + field.Name
+
+		tag, hasTag := field.Tag.Lookup("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" && tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !hasCustomJSON(embedded) {
+				c.collectKeys(embedded, fieldPath, keys, keyOrder)
+				continue
+			}
+		}
+
+		// NOTE: Unexported fields are ignored by `encoding/json`.
+		if !field.IsExported() {
+			continue
+		}
+
+		if !hasTag {
+			AppendError(&c.issues, "", eris.Wrapf(ErrStructTagMissing, "field %s", fieldPath))
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := keys[name]; !ok {
+			*keyOrder = append(*keyOrder, name)
+		}
+		keys[name] = append(keys[name], fieldPath)
+
+		c.checkType(field.Type, fieldPath)
+	}
+}
+
+func hasCustomJSON(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return t.Implements(jsonMarshalerType) ||
+		ptr.Implements(jsonUnmarshalerType) ||
+		ptr.Implements(textUnmarshalerType)
+}
+
+func typeName(t reflect.Type) string {
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.Name()
+}
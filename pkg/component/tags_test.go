@@ -0,0 +1,64 @@
+package component
+
+import (
+	"reflect"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type taggedBase struct {
+	Kind string `json:"kind"`
+}
+
+type taggedStruct struct {
+	taggedBase `json:",inline"`
+
+	Name    string            `json:"name"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Skipped string            `json:"-"`
+	private string
+}
+
+type untaggedStruct struct {
+	Name   string `json:"name"`
+	Nested struct {
+		Value string
+	} `json:"nested"`
+}
+
+func TestCheckStructTags(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(CheckStructTags[taggedStruct]())
+	assert.Nil(CheckStructTags[*taggedStruct]())
+	assert.Nil(CheckStructTags[corev1.ConfigMap]())
+	assert.Nil(CheckStructTags[appsv1.Deployment]())
+}
+
+func TestCheckStructTagsDuplicate(t *testing.T) {
+	assert := assert.New(t)
+
+	// NOTE: `go vet` refuses duplicate json tags in struct literals, so we have
+	// to build the struct dynamically.
+	duplicateTagStruct := reflect.StructOf([]reflect.StructField{
+		{Name: "Name", Type: reflect.TypeOf(""), Tag: `json:"name"`},
+		{Name: "FullName", Type: reflect.TypeOf(""), Tag: `json:"name,omitempty"`},
+	})
+	err := checkStructTags(reflect.SliceOf(duplicateTagStruct))
+	assert.ErrorIs(err, ErrStructTagDuplicate)
+	assert.NotErrorIs(err, ErrStructTagMissing)
+	assert.Contains(err.Error(), `key "name"`)
+	assert.Contains(err.Error(), ".FullName")
+}
+
+func TestCheckStructTagsMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	err := CheckStructTags[untaggedStruct]()
+	assert.ErrorIs(err, ErrStructTagMissing)
+	assert.NotErrorIs(err, ErrStructTagDuplicate)
+	assert.Contains(err.Error(), "field untaggedStruct.Nested.Value")
+}
@@ -0,0 +1,83 @@
+package component
+
+import (
+	"context"
+	"time"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var ErrTimeout = eris.New("component did not finish rendering within `Options.Timeout`")
+
+// Deadline of a single render, see `Options.Timeout`
+type renderDeadline struct {
+	// Cancelled when the timeout expires. Passed to `SetupCtx`.
+	ctx     context.Context
+	start   time.Time
+	timeout time.Duration
+}
+
+// NOTE: Call the returned function when the render is done, to release the timer.
+func newRenderDeadline(timeout time.Duration) (renderDeadline, context.CancelFunc) {
+	if timeout <= 0 {
+		return renderDeadline{ctx: context.Background(), start: time.Now()}, func() {}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return renderDeadline{ctx: ctx, start: time.Now(), timeout: timeout}, cancel
+}
+
+// Fail with `ErrTimeout` if the deadline has passed. Used between the phases
+// that are not run with `runWithDeadline`.
+func (d renderDeadline) check(compName string, stage renderStage) error {
+	if d.ctx.Err() == nil {
+		return nil
+	}
+	elapsed := time.Since(d.start).Round(time.Millisecond)
+	return eris.Wrapf(ErrTimeout, "%q timed out in %s after %s (timeout is %s)", compName, stage, elapsed, d.timeout)
+}
+
+// Run the phase of the render, and fail with `ErrTimeout` if the render
+// doesn't finish it before the deadline. Zero timeout means no timeout.
+//
+// Panics are passed on to the caller, so they are reported as usual.
+//
+// NOTE: Only `SetupCtx` can be told to stop. `Setup`, template execution and
+// custom `Render` cannot be cancelled, so on timeout they are left running in
+// the background, and their results are discarded. Hence `fn` must not write
+// to any state of the render, and should return all it produces instead.
+func runWithDeadline[T any](deadline renderDeadline, compName string, stage renderStage, fn func() (T, error)) (T, error) {
+	if deadline.timeout <= 0 {
+		return fn()
+	}
+	if err := deadline.check(compName, stage); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	type result struct {
+		val       T
+		err       error
+		recovered any
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{recovered: r}
+			}
+		}()
+		val, err := fn()
+		done <- result{val: val, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.recovered != nil {
+			panic(res.recovered)
+		}
+		return res.val, res.err
+	case <-deadline.ctx.Done():
+		var zero T
+		return zero, deadline.check(compName, stage)
+	}
+}
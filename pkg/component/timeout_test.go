@@ -0,0 +1,140 @@
+package component
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestComponentTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Name:     "Slow",
+		Template: "kind: ConfigMap\n",
+		Setup: func(Input) (struct{}, error) {
+			time.Sleep(200 * time.Millisecond)
+			return struct{}{}, nil
+		},
+		Options: Options[Input]{Timeout: 50 * time.Millisecond},
+	}
+
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	start := time.Now()
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrTimeout)
+	assert.Contains(err.Error(), `"Slow" timed out in setup after`)
+	assert.Less(time.Since(start), 200*time.Millisecond)
+
+	// Custom `Render` too
+	def.Setup = func(Input) (struct{}, error) { return struct{}{}, nil }
+	def.Render = func(Input, struct{}, string) (corev1.ConfigMap, error) {
+		time.Sleep(200 * time.Millisecond)
+		return corev1.ConfigMap{}, nil
+	}
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrTimeout)
+	assert.Contains(err.Error(), "timed out in custom-render")
+
+	// Zero means no timeout
+	def.Options.Timeout = 0
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.Nil(err)
+}
+
+func TestComponentMultiTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name:     "Slow",
+			Template: "kind: ConfigMap\n",
+			Setup: func(Input) (struct{}, error) {
+				time.Sleep(200 * time.Millisecond)
+				return struct{}{}, nil
+			},
+			Options: Options[Input]{Timeout: 50 * time.Millisecond},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrTimeout)
+	assert.Contains(err.Error(), "timed out in setup")
+}
+
+func TestComponentTimeoutPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\n",
+			Setup:    func(Input) (struct{}, error) { panic("boom") },
+			Options:  Options[Input]{Timeout: time.Second},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrSetupPanic)
+}
+
+func TestComponentTimeoutSetupCtx(t *testing.T) {
+	assert := assert.New(t)
+
+	cancelled := make(chan error, 1)
+	comp, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Name:     "Slow",
+			Template: "kind: ConfigMap\n",
+			SetupCtx: func(ctx context.Context, input Input) (struct{}, error) {
+				select {
+				case <-ctx.Done():
+					cancelled <- ctx.Err()
+				case <-time.After(time.Second):
+					cancelled <- nil
+				}
+				return struct{}{}, ctx.Err()
+			},
+			Options: Options[Input]{Timeout: 50 * time.Millisecond},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrTimeout)
+	assert.Contains(err.Error(), "timed out in setup")
+	// Setup was told to stop
+	assert.ErrorIs(<-cancelled, context.DeadlineExceeded)
+
+	// Without timeout, the context is never cancelled
+	single, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\n",
+			SetupCtx: func(ctx context.Context, input Input) (struct{}, error) {
+				return struct{}{}, ctx.Err()
+			},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = single.Render(Input{})
+	assert.Nil(err)
+}
+
+func TestComponentSetupConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\n",
+			Setup:    func(Input) (struct{}, error) { return struct{}{}, nil },
+			SetupCtx: func(context.Context, Input) (struct{}, error) { return struct{}{}, nil },
+		},
+	)
+	assert.ErrorIs(err, ErrSetupConflict)
+}
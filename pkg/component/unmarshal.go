@@ -0,0 +1,102 @@
+package component
+
+import (
+	"reflect"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	scheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var (
+	ErrNotK8sObject = eris.New("container cannot hold a K8s object")
+)
+
+// Signature of `Options.Unmarshal`
+type UnmarshalFunc[TInput any] func(rendered string, container any, options Options[TInput]) error
+
+// Get the unmarshal function for the document, see `Options.UnmarshalFor`
+func unmarshalFor[TInput any](options Options[TInput], doc string, index int) UnmarshalFunc[TInput] {
+	if options.UnmarshalFor != nil {
+		if unmarshal := options.UnmarshalFor(doc, index); unmarshal != nil {
+			return unmarshal
+		}
+	}
+	return options.Unmarshal
+}
+
+// Use as `Options.UnmarshalFor` to decode documents that start with `{` or `[`
+// as JSON. Other documents are decoded with `Options.Unmarshal`, e.g.:
+//
+//	Options: component.Options[Input]{
+//		UnmarshalFor: component.SniffFormat[Input],
+//	}
+func SniffFormat[TInput any](doc string, index int) UnmarshalFunc[TInput] {
+	trimmed := strings.TrimSpace(doc)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return JSONUnmarshal[TInput]
+	}
+	return nil
+}
+
+// Unmarshal the rendered JSON. Same as the default YAML unmarshalling,
+// unknown fields are not allowed, unless `Options.AllowUnknownFields` is set.
+func JSONUnmarshal[TInput any](rendered string, container any, options Options[TInput]) error {
+	err := decodeJSON([]byte(rendered), container, options)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal document as JSON")
+	}
+	return nil
+}
+
+// Unmarshal K8s objects the same way as `kubectl` does, using the types registered
+// in the client-go scheme, e.g.:
+//
+//	Options: component.Options[Input]{
+//		Unmarshal: component.K8sUnmarshal[Input],
+//	}
+//
+// Compared to the default unmarshalling, this:
+//   - Creates the object from its `apiVersion` and `kind`, so the instance may be
+//     a nil `runtime.Object`, e.g. with `Def[runtime.Object, ...]`.
+//   - Tolerates unknown fields, e.g. from newer API versions.
+//
+// NOTE: Only the types registered in `k8s.io/client-go/kubernetes/scheme.Scheme`
+// can be decoded. Register your CRDs with `AddToScheme` to decode them too.
+func K8sUnmarshal[TInput any](rendered string, container any, options Options[TInput]) error {
+	containerVal := reflect.ValueOf(container)
+	if containerVal.Kind() != reflect.Ptr || containerVal.IsNil() {
+		return eris.Wrapf(ErrNotK8sObject, "expected pointer, got %T", container)
+	}
+
+	// Decode into the object if given one, e.g. `*corev1.ConfigMap`, or
+	// `*runtime.Object` holding `&corev1.ConfigMap{}`
+	var into runtime.Object
+	if obj, ok := container.(runtime.Object); ok {
+		into = obj
+	} else if obj, ok := containerVal.Elem().Interface().(runtime.Object); ok && !isNilObject(obj) {
+		into = obj
+	}
+
+	decoded, _, err := scheme.Codecs.UniversalDeserializer().Decode([]byte(rendered), nil, into)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal document as K8s object")
+	}
+	if into != nil {
+		return nil
+	}
+
+	// Otherwise the object was created from its kind, so we set it to the container
+	decodedVal := reflect.ValueOf(decoded)
+	if !decodedVal.Type().AssignableTo(containerVal.Elem().Type()) {
+		return eris.Wrapf(ErrNotK8sObject, "decoded %T cannot be assigned to %T", decoded, container)
+	}
+	containerVal.Elem().Set(decodedVal)
+	return nil
+}
+
+func isNilObject(obj runtime.Object) bool {
+	val := reflect.ValueOf(obj)
+	return val.Kind() == reflect.Ptr && val.IsNil()
+}
@@ -0,0 +1,131 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	k8s "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+type dashboardSpec struct {
+	Title  string   `json:"title"`
+	Panels []string `json:"panels"`
+}
+
+func TestComponentMultiUnmarshalFor(t *testing.T) {
+	assert := assert.New(t)
+
+	makeInstances := func(Input, Context) ([]any, error) {
+		return []any{&corev1.ConfigMap{}, &dashboardSpec{}}, nil
+	}
+	options := Options[Input]{
+		TabSize:      utils.PointerOf(2),
+		UnmarshalFor: SniffFormat[Input],
+	}
+
+	comp, err := setupComponentMultiInlineWithOptions(`
+	apiVersion: v1
+	kind: ConfigMap
+	metadata:
+	  name: dashboards
+	---
+	{"title": "Kuard", "panels": ["cpu", "memory"]}
+	`, makeInstances, nil, nil, options)
+	assert.Nil(err)
+
+	instances, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("dashboards", instances[0].(*corev1.ConfigMap).Name)
+	assert.Equal(&dashboardSpec{Title: "Kuard", Panels: []string{"cpu", "memory"}}, instances[1])
+
+	// Errors tell the document and the format
+	comp, err = setupComponentMultiInlineWithOptions(`
+	kind: ConfigMap
+	---
+	{"title": "Kuard", "unknown": true}
+	`, makeInstances, nil, nil, options)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "document at index 1")
+	assert.Contains(err.Error(), "as JSON")
+}
+
+func TestSniffFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NotNil(SniffFormat[Input](`  {"kind": "ConfigMap"}`, 0))
+	assert.NotNil(SniffFormat[Input]("\n[1, 2]", 0))
+	assert.Nil(SniffFormat[Input]("kind: ConfigMap", 0))
+
+	// Index passed to the hook is the position in the template
+	indices := []int{}
+	comp, err := CreateComponentMulti(
+		DefMulti[runtime.Object, Input, struct{}]{
+			Template: "kind: Service\n---\nkind: ConfigMap",
+			GetInstances: func(Input, struct{}) ([]runtime.Object, error) {
+				return []runtime.Object{nil, &corev1.ConfigMap{}}, nil
+			},
+			Options: Options[Input]{
+				UnmarshalFor: func(doc string, index int) UnmarshalFunc[Input] {
+					indices = append(indices, index)
+					return nil
+				},
+			},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal([]int{1}, indices)
+}
+
+func TestComponentK8sUnmarshal(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[runtime.Object, Input, struct{}]{
+		Template: `
+		apiVersion: apps/v1
+		kind: Deployment
+		metadata:
+		  name: kuard
+		spec:
+		  replicas: 2
+		  # Field from a newer API version
+		  unknownField: true
+		`,
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+
+	// Default path cannot decode into a nil interface, nor tolerate unknown fields
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+
+	def.Options.Unmarshal = K8sUnmarshal[Input]
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	deployment, ok := instance.(*k8s.Deployment)
+	assert.True(ok)
+	assert.Equal("kuard", deployment.Name)
+	assert.Equal(int32(2), *deployment.Spec.Replicas)
+
+	// Given instances are decoded into
+	compTyped, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{
+			Template: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: kuard\nextra: field",
+			Options:  Options[Input]{Unmarshal: K8sUnmarshal[Input]},
+		},
+	)
+	assert.Nil(err)
+	configMap, _, err := compTyped.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard", configMap.Name)
+}
@@ -0,0 +1,43 @@
+package component
+
+import (
+	"fmt"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// Render the component with each of the inputs (or the zero input, if none given),
+// and return the errors of all failed renders as `Errors`.
+// It's the same check as frontloading (see `Options.FrontloadEnabled`), but
+// it can be run on demand, e.g. in a CI step that validates all components.
+//
+// NOTE: With `Options.PanicOnError`, the panics are recovered and reported
+// as errors too.
+func validateInputs[TInput any](compName string, render func(input TInput) error, inputs []TInput) error {
+	if len(inputs) == 0 {
+		var zero TInput
+		inputs = []TInput{zero}
+	}
+
+	var errs Errors
+	for index, input := range inputs {
+		err := validateInput(render, input)
+		if err != nil {
+			AppendError(&errs, fmt.Sprintf("input at index %d", index), eris.Wrapf(err, "validation failed in %q", compName))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func validateInput[TInput any](render func(input TInput) error, input TInput) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rErr, ok := r.(error); ok {
+				err = rErr
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	return render(input)
+}
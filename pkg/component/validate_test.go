@@ -0,0 +1,147 @@
+package component
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestComponentMultiValidateInputs(t *testing.T) {
+	assert := assert.New(t)
+
+	def := DefMulti[corev1.ConfigMap, Input, Input]{
+		Name: "ConfigMaps",
+		Template: `
+		kind: ConfigMap
+		metadata:
+		  name: "{{ .Helpa.Name }}"
+		---
+		kind: ConfigMap
+		metadata:
+		  name: "{{ .Helpa.Name }}-{{ .Helpa.Number }}"
+		`,
+		Setup: func(input Input) (Input, error) {
+			return input, nil
+		},
+		GetInstances: func(Input, Input) ([]corev1.ConfigMap, error) {
+			return make([]corev1.ConfigMap, 2), nil
+		},
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+
+	comp, err := CreateComponentMulti(def)
+	assert.Nil(err)
+
+	assert.Nil(comp.Validate())
+	assert.Nil(comp.Validate(Input{}, Input{Name: "kuard", Number: 2}))
+
+	// Only the inputs that fail are reported
+	def.Options.ValidateInput = func(input Input) error {
+		if input.Number > 1 {
+			return errors.New("only one replica is allowed")
+		}
+		return nil
+	}
+	comp, err = CreateComponentMulti(def)
+	assert.Nil(err)
+
+	err = comp.Validate(Input{Name: "kuard"}, Input{Name: "kuard", Number: 2}, Input{Number: 3})
+	assert.NotNil(err)
+	assert.NotContains(err.Error(), "input at index 0")
+	assert.Contains(err.Error(), "input at index 1: validation failed in \"ConfigMaps\"")
+	assert.Contains(err.Error(), "input at index 2: validation failed in \"ConfigMaps\"")
+	assert.Contains(err.Error(), "only one replica is allowed")
+	assert.Len(err.(interface{ Unwrap() []error }).Unwrap(), 2)
+}
+
+func TestComponentValidateInputs(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[corev1.ConfigMap, Input, Input]{
+		Template: `
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: {{ required "name is required" .Helpa.Name }}
+		`,
+		Setup: func(input Input) (Input, error) {
+			return input, nil
+		},
+		Options: Options[Input]{TabSize: utils.PointerOf(2)},
+	}
+
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	assert.Nil(comp.Validate(Input{Name: "kuard"}))
+	err = comp.Validate()
+	assert.ErrorContains(err, "name is required")
+
+	// Panics are reported as errors
+	def.Options.PanicOnError = true
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+
+	err = comp.Validate(Input{Name: "kuard"}, Input{})
+	assert.ErrorContains(err, "input at index 1: validation failed")
+	assert.ErrorContains(err, "name is required")
+	assert.False(errors.Is(err, ErrSetupPanic))
+}
+
+type certInput struct {
+	Domain string
+}
+
+func TestComponentValidateInput(t *testing.T) {
+	assert := assert.New(t)
+
+	setupCalled := false
+	validateInput := func(input certInput) error {
+		if input.Domain == "" {
+			return errors.New("domain must not be empty")
+		}
+		return nil
+	}
+	setup := func(input certInput) (certInput, error) {
+		setupCalled = true
+		return input, nil
+	}
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, certInput, certInput]{
+			Name:     "Certbot",
+			Template: "kind: ConfigMap\ndata:\n  domain: {{ .Helpa.Domain }}\n",
+			Setup:    setup,
+			Options:  Options[certInput]{ValidateInput: validateInput},
+		},
+	)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(certInput{})
+	assert.ErrorIs(err, ErrInvalidInput)
+	assert.Contains(err.Error(), "domain must not be empty")
+	assert.False(setupCalled)
+
+	instance, _, err := comp.Render(certInput{Domain: "example.com"})
+	assert.Nil(err)
+	assert.Equal("example.com", instance.Data["domain"])
+	assert.True(setupCalled)
+
+	compMulti, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, certInput, certInput]{
+			Name:     "Certbot",
+			Template: "kind: ConfigMap\n",
+			InstanceFor: func(int) (corev1.ConfigMap, error) {
+				return corev1.ConfigMap{}, nil
+			},
+			Options: Options[certInput]{ValidateInput: validateInput},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = compMulti.Render(certInput{})
+	assert.ErrorIs(err, ErrInvalidInput)
+	assert.Contains(err.Error(), `setup failed in "Certbot"`)
+}
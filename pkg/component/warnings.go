@@ -0,0 +1,83 @@
+package component
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+)
+
+var (
+	ErrDeprecatedInput = eris.New("input uses deprecated fields")
+)
+
+// Codes of the warnings, see `Warning.Code`
+const (
+	// Input field marked with `helpa:"deprecated=..."` is set
+	WarningDeprecatedField = "DeprecatedField"
+	// Rendered document has a field that the instance doesn't, and it was ignored,
+	// see `Options.AllowUnknownFields`
+	WarningUnknownField = "UnknownField"
+	// Input cannot be marshalled to JSON, so it was rendered without the cache,
+	// see `Options.MemoizeRenders`
+	WarningNotMemoizable = "NotMemoizable"
+)
+
+// Issue found while rendering a component that doesn't fail the render.
+// See `Options.OnWarning`.
+type Warning struct {
+	// Name of the component
+	Component string
+	// Kind of the warning, e.g. `WarningDeprecatedField`
+	Code    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s in %q: %s", w.Code, w.Component, w.Message)
+}
+
+// Report the warning to `Options.OnWarning`, or log it if not set.
+func emitWarning[TInput any](options Options[TInput], warning Warning) {
+	if options.OnWarning != nil {
+		options.OnWarning(warning)
+		return
+	}
+	log.Printf("helpa: warning: %s", warning)
+}
+
+// Warn about the deprecated input fields that are set, or fail with
+// `Options.DeprecationsAsErrors`.
+func checkDeprecatedInput[TInput any](compName string, input TInput, options Options[TInput]) error {
+	for _, field := range utils.FindDeprecatedFields(input) {
+		message := fmt.Sprintf("field %q is deprecated: %s", field.Path, field.Message)
+		if options.DeprecationsAsErrors {
+			return eris.Wrap(ErrDeprecatedInput, message)
+		}
+		emitWarning(options, Warning{Component: compName, Code: WarningDeprecatedField, Message: message})
+	}
+	return nil
+}
+
+// Decode JSON into the container. Unknown fields fail the decoding, unless
+// `Options.AllowUnknownFields` is set, in which case they emit a warning.
+func decodeJSON[TInput any](data []byte, container any, options Options[TInput]) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(container)
+	// NOTE: `encoding/json` has no error type for unknown fields
+	if err == nil || !options.AllowUnknownFields || !strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return err
+	}
+
+	emitWarning(options, Warning{
+		Code:    WarningUnknownField,
+		Message: strings.TrimPrefix(err.Error(), "json: "),
+	})
+	return json.Unmarshal(data, container)
+}
@@ -0,0 +1,104 @@
+package component
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type deprecatedInput struct {
+	Name     string
+	OldName  string `helpa:"deprecated=use Name instead"`
+	Replicas int
+}
+
+var deprecatedDef = Def[corev1.ConfigMap, deprecatedInput, struct{}]{
+	Name:     "DeprecatedConfigMap",
+	Template: "apiVersion: v1\nkind: ConfigMap",
+	Defaults: func() deprecatedInput { return deprecatedInput{Replicas: 1} },
+}
+
+func TestComponentDeprecatedInputWarning(t *testing.T) {
+	assert := assert.New(t)
+
+	warnings := []Warning{}
+	def := deprecatedDef.Copy()
+	def.Options.OnWarning = func(w Warning) { warnings = append(warnings, w) }
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	// Zero-valued deprecated fields stay silent
+	_, _, err = comp.Render(deprecatedInput{Name: "kuard"})
+	assert.Nil(err)
+	assert.Empty(warnings)
+
+	_, _, err = comp.Render(deprecatedInput{OldName: "kuard"})
+	assert.Nil(err)
+	assert.Equal([]Warning{{
+		Component: "DeprecatedConfigMap",
+		Code:      WarningDeprecatedField,
+		Message:   `field "OldName" is deprecated: use Name instead`,
+	}}, warnings)
+}
+
+func TestComponentDeprecationsAsErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	def := deprecatedDef.Copy()
+	def.Options.DeprecationsAsErrors = true
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(deprecatedInput{Name: "kuard"})
+	assert.Nil(err)
+
+	_, _, err = comp.Render(deprecatedInput{OldName: "kuard"})
+	assert.ErrorIs(err, ErrDeprecatedInput)
+	assert.Contains(err.Error(), `field "OldName" is deprecated: use Name instead`)
+
+	compMulti, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, deprecatedInput, struct{}]{
+			Template: "apiVersion: v1\nkind: ConfigMap",
+			GetInstances: func(deprecatedInput, struct{}) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 1), nil
+			},
+			Options: Options[deprecatedInput]{DeprecationsAsErrors: true},
+		},
+	)
+	assert.Nil(err)
+
+	_, _, err = compMulti.Render(deprecatedInput{OldName: "kuard"})
+	assert.ErrorIs(err, ErrDeprecatedInput)
+}
+
+func TestComponentAllowUnknownFields(t *testing.T) {
+	assert := assert.New(t)
+
+	warnings := []Warning{}
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Name:     "ConfigMap",
+		Template: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: kuard\nfoo: bar",
+		Options: Options[Input]{
+			OnWarning: func(w Warning) { warnings = append(warnings, w) },
+		},
+	}
+
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorContains(err, `unknown field "foo"`)
+	assert.Empty(warnings)
+
+	def.Options.AllowUnknownFields = true
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("kuard", instance.Name)
+	assert.Equal([]Warning{{
+		Component: "ConfigMap",
+		Code:      WarningUnknownField,
+		Message:   `unknown field "foo"`,
+	}}, warnings)
+}
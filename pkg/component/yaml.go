@@ -0,0 +1,72 @@
+package component
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	yamlv3 "gopkg.in/yaml.v3"
+	yaml "sigs.k8s.io/yaml"
+)
+
+var (
+	ErrInvalidYaml = eris.New("rendered template is not valid YAML")
+)
+
+var yamlLineRegex = regexp.MustCompile(`yaml: line (\d+): (.*)`)
+
+// Check that the content is syntactically valid YAML, without unmarshalling
+// it to any specific type. See `Options.ValidateYaml`.
+func checkYamlSyntax(content string) error {
+	dec := yamlv3.NewDecoder(strings.NewReader(content))
+	for {
+		var out any
+		err := dec.Decode(&out)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return yamlSyntaxError(err, content)
+		}
+	}
+}
+
+// Report YAML syntax error as `ErrInvalidYaml`, with the line where it occurred
+func yamlSyntaxError(err error, content string) error {
+	match := yamlLineRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return eris.Wrapf(ErrInvalidYaml, "invalid YAML: %v", err)
+	}
+
+	// NOTE: YAML reports tabs as e.g. "found character that cannot start any token",
+	// so say it's the tab if the line the parser points to is indented with one
+	line, _ := strconv.Atoi(match[1])
+	if text, ok := indentTabAt(content, line); ok {
+		return eris.Wrapf(ErrInvalidYaml, "invalid YAML at line %s, indentation contains a tab, which YAML doesn't allow: %q: %s", match[1], text, match[2])
+	}
+	return eris.Wrapf(ErrInvalidYaml, "invalid YAML at line %s: %s", match[1], match[2])
+}
+
+// Get the line if its indentation contains a tab. Line is 1-based.
+func indentTabAt(content string, line int) (text string, ok bool) {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	text = lines[line-1]
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	return text, strings.Contains(indent, "\t")
+}
+
+// Marshal the instance to YAML, with the JSON field names, so K8s objects
+// use their API field names. See `Options.ContentFromInstance`.
+func instanceToYaml(instance any) (string, error) {
+	data, err := yaml.Marshal(instance)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to marshal instance to YAML")
+	}
+	return string(data), nil
+}
@@ -0,0 +1,138 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/jurooravec/helpa/pkg/utils"
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NOTE: `name` is indented deeper than `namespace`
+const malformedYaml = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n    name: kuard\n  namespace: default\n"
+
+func TestComponentInvalidYaml(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{Template: malformedYaml},
+	)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidYaml)
+	assert.Contains(err.Error(), "invalid YAML at line 4: did not find expected key")
+
+	// Mismatched types are not syntax errors
+	comp, err = CreateComponent(
+		Def[corev1.ConfigMap, Input, struct{}]{Template: "apiVersion: v1\nkind: ConfigMap\nunknown: field\n"},
+	)
+	assert.Nil(err)
+
+	_, _, err = comp.Render(Input{})
+	assert.NotNil(err)
+	assert.NotErrorIs(err, ErrInvalidYaml)
+}
+
+func TestComponentValidateYaml(t *testing.T) {
+	assert := assert.New(t)
+
+	renderCalled := false
+	def := Def[corev1.ConfigMap, Input, struct{}]{
+		Template: malformedYaml,
+		Render: func(Input, struct{}, string) (corev1.ConfigMap, error) {
+			renderCalled = true
+			return corev1.ConfigMap{}, nil
+		},
+	}
+
+	// Without the check, custom `Render` receives the content as is
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.Nil(err)
+	assert.True(renderCalled)
+
+	renderCalled = false
+	def.Options.ValidateYaml = true
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidYaml)
+	assert.Contains(err.Error(), "invalid YAML at line 4: did not find expected key")
+	assert.False(renderCalled)
+
+	compMulti, err := CreateComponentMulti(
+		DefMulti[corev1.ConfigMap, Input, struct{}]{
+			Template: "kind: ConfigMap\n---\n" + malformedYaml,
+			GetInstances: func(Input, struct{}) ([]corev1.ConfigMap, error) {
+				return make([]corev1.ConfigMap, 2), nil
+			},
+			Render: func(Input, struct{}, []string) ([]corev1.ConfigMap, error) {
+				return nil, nil
+			},
+			Options: Options[Input]{ValidateYaml: true},
+		},
+	)
+	assert.Nil(err)
+	_, _, err = compMulti.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidYaml)
+	assert.Contains(err.Error(), "document at index 1")
+}
+
+type tabContext struct {
+	Makefile   string
+	TabbedData func() string
+}
+
+func TestComponentTabsInOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	def := Def[corev1.ConfigMap, Input, tabContext]{
+		Template: "kind: ConfigMap\ndata:\n  Makefile: {{ multiline 4 .Helpa.Makefile }}\n{{ TabbedData }}\n",
+		Setup: func(Input) (tabContext, error) {
+			return tabContext{
+				// NOTE: Tabs in a block scalar are content, so they are allowed
+				Makefile: "all:\n\techo hi\n",
+				// NOTE: Tab comes from the function, not from the template
+				TabbedData: func() string { return "\tapp: kuard\n\tnote: \"a\tb\"" },
+			}, nil
+		},
+	}
+
+	comp, err := CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidYaml)
+	assert.Contains(err.Error(), "invalid YAML at line 6, indentation contains a tab")
+	assert.Contains(err.Error(), `"\tapp: kuard"`)
+
+	// `TabSize` replaces only the tabs in the template
+	def.Options.TabSize = utils.PointerOf(2)
+	comp, err = CreateComponent(def)
+	assert.Nil(err)
+	_, _, err = comp.Render(Input{})
+	assert.ErrorIs(err, ErrInvalidYaml)
+	assert.Contains(err.Error(), "invalid YAML at line 6, indentation contains a tab")
+}
+
+func TestComponentTabsInBlockScalar(t *testing.T) {
+	assert := assert.New(t)
+
+	comp, err := CreateComponent(
+		Def[corev1.ConfigMap, Input, tabContext]{
+			Template: "kind: ConfigMap\ndata:\n  Makefile: {{ multiline 4 .Helpa.Makefile }}\n",
+			Setup: func(Input) (tabContext, error) {
+				return tabContext{Makefile: "all:\n\techo hi\n"}, nil
+			},
+			Options: Options[Input]{
+				TabSize: utils.PointerOf(2),
+			},
+		},
+	)
+	assert.Nil(err)
+
+	instance, _, err := comp.Render(Input{})
+	assert.Nil(err)
+	assert.Equal("all:\n\techo hi\n", instance.Data["Makefile"])
+}
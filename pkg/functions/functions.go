@@ -1,10 +1,14 @@
 package functions
 
 import (
+	"bytes"
+	"encoding/json"
 	"log"
+	"reflect"
 	"strings"
 
 	sprig "github.com/Masterminds/sprig"
+	yamlv3 "gopkg.in/yaml.v3"
 	yaml "sigs.k8s.io/yaml"
 )
 
@@ -18,21 +22,152 @@ func init() {
 	}
 }
 
-// Same as Sprig's `Indent`, except the first line is NOT indented
+// Convert Windows (`\r\n`) and old Mac (`\r`) line endings to `\n`
+func normalizeNewlines(v string) string {
+	v = strings.ReplaceAll(v, "\r\n", "\n")
+	return strings.ReplaceAll(v, "\r", "\n")
+}
+
+// Indent all lines, except empty ones, to avoid trailing whitespace
+func indentNonEmpty(spaces int, v string) string {
+	pad := strings.Repeat(" ", spaces)
+	lines := strings.Split(v, "\n")
+	for index, line := range lines {
+		if line != "" {
+			lines[index] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Same as Sprig's `Indent`, except the first line is NOT indented.
+//
+// Line endings are normalized to `\n`. Empty lines (including the one after
+// a trailing newline) are NOT indented, so the output has no trailing whitespace.
 func IndentRest(spaces int, v string) string {
-	headAndRest := strings.SplitN(v, "\n", 2)
+	headAndRest := strings.SplitN(normalizeNewlines(v), "\n", 2)
 
 	// Skip if there are no newlines in the text
 	if len(headAndRest) <= 1 {
-		return v
+		return headAndRest[0]
 	}
 
 	return strings.Join([]string{
 		headAndRest[0],
-		indentFn(spaces, headAndRest[1]),
+		indentNonEmpty(spaces, headAndRest[1]),
 	}, "\n")
 }
 
+// Same as `IndentRest`, but with a leading newline. `NindentRest` is to `IndentRest`
+// what Sprig's `nindent` is to `indent`.
+func NindentRest(spaces int, v string) string {
+	return "\n" + IndentRest(spaces, v)
+}
+
+// Shorthand for `toYaml | indent n`.
+//
+// Same as Helm's `toYaml`, the trailing newline is removed.
+func ToYamlIndent(spaces int, v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return indentFn(spaces, strings.TrimSuffix(string(data), "\n")), nil
+}
+
+// Render a value as YAML under the given key, e.g.:
+//
+//	metadata:
+//	  {{ embedYaml "labels" 4 .Helpa.Labels }}
+//
+// Outputs `labels:` followed by the value marshalled to YAML, with lines indented
+// by `spaces`. Empty (or nil) maps and structs are output as `labels: {}`,
+// and empty slices as `labels: []`. Scalars are put on the same line as the key.
+func EmbedYaml(key string, spaces int, v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	body := strings.TrimSuffix(string(data), "\n")
+
+	kind := reflect.Invalid
+	if val := reflect.ValueOf(v); val.IsValid() {
+		kind = reflect.Indirect(val).Kind()
+		if val.Kind() == reflect.Ptr && val.IsNil() {
+			kind = val.Type().Elem().Kind()
+		}
+	}
+	isList := kind == reflect.Slice || kind == reflect.Array
+	isCollection := isList || kind == reflect.Map || kind == reflect.Struct
+
+	switch {
+	case body == "null" || body == "{}" || body == "[]":
+		if isList {
+			return key + ": []", nil
+		}
+		return key + ": {}", nil
+	case !isCollection:
+		return key + ": " + IndentRest(spaces, body), nil
+	}
+	return key + ":\n" + indentNonEmpty(spaces, body), nil
+}
+
+// Format a (multi-line) string as a YAML block scalar, so it can be embedded
+// as a value under a key, e.g.:
+//
+//	data:
+//	  script.sh: {{ multiline 4 .Helpa.Script }}
+//
+// Lines of the string are indented by `spaces`. The chomping indicator is chosen
+// based on the trailing newlines, so that the value survives the round trip as is:
+//   - `|-` if there is no trailing newline
+//   - `|` if there is exactly one trailing newline
+//   - `|+` if there are more trailing newlines
+//
+// NOTE: If the first non-empty line starts with a space, the block's indentation
+// would have to be set relative to the parent key, which we don't know. So such
+// strings are formatted as a double-quoted scalar instead.
+func Multiline(spaces int, v string) string {
+	v = normalizeNewlines(v)
+	if v == "" {
+		return `""`
+	}
+	if strings.HasPrefix(strings.TrimLeft(v, "\n"), " ") {
+		return quoteScalar(v)
+	}
+
+	body := strings.TrimRight(v, "\n")
+	trailing := len(v) - len(body)
+
+	chomping := ""
+	switch {
+	case trailing == 0:
+		chomping = "-"
+	case trailing > 1:
+		chomping = "+"
+	}
+
+	// With `|+`, the extra trailing newlines are part of the value. The last one
+	// is supplied by the template itself.
+	if trailing > 1 {
+		body += strings.Repeat("\n", trailing-1)
+	}
+
+	return "|" + chomping + "\n" + indentNonEmpty(spaces, body)
+}
+
+// Format the string as a single-line double-quoted YAML scalar.
+//
+// NOTE: JSON strings are valid YAML double-quoted scalars.
+func quoteScalar(v string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// NOTE: Encoding a string cannot fail
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
 func YamlToJson(v string) (string, error) {
 	jsondata, err := yaml.YAMLToJSON([]byte(v))
 	return string(jsondata), err
@@ -42,3 +177,34 @@ func JsonToYaml(v string) (string, error) {
 	jsondata, err := yaml.JSONToYAML([]byte(v))
 	return string(jsondata), err
 }
+
+// Same as `JsonToYaml`, but the YAML is indented by given number of spaces.
+func JsonToYamlIndent(indent int, v string) (string, error) {
+	var data any
+	if err := yamlv3.Unmarshal([]byte(v), &data); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(data); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Same as `YamlToJson`, but the JSON is pretty-printed with given number of spaces.
+func YamlToJsonIndent(indent int, v string) (string, error) {
+	jsondata, err := yaml.YAMLToJSON([]byte(v))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = json.Indent(&buf, jsondata, "", strings.Repeat(" ", indent))
+	return buf.String(), err
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	assert "github.com/stretchr/testify/assert"
+	yaml "sigs.k8s.io/yaml"
 )
 
 func TestIndentRestNoLines(t *testing.T) {
@@ -35,3 +36,133 @@ func TestYamlToJson(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(`{"Value":["1",2,null,{"some":"value"}]}`, result)
 }
+
+func TestNindentRest(t *testing.T) {
+	assert := assert.New(t)
+
+	result := NindentRest(4, "Hello there!\r\nTraveller")
+	assert.Equal("\nHello there!\n    Traveller", result)
+}
+
+func TestNindentRestNoLines(t *testing.T) {
+	assert := assert.New(t)
+
+	result := NindentRest(4, "Hello there!")
+	assert.Equal("\nHello there!", result)
+}
+
+func TestToYamlIndent(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := ToYamlIndent(2, map[string]any{"name": "kuard", "ports": []int{80}})
+	assert.Nil(err)
+	assert.Equal("  name: kuard\n  ports:\n  - 80", result)
+}
+
+func TestEmbedYaml(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := EmbedYaml("labels", 4, map[string]string{"app": "kuard", "tier": "web"})
+	assert.Nil(err)
+	assert.Equal("labels:\n    app: kuard\n    tier: web", result)
+
+	result, err = EmbedYaml("args", 2, []string{"--port", "8080"})
+	assert.Nil(err)
+	assert.Equal("args:\n  - --port\n  - \"8080\"", result)
+
+	result, err = EmbedYaml("name", 2, "kuard")
+	assert.Nil(err)
+	assert.Equal("name: kuard", result)
+}
+
+func TestEmbedYamlEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := EmbedYaml("labels", 4, map[string]string{})
+	assert.Nil(err)
+	assert.Equal("labels: {}", result)
+
+	var nilMap map[string]string
+	result, err = EmbedYaml("labels", 4, nilMap)
+	assert.Nil(err)
+	assert.Equal("labels: {}", result)
+
+	var nilList []string
+	result, err = EmbedYaml("args", 4, nilList)
+	assert.Nil(err)
+	assert.Equal("args: []", result)
+
+	result, err = EmbedYaml("spec", 4, nil)
+	assert.Nil(err)
+	assert.Equal("spec: {}", result)
+}
+
+func TestMultiline(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("|-\n    echo hello\n\n    exit 0", Multiline(4, "echo hello\n\nexit 0"))
+	assert.Equal("|\n    echo hello\n    exit 0", Multiline(4, "echo hello\r\nexit 0\r\n"))
+	assert.Equal("|+\n    exit 0\n", Multiline(4, "exit 0\n\n"))
+}
+
+func TestMultilineSingleLine(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("|-\n  exit 0", Multiline(2, "exit 0"))
+	assert.Equal(`""`, Multiline(2, ""))
+}
+
+func TestMultilineLeadingSpace(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`"  indented\nnot"`, Multiline(2, "  indented\nnot"))
+	assert.Equal(`" <b>\n\tx\n\n"`, Multiline(2, " <b>\r\n\tx\n\n"))
+	// Leading empty lines don't count
+	assert.Equal(`"\n  a\nb"`, Multiline(4, "\n  a\nb"))
+
+	// Value nested under a key survives the round trip
+	for _, script := range []string{" echo hello\n  exit 0\n", "          deep\nexit 0", "\n  a\nb", "\n\n   \nb\n"} {
+		doc := "data:\n  script.sh: " + Multiline(4, script) + "\n"
+		var data map[string]map[string]string
+		err := yaml.Unmarshal([]byte(doc), &data)
+		assert.Nil(err)
+		assert.Equal(script, data["data"]["script.sh"])
+	}
+}
+
+func TestIndentRestCRLF(t *testing.T) {
+	assert := assert.New(t)
+
+	result := IndentRest(2, "Hello there!\r\nTraveller\r\n  What a nice day.")
+	assert.Equal("Hello there!\n  Traveller\n    What a nice day.", result)
+}
+
+func TestIndentRestTrailingNewline(t *testing.T) {
+	assert := assert.New(t)
+
+	result := IndentRest(2, "Hello there!\nTraveller\n\nWhat a nice day.\n")
+	assert.Equal("Hello there!\n  Traveller\n\n  What a nice day.\n", result)
+}
+
+func TestIndentRestEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", IndentRest(4, ""))
+	assert.Equal("\n", IndentRest(4, "\n"))
+}
+
+func TestJsonToYamlIndent(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := JsonToYamlIndent(4, `{"Value": ["1", 2, null, {"some": {"nested": "value"}}]}`)
+	assert.Nil(err)
+	assert.Equal("Value:\n    - \"1\"\n    - 2\n    - null\n    - some:\n        nested: value\n", result)
+}
+
+func TestYamlToJsonIndent(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := YamlToJsonIndent(4, "Value:\n  - 2\n  - some: \"value\"")
+	assert.Nil(err)
+	assert.Equal("{\n    \"Value\": [\n        2,\n        {\n            \"some\": \"value\"\n        }\n    ]\n}", result)
+}
@@ -0,0 +1,99 @@
+package functions
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrPathNotFound = eris.New("PathNotFound")
+)
+
+// Look up a value in nested maps, structs, and slices by a dotted path, e.g.
+//
+//	{{ dget "Container.Ports.0.ContainerPort" .Helpa }}
+//
+// Path segments are map keys, struct field names (or their JSON names), or
+// slice indices. Pointers and interfaces are dereferenced along the way.
+//
+// Returns `nil` if the path does not exist. Use `MustDget` to error instead.
+func Dget(path string, obj any) (any, error) {
+	val, err := MustDget(path, obj)
+	if eris.Is(err, ErrPathNotFound) {
+		return nil, nil
+	}
+	return val, err
+}
+
+// Same as `Dget`, but returns `ErrPathNotFound` if the path does not exist.
+func MustDget(path string, obj any) (any, error) {
+	current := reflect.ValueOf(obj)
+	if path == "" {
+		return obj, nil
+	}
+
+	segments := strings.Split(path, ".")
+	for index, segment := range segments {
+		current = unwrapLookupValue(current)
+		if !current.IsValid() {
+			return nil, eris.Wrapf(ErrPathNotFound, "path %q: %q is nil", path, strings.Join(segments[:index], "."))
+		}
+
+		next, ok := lookupSegment(current, segment)
+		if !ok {
+			return nil, eris.Wrapf(ErrPathNotFound, "path %q: %q not found in %s", path, segment, current.Type())
+		}
+		current = next
+	}
+
+	current = unwrapLookupValue(current)
+	if !current.IsValid() {
+		return nil, nil
+	}
+	return current.Interface(), nil
+}
+
+// Dereference pointers and interfaces. Returns invalid Value if nil.
+func unwrapLookupValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func lookupSegment(v reflect.Value, segment string) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		item := v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+		return item, item.IsValid()
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 || index >= v.Len() {
+			return reflect.Value{}, false
+		}
+		return v.Index(index), true
+	case reflect.Struct:
+		if field, ok := v.Type().FieldByName(segment); ok && field.IsExported() {
+			return v.FieldByIndex(field.Index), true
+		}
+		// Fall back to JSON names, so paths work the same for structs
+		// and for their YAML/JSON representation.
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.IsExported() && name == segment {
+				return v.Field(i), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
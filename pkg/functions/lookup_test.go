@@ -0,0 +1,67 @@
+package functions
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDget(t *testing.T) {
+	assert := assert.New(t)
+
+	obj := map[string]any{
+		"app": map[string]any{
+			"name":   "kuard",
+			"labels": map[string]string{"env": "prod"},
+		},
+		"container": &corev1.Container{
+			Name:  "kuard",
+			Ports: []corev1.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 9090}},
+		},
+	}
+
+	result, err := Dget("app.name", obj)
+	assert.Nil(err)
+	assert.Equal("kuard", result)
+
+	result, err = Dget("app.labels.env", obj)
+	assert.Nil(err)
+	assert.Equal("prod", result)
+
+	// Struct fields by Go name and by JSON name
+	result, err = Dget("container.Name", obj)
+	assert.Nil(err)
+	assert.Equal("kuard", result)
+
+	result, err = Dget("container.ports.1.containerPort", obj)
+	assert.Nil(err)
+	assert.Equal(int32(9090), result)
+
+	result, err = Dget("", obj)
+	assert.Nil(err)
+	assert.Equal(obj, result)
+}
+
+func TestDgetMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	obj := map[string]any{
+		"app":   map[string]any{"name": "kuard"},
+		"ports": []int{8080},
+		"empty": nil,
+	}
+
+	for _, path := range []string{"app.namespace", "ports.1", "ports.first", "empty.name", "app.name.first"} {
+		result, err := Dget(path, obj)
+		assert.Nil(err, path)
+		assert.Nil(result, path)
+
+		_, err = MustDget(path, obj)
+		assert.ErrorIs(err, ErrPathNotFound, path)
+	}
+
+	result, err := MustDget("empty", obj)
+	assert.Nil(err)
+	assert.Nil(result)
+}
@@ -0,0 +1,88 @@
+package functions
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	alphaChars   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	numericChars = "0123456789"
+)
+
+// Deterministic counterparts of Sprig's random functions (`randAlphaNum`, `uuidv4`, ...),
+// which draw from a seeded source. So the same seed always produces the same output,
+// which is what we want for golden tests and drift detection.
+//
+// NOTE: Not safe for concurrent use. Create a new instance for each render.
+type SeededRand struct {
+	rand *rand.Rand
+}
+
+func NewSeededRand(seed int64) *SeededRand {
+	return &SeededRand{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *SeededRand) randString(count int, chars string) string {
+	if count <= 0 {
+		return ""
+	}
+	buf := make([]byte, count)
+	for i := range buf {
+		buf[i] = chars[r.rand.Intn(len(chars))]
+	}
+	return string(buf)
+}
+
+func (r *SeededRand) RandAlphaNum(count int) string {
+	return r.randString(count, alphaChars+numericChars)
+}
+
+func (r *SeededRand) RandAlpha(count int) string {
+	return r.randString(count, alphaChars)
+}
+
+func (r *SeededRand) RandNumeric(count int) string {
+	return r.randString(count, numericChars)
+}
+
+// Random printable ASCII characters, same as Sprig's `randAscii`
+func (r *SeededRand) RandAscii(count int) string {
+	if count <= 0 {
+		return ""
+	}
+	buf := make([]byte, count)
+	for i := range buf {
+		buf[i] = byte(32 + r.rand.Intn(95))
+	}
+	return string(buf)
+}
+
+// Random integer in the range [min, max), same as Sprig's `randInt`
+func (r *SeededRand) RandInt(min int, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + r.rand.Intn(max-min)
+}
+
+// Random UUID version 4
+func (r *SeededRand) Uuidv4() string {
+	b := make([]byte, 16)
+	r.rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Template functions that replace their random Sprig counterparts
+func (r *SeededRand) FuncMap() map[string]any {
+	return map[string]any{
+		"randAlphaNum": r.RandAlphaNum,
+		"randAlpha":    r.RandAlpha,
+		"randNumeric":  r.RandNumeric,
+		"randAscii":    r.RandAscii,
+		"randInt":      r.RandInt,
+		"uuidv4":       r.Uuidv4,
+	}
+}
@@ -0,0 +1,36 @@
+package functions
+
+import (
+	"regexp"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSeededRand(t *testing.T) {
+	assert := assert.New(t)
+
+	first := NewSeededRand(42)
+	second := NewSeededRand(42)
+	other := NewSeededRand(7)
+
+	a := first.RandAlphaNum(16)
+	assert.Len(a, 16)
+	assert.Regexp(`^[a-zA-Z0-9]+$`, a)
+	assert.Equal(a, second.RandAlphaNum(16))
+	assert.NotEqual(a, other.RandAlphaNum(16))
+
+	assert.Regexp(`^[a-zA-Z]{8}$`, first.RandAlpha(8))
+	assert.Regexp(`^[0-9]{8}$`, first.RandNumeric(8))
+	assert.Len(first.RandAscii(8), 8)
+	assert.Equal("", first.RandAlpha(0))
+
+	n := first.RandInt(5, 10)
+	assert.GreaterOrEqual(n, 5)
+	assert.Less(n, 10)
+	assert.Equal(5, first.RandInt(5, 5))
+
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Regexp(uuidRegex, NewSeededRand(1).Uuidv4())
+	assert.Equal(NewSeededRand(1).Uuidv4(), NewSeededRand(1).Uuidv4())
+}
@@ -0,0 +1,47 @@
+package functions
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrNotStringMap = eris.New("value is not a map with string keys")
+)
+
+// Base64-encode all values of the map, e.g. to fill in `data` of a Secret:
+//
+//	data:
+//	  {{- b64encMap .Helpa.Credentials | toYaml | nindent 2 }}
+//
+// Values that are not strings or bytes are formatted with `fmt.Sprint` first.
+func B64encMap(m any) (map[string]string, error) {
+	val := reflect.ValueOf(m)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return map[string]string{}, nil
+	}
+	if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+		return nil, eris.Wrapf(ErrNotStringMap, "b64encMap got %T", m)
+	}
+
+	out := make(map[string]string, val.Len())
+	for _, key := range val.MapKeys() {
+		var raw []byte
+		switch v := val.MapIndex(key).Interface().(type) {
+		case []byte:
+			raw = v
+		case string:
+			raw = []byte(v)
+		default:
+			raw = []byte(fmt.Sprint(v))
+		}
+		out[key.String()] = base64.StdEncoding.EncodeToString(raw)
+	}
+	return out, nil
+}
@@ -0,0 +1,26 @@
+package functions
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestB64encMap(t *testing.T) {
+	assert := assert.New(t)
+
+	out, err := B64encMap(map[string]string{"username": "admin", "password": "s3cr3t"})
+	assert.Nil(err)
+	assert.Equal(map[string]string{"username": "YWRtaW4=", "password": "czNjcjN0"}, out)
+
+	out, err = B64encMap(map[string]any{"port": 5432, "raw": []byte("ab")})
+	assert.Nil(err)
+	assert.Equal(map[string]string{"port": "NTQzMg==", "raw": "YWI="}, out)
+
+	out, err = B64encMap(nil)
+	assert.Nil(err)
+	assert.Empty(out)
+
+	_, err = B64encMap([]string{"a"})
+	assert.ErrorIs(err, ErrNotStringMap)
+}
@@ -0,0 +1,152 @@
+package functions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	validation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+const DefaultClusterDomain = "cluster.local"
+
+var (
+	ErrInvalidDNSName = eris.New("InvalidDNSName")
+	ErrInvalidPort    = eris.New("InvalidPort")
+	ErrTooManyArgs    = eris.New("TooManyArgs")
+)
+
+func validateDNSLabel(field string, value string) error {
+	if errs := validation.IsDNS1123Label(value); len(errs) > 0 {
+		return eris.Wrapf(ErrInvalidDNSName, "invalid %s %q: %s", field, value, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Resolve the optional cluster domain argument
+func getClusterDomain(clusterDomain []string) (string, error) {
+	switch len(clusterDomain) {
+	case 0:
+		return DefaultClusterDomain, nil
+	case 1:
+		if errs := validation.IsDNS1123Subdomain(clusterDomain[0]); len(errs) > 0 {
+			return "", eris.Wrapf(ErrInvalidDNSName, "invalid cluster domain %q: %s", clusterDomain[0], strings.Join(errs, "; "))
+		}
+		return clusterDomain[0], nil
+	default:
+		return "", eris.Wrapf(ErrTooManyArgs, "expected at most one cluster domain, got %v", len(clusterDomain))
+	}
+}
+
+// Convert port given as number or string to int. Ports may come from
+// different sources, e.g. `corev1.ContainerPort` uses `int32`.
+func toPort(port any) (int, error) {
+	var num int
+	switch v := port.(type) {
+	case int:
+		num = v
+	case int32:
+		num = int(v)
+	case int64:
+		num = int(v)
+	case uint:
+		num = int(v)
+	case uint16:
+		num = int(v)
+	case uint32:
+		num = int(v)
+	case float64:
+		num = int(v)
+		if float64(num) != v {
+			return 0, eris.Wrapf(ErrInvalidPort, "port %v is not a whole number", v)
+		}
+	case string:
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, eris.Wrapf(ErrInvalidPort, "port %q is not a number", v)
+		}
+		num = parsed
+	default:
+		return 0, eris.Wrapf(ErrInvalidPort, "unsupported port type %T", port)
+	}
+
+	if errs := validation.IsValidPortNum(num); len(errs) > 0 {
+		return 0, eris.Wrapf(ErrInvalidPort, "invalid port %v: %s", num, strings.Join(errs, "; "))
+	}
+	return num, nil
+}
+
+// Get the in-cluster DNS name of a service, e.g. `kuard.default.svc.cluster.local`.
+//
+// Cluster domain may be given as optional last argument. Defaults to `cluster.local`.
+func SvcDNS(name string, namespace string, clusterDomain ...string) (string, error) {
+	if err := validateDNSLabel("service name", name); err != nil {
+		return "", err
+	}
+	if err := validateDNSLabel("namespace", namespace); err != nil {
+		return "", err
+	}
+	domain, err := getClusterDomain(clusterDomain)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s.svc.%s", name, namespace, domain), nil
+}
+
+// Get the in-cluster URL of a service, e.g. `http://kuard.default.svc.cluster.local:8080`.
+//
+// Cluster domain may be given as optional last argument. Defaults to `cluster.local`.
+func SvcURL(scheme string, name string, namespace string, port any, clusterDomain ...string) (string, error) {
+	host, err := SvcDNS(name, namespace, clusterDomain...)
+	if err != nil {
+		return "", err
+	}
+	portNum, err := toPort(port)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s://%s:%v", scheme, host, portNum), nil
+}
+
+// Join URL parts with single slashes, e.g. `urlJoin "http://a.b/" "/api" "v1"`
+// gives `http://a.b/api/v1`.
+func UrlJoin(base string, parts ...string) string {
+	url := base
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		url = strings.TrimRight(url, "/") + "/" + strings.TrimLeft(part, "/")
+	}
+	return url
+}
+
+var invalidK8sNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Make a valid resource name (DNS-1123 label) from any string, e.g. `My_App` gives `my-app`.
+//
+// The string is lowercased, and invalid characters are replaced with `-`.
+// Names longer than 63 characters are truncated, and suffixed with a hash of
+// the whole string, so that long names that differ only at the end stay unique.
+//
+// Same as Helm's `{{ . | lower | trunc 63 | trimSuffix "-" }}`, but in one call.
+func K8sName(s string) (string, error) {
+	name := invalidK8sNameChars.ReplaceAllString(strings.ToLower(s), "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		return "", eris.Wrapf(ErrInvalidDNSName, "%q has no characters allowed in a resource name", s)
+	}
+
+	if len(name) > validation.DNS1123LabelMaxLength {
+		hash := sha256.Sum256([]byte(s))
+		suffix := hex.EncodeToString(hash[:])[:8]
+		name = strings.TrimRight(name[:validation.DNS1123LabelMaxLength-len(suffix)-1], "-") + "-" + suffix
+	}
+	return name, nil
+}
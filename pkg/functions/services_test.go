@@ -0,0 +1,97 @@
+package functions
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSvcDNS(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := SvcDNS("kuard", "default")
+	assert.Nil(err)
+	assert.Equal("kuard.default.svc.cluster.local", result)
+
+	result, err = SvcDNS("kuard", "default", "my.cluster")
+	assert.Nil(err)
+	assert.Equal("kuard.default.svc.my.cluster", result)
+}
+
+func TestSvcDNSInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := SvcDNS("Kuard_1", "default")
+	assert.ErrorIs(err, ErrInvalidDNSName)
+
+	_, err = SvcDNS("kuard", "default", "my_cluster")
+	assert.ErrorIs(err, ErrInvalidDNSName)
+
+	_, err = SvcDNS("kuard", "default", "a", "b")
+	assert.ErrorIs(err, ErrTooManyArgs)
+}
+
+func TestSvcURL(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := SvcURL("http", "kuard", "default", 8080)
+	assert.Nil(err)
+	assert.Equal("http://kuard.default.svc.cluster.local:8080", result)
+
+	result, err = SvcURL("https", "kuard", "default", int32(443), "my.cluster")
+	assert.Nil(err)
+	assert.Equal("https://kuard.default.svc.my.cluster:443", result)
+
+	result, err = SvcURL("http", "kuard", "default", "80")
+	assert.Nil(err)
+	assert.Equal("http://kuard.default.svc.cluster.local:80", result)
+}
+
+func TestSvcURLInvalidPort(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := SvcURL("http", "kuard", "default", 0)
+	assert.ErrorIs(err, ErrInvalidPort)
+
+	_, err = SvcURL("http", "kuard", "default", 65536)
+	assert.ErrorIs(err, ErrInvalidPort)
+
+	_, err = SvcURL("http", "kuard", "default", "http")
+	assert.ErrorIs(err, ErrInvalidPort)
+}
+
+func TestUrlJoin(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("http://a.b/api/v1", UrlJoin("http://a.b/", "/api", "v1"))
+	assert.Equal("http://a.b/api/", UrlJoin("http://a.b", "", "api/"))
+	assert.Equal("http://a.b", UrlJoin("http://a.b"))
+}
+
+func TestK8sName(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := K8sName("MyApp")
+	assert.Nil(err)
+	assert.Equal("myapp", result)
+
+	result, err = K8sName("_My App/v1.2__")
+	assert.Nil(err)
+	assert.Equal("my-app-v1-2", result)
+
+	long := strings.Repeat("a", 70)
+	result, err = K8sName(long + "x")
+	assert.Nil(err)
+	assert.Len(result, 63)
+	assert.Nil(validateDNSLabel("name", result))
+	assert.True(strings.HasPrefix(result, strings.Repeat("a", 54)+"-"))
+
+	// Names that differ only after the limit stay unique
+	other, err := K8sName(long + "y")
+	assert.Nil(err)
+	assert.NotEqual(result, other)
+
+	_, err = K8sName("___")
+	assert.ErrorIs(err, ErrInvalidDNSName)
+}
@@ -0,0 +1,70 @@
+package functions
+
+import (
+	"time"
+
+	eris "github.com/rotisserie/eris"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	ErrUnsupportedType = eris.New("UnsupportedType")
+)
+
+// Format a resource quantity in its canonical form, e.g. `500Mi`.
+//
+// Interpolating `resource.Quantity` directly with `{{ }}` prints its internals,
+// because `String()` is defined only on the pointer.
+//
+// Accepts `resource.Quantity`, `*resource.Quantity`, or a string that will be parsed.
+func Quantity(v any) (string, error) {
+	switch q := v.(type) {
+	case resource.Quantity:
+		return q.String(), nil
+	case *resource.Quantity:
+		if q == nil {
+			return "", nil
+		}
+		return q.String(), nil
+	case string:
+		parsed, err := resource.ParseQuantity(q)
+		if err != nil {
+			return "", eris.Wrapf(err, "failed to parse quantity %q", q)
+		}
+		return parsed.String(), nil
+	}
+	return "", eris.Wrapf(ErrUnsupportedType, "cannot format %T as quantity", v)
+}
+
+// Format a duration in the form used by K8s, e.g. `30s` or `1m30s`.
+//
+// Interpolating `metav1.Duration` directly with `{{ }}` prints it as a struct.
+//
+// Accepts `time.Duration`, `metav1.Duration`, pointers to these, or a string
+// that will be parsed.
+func Duration(v any) (string, error) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d.String(), nil
+	case *time.Duration:
+		if d == nil {
+			return "", nil
+		}
+		return d.String(), nil
+	case metav1.Duration:
+		return d.Duration.String(), nil
+	case *metav1.Duration:
+		if d == nil {
+			return "", nil
+		}
+		return d.Duration.String(), nil
+	case string:
+		parsed, err := time.ParseDuration(d)
+		if err != nil {
+			return "", eris.Wrapf(err, "failed to parse duration %q", d)
+		}
+		return parsed.String(), nil
+	}
+	return "", eris.Wrapf(ErrUnsupportedType, "cannot format %T as duration", v)
+}
@@ -0,0 +1,50 @@
+package functions
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuantity(t *testing.T) {
+	assert := assert.New(t)
+
+	q := resource.MustParse("500Mi")
+
+	result, err := Quantity(q)
+	assert.Nil(err)
+	assert.Equal("500Mi", result)
+
+	result, err = Quantity(&q)
+	assert.Nil(err)
+	assert.Equal("500Mi", result)
+
+	result, err = Quantity("0.5")
+	assert.Nil(err)
+	assert.Equal("500m", result)
+
+	_, err = Quantity(12)
+	assert.ErrorIs(err, ErrUnsupportedType)
+}
+
+func TestDuration(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := Duration(30 * time.Second)
+	assert.Nil(err)
+	assert.Equal("30s", result)
+
+	result, err = Duration(metav1.Duration{Duration: 30 * time.Second})
+	assert.Nil(err)
+	assert.Equal("30s", result)
+
+	result, err = Duration("90s")
+	assert.Nil(err)
+	assert.Equal("1m30s", result)
+
+	_, err = Duration("soon")
+	assert.NotNil(err)
+}
@@ -1,6 +1,7 @@
 package preprocess
 
 import (
+	"os"
 	"regexp"
 	"strings"
 
@@ -51,3 +52,30 @@ func Unindent(input string) string {
 	// Join the lines back together.
 	return strings.Join(lines, "\n")
 }
+
+// Expand `${VAR}` and `$VAR` with the values of environment variables,
+// same as `os.ExpandEnv`, e.g. for templates ported from `envsubst`.
+//
+// NOTE: Template actions (`{{ ... }}`) are left as they are, so template
+// variables like `{{ $name }}` still work. To output a literal `$` outside
+// of actions, use `{{ "$" }}`.
+func ExpandEnv(tmpl string) string {
+	var out strings.Builder
+	for {
+		start := strings.Index(tmpl, "{{")
+		if start == -1 {
+			out.WriteString(os.ExpandEnv(tmpl))
+			return out.String()
+		}
+		out.WriteString(os.ExpandEnv(tmpl[:start]))
+		tmpl = tmpl[start:]
+
+		end := strings.Index(tmpl, "}}")
+		if end == -1 {
+			out.WriteString(tmpl)
+			return out.String()
+		}
+		out.WriteString(tmpl[:end+2])
+		tmpl = tmpl[end+2:]
+	}
+}
@@ -0,0 +1,138 @@
+package serializers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrInvalidValues = eris.New("InvalidValues")
+)
+
+type ProblemSeverity string
+
+const (
+	// E.g. the template uses a value that values.yaml doesn't define
+	ProblemError ProblemSeverity = "error"
+	// E.g. values.yaml defines a value that the template doesn't use
+	ProblemWarning ProblemSeverity = "warning"
+)
+
+// Issue found by `CheckValuesCoverage`
+type Problem struct {
+	Severity ProblemSeverity
+	// Path in the values, without the `.Values` prefix, e.g. `image.tag`
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+}
+
+// Check that the `.Values` paths used by the escaped Helm actions (see
+// `component.RenderMultiResult.EscapedActions`) are defined in values.yaml.
+// E.g. to catch when the template uses `.Values.image.repository`, but
+// values.yaml defines only `image.name`.
+//
+//   - Paths that are used but not defined are `ProblemError`, unless the template
+//     handles them being unset, e.g. `.Values.tag | default "latest"`, or
+//     `{{! if .Values.enabled }}`.
+//   - Values that are defined but not used are `ProblemWarning`. Values under
+//     a used path count as used, e.g. `image.tag` when the template uses
+//     `.Values.image | toYaml`.
+//
+// Problems are sorted by severity (errors first), and then by path.
+func CheckValuesCoverage(actions []string, values []byte) ([]Problem, error) {
+	refs, err := valuesRefsInActions("actions", actions)
+	if err != nil {
+		return nil, err
+	}
+
+	valuesMap := map[string]any{}
+	if err := yaml.Unmarshal(values, &valuesMap); err != nil {
+		return nil, eris.Wrapf(ErrInvalidValues, "failed to parse values: %v", err)
+	}
+
+	// Path is required if any of the references requires it
+	required := map[string]bool{}
+	for _, ref := range refs {
+		required[ref.path] = required[ref.path] || !ref.optional
+	}
+
+	problems := []Problem{}
+	for path, isRequired := range required {
+		if isRequired && !hasValuesPath(valuesMap, path) {
+			problems = append(problems, Problem{
+				Severity: ProblemError,
+				Path:     path,
+				Message:  fmt.Sprintf("%q is used by the template, but not defined in values", path),
+			})
+		}
+	}
+
+	for _, path := range valuesLeafPaths(valuesMap, "") {
+		if !isPathUsed(path, required) {
+			problems = append(problems, Problem{
+				Severity: ProblemWarning,
+				Path:     path,
+				Message:  fmt.Sprintf("%q is defined in values, but not used by the template", path),
+			})
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Severity != problems[j].Severity {
+			return problems[i].Severity == ProblemError
+		}
+		return problems[i].Path < problems[j].Path
+	})
+	return problems, nil
+}
+
+// NOTE: Values set to `null` count as defined
+func hasValuesPath(values map[string]any, path string) bool {
+	var current any = values
+	for _, key := range strings.Split(path, ".") {
+		currentMap, ok := current.(map[string]any)
+		if !ok {
+			return false
+		}
+		current, ok = currentMap[key]
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Paths of the values that are not maps, e.g. `image.tag`. Empty maps are included too.
+func valuesLeafPaths(values map[string]any, prefix string) []string {
+	paths := []string{}
+	for key, val := range values {
+		path := prefix + key
+		if nested, ok := val.(map[string]any); ok && len(nested) > 0 {
+			paths = append(paths, valuesLeafPaths(nested, path+".")...)
+		} else {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Value is used if the template uses it, or any of its parents
+func isPathUsed(path string, usedPaths map[string]bool) bool {
+	if _, ok := usedPaths[path]; ok {
+		return true
+	}
+	for usedPath := range usedPaths {
+		if strings.HasPrefix(path, usedPath+".") {
+			return true
+		}
+	}
+	return false
+}
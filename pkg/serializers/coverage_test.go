@@ -0,0 +1,71 @@
+package serializers
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+var coverageValues = []byte(`
+image:
+  name: kuard
+  tag: blue
+service:
+  port: 8080
+  annotations: {}
+replicas: 1
+`)
+
+func TestCheckValuesCoverage(t *testing.T) {
+	assert := assert.New(t)
+
+	problems, err := CheckValuesCoverage([]string{
+		// Nested paths
+		`{{ .Values.image.name }}:{{ .Values.image.tag }}`,
+		// Required, but missing
+		`{{ .Values.image.repository }}`,
+		// Optional, and missing
+		`{{ .Values.image.pullPolicy | default "Always" }}`,
+		`{{ default "kuard" .Values.nameOverride }}`,
+		`{{ if .Values.ingress.enabled }}ingress{{ end }}`,
+		// Whole subtree is used
+		`{{ $.Values.service | toYaml }}`,
+	}, coverageValues)
+	assert.Nil(err)
+	assert.Equal([]Problem{
+		{
+			Severity: ProblemError,
+			Path:     "image.repository",
+			Message:  `"image.repository" is used by the template, but not defined in values`,
+		},
+		{
+			Severity: ProblemWarning,
+			Path:     "replicas",
+			Message:  `"replicas" is defined in values, but not used by the template`,
+		},
+	}, problems)
+}
+
+func TestCheckValuesCoverageRequiredWins(t *testing.T) {
+	assert := assert.New(t)
+
+	// Optional in one place doesn't make it optional elsewhere
+	problems, err := CheckValuesCoverage([]string{
+		`{{ .Values.tag | default "latest" }}`,
+		`{{ .Values.tag }}`,
+	}, []byte(`{}`))
+	assert.Nil(err)
+	assert.Len(problems, 1)
+	assert.Equal("tag", problems[0].Path)
+	assert.Equal(ProblemError, problems[0].Severity)
+}
+
+func TestCheckValuesCoverageInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := CheckValuesCoverage([]string{`{{ .Values.tag }}`}, []byte(`- not a map`))
+	assert.ErrorIs(err, ErrInvalidValues)
+
+	_, err = CheckValuesCoverage([]string{`{{ if .Values.tag }}`}, coverageValues)
+	assert.ErrorIs(err, ErrInvalidEscapedAction)
+}
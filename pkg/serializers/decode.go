@@ -0,0 +1,63 @@
+package serializers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	serializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// Decode a multi-document YAML string into typed K8s resources, e.g. when you
+// have the raw output of a template, and don't use `CreateComponentMulti`.
+//
+// Documents are separated by `---`, and decoded with the scheme's universal
+// deserializer, based on their `apiVersion` and `kind`. Empty documents are skipped.
+//
+// If `scheme` is nil, the client-go scheme with the built-in K8s types is used.
+func DecodeMultiDoc(content string, scheme *runtime.Scheme) ([]runtime.Object, error) {
+	if scheme == nil {
+		scheme = clientgoscheme.Scheme
+	}
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	objects := []runtime.Object{}
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(strings.NewReader(content)))
+	for index := 0; ; index++ {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return objects, eris.Wrapf(err, "failed to read document at index %v", index)
+		}
+		if isEmptyYAMLDoc(doc) {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return objects, eris.Wrapf(err, "failed to decode document at index %v", index)
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// Whether the document contains nothing but whitespace and comments
+func isEmptyYAMLDoc(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' {
+			return false
+		}
+	}
+	return true
+}
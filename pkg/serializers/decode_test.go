@@ -0,0 +1,73 @@
+package serializers
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDecodeMultiDoc(t *testing.T) {
+	assert := assert.New(t)
+
+	content := `
+# Autogenerated
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kuard
+spec:
+  replicas: 2
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: kuard
+spec:
+  ports:
+    - port: 8080
+`
+
+	objects, err := DecodeMultiDoc(content, nil)
+	assert.Nil(err)
+	assert.Len(objects, 2)
+
+	deploy, ok := objects[0].(*appsv1.Deployment)
+	assert.True(ok)
+	assert.Equal("kuard", deploy.Name)
+	assert.Equal(int32(2), *deploy.Spec.Replicas)
+
+	svc, ok := objects[1].(*corev1.Service)
+	assert.True(ok)
+	assert.Equal(int32(8080), svc.Spec.Ports[0].Port)
+}
+
+func TestDecodeMultiDocRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	content := ""
+	for _, resource := range makeTestResources() {
+		doc, err := MarshalK8sResource(resource)
+		assert.Nil(err)
+		content += doc + "\n---\n"
+	}
+
+	objects, err := DecodeMultiDoc(content, nil)
+	assert.Nil(err)
+	assert.Len(objects, 2)
+	assert.IsType(&appsv1.Deployment{}, objects[0])
+	assert.IsType(&corev1.Service{}, objects[1])
+}
+
+func TestDecodeMultiDocUnknownKind(t *testing.T) {
+	assert := assert.New(t)
+
+	content := "apiVersion: example.com/v1\nkind: Unknown\nmetadata:\n  name: kuard\n"
+
+	_, err := DecodeMultiDoc(content, runtime.NewScheme())
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "index 0")
+}
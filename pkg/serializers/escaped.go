@@ -0,0 +1,170 @@
+package serializers
+
+import (
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrInvalidEscapedAction = eris.New("InvalidEscapedAction")
+)
+
+// `.Values` paths referenced by the escaped Helm actions of components.
+// See `ReportEscapedActions`.
+type EscapedActionsReport struct {
+	// Referenced paths, sorted and without the `.Values` prefix, e.g. `image.tag`
+	ValuesPaths []string
+	// Names of the components that reference each path, sorted
+	Components map[string][]string
+}
+
+// Find which `.Values` paths are referenced by the escaped Helm actions
+// (`{{! ... }}`) of the components, e.g. to cross-check them against
+// the generated values.yaml.
+//
+// Keys of `actions` are component names, and values are the actions of each
+// component, as given by `component.RenderMultiResult.EscapedActions`.
+//
+// NOTE: Actions of a component are parsed together, so blocks split across
+// several actions, e.g. `{{! if .Values.x }}` and `{{! end }}`, are supported.
+// Paths relative to the dot, e.g. `.tag` inside `{{! with .Values.image }}`,
+// are not reported.
+func ReportEscapedActions(actions map[string][]string) (EscapedActionsReport, error) {
+	report := EscapedActionsReport{ValuesPaths: []string{}, Components: map[string][]string{}}
+
+	compNames := []string{}
+	for compName := range actions {
+		compNames = append(compNames, compName)
+	}
+	sort.Strings(compNames)
+
+	for _, compName := range compNames {
+		paths, err := valuesPathsInActions(compName, actions[compName])
+		if err != nil {
+			return report, err
+		}
+		for _, path := range paths {
+			if _, ok := report.Components[path]; !ok {
+				report.ValuesPaths = append(report.ValuesPaths, path)
+			}
+			report.Components[path] = append(report.Components[path], compName)
+		}
+	}
+
+	sort.Strings(report.ValuesPaths)
+	return report, nil
+}
+
+// Reference to `.Values` in the escaped actions
+type valuesRef struct {
+	// Path without the `.Values` prefix, e.g. `image.tag`
+	path string
+	// If true, the template handles the value being unset, e.g. with `default`,
+	// or in the condition of `if`
+	optional bool
+}
+
+// Unique `.Values` paths in the actions, in the order they appear
+func valuesPathsInActions(compName string, actions []string) ([]string, error) {
+	refs, err := valuesRefsInActions(compName, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if !seen[ref.path] {
+			seen[ref.path] = true
+			paths = append(paths, ref.path)
+		}
+	}
+	return paths, nil
+}
+
+// All `.Values` references in the actions, in the order they appear
+func valuesRefsInActions(name string, actions []string) ([]valuesRef, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := map[string]*parse.Tree{}
+	_, err := tree.Parse(strings.Join(actions, ""), "", "", treeSet)
+	if err != nil {
+		return nil, eris.Wrapf(ErrInvalidEscapedAction, "failed to parse actions of %q: %v", name, err)
+	}
+
+	// NOTE: Actions may define templates with `{{! define }}`
+	treeNames := []string{}
+	for treeName := range treeSet {
+		treeNames = append(treeNames, treeName)
+	}
+	sort.Strings(treeNames)
+
+	refs := []valuesRef{}
+	onRef := func(ref valuesRef) { refs = append(refs, ref) }
+	for _, treeName := range treeNames {
+		walkValuesRefs(treeSet[treeName].Root, false, onRef)
+	}
+	return refs, nil
+}
+
+func walkValuesRefs(node parse.Node, optional bool, onRef func(ref valuesRef)) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			walkValuesRefs(child, false, onRef)
+		}
+	case *parse.ActionNode:
+		walkValuesRefs(node.Pipe, false, onRef)
+	case *parse.IfNode:
+		walkValuesRefs(&node.BranchNode, false, onRef)
+	case *parse.RangeNode:
+		walkValuesRefs(&node.BranchNode, false, onRef)
+	case *parse.WithNode:
+		walkValuesRefs(&node.BranchNode, false, onRef)
+	case *parse.BranchNode:
+		// Conditions handle unset values, e.g. `{{! if .Values.enabled }}`
+		walkValuesRefs(node.Pipe, true, onRef)
+		walkValuesRefs(node.List, false, onRef)
+		walkValuesRefs(node.ElseList, false, onRef)
+	case *parse.TemplateNode:
+		walkValuesRefs(node.Pipe, false, onRef)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for index, cmd := range node.Cmds {
+			// E.g. `.Values.tag | default "latest"`
+			pipedToDefault := index+1 < len(node.Cmds) && isDefaultCommand(node.Cmds[index+1])
+			walkValuesRefs(cmd, optional || pipedToDefault, onRef)
+		}
+	case *parse.CommandNode:
+		for index, arg := range node.Args {
+			// E.g. `default "latest" .Values.tag`
+			defaultedArg := isDefaultCommand(node) && len(node.Args) == 3 && index == 2
+			walkValuesRefs(arg, optional || defaultedArg, onRef)
+		}
+	case *parse.ChainNode:
+		walkValuesRefs(node.Node, optional, onRef)
+	case *parse.FieldNode:
+		// E.g. `.Values.image.tag`
+		if len(node.Ident) > 1 && node.Ident[0] == "Values" {
+			onRef(valuesRef{path: strings.Join(node.Ident[1:], "."), optional: optional})
+		}
+	case *parse.VariableNode:
+		// E.g. `$.Values.image.tag`
+		if len(node.Ident) > 2 && node.Ident[0] == "$" && node.Ident[1] == "Values" {
+			onRef(valuesRef{path: strings.Join(node.Ident[2:], "."), optional: optional})
+		}
+	}
+}
+
+func isDefaultCommand(cmd *parse.CommandNode) bool {
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == "default"
+}
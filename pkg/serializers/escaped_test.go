@@ -0,0 +1,43 @@
+package serializers
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestReportEscapedActions(t *testing.T) {
+	assert := assert.New(t)
+
+	report, err := ReportEscapedActions(map[string][]string{
+		"kuard": {
+			`{{ .Values.namespace }}`,
+			`{{ .Values.image.tag | default "blue" }}`,
+		},
+		"kuard-service": {
+			`{{ if .Values.service.enabled }}`,
+			`{{ $.Values.namespace }}`,
+			`{{ end }}`,
+			// Not `.Values`
+			`{{ .Release.Name }}`,
+		},
+	})
+	assert.Nil(err)
+	assert.Equal([]string{"image.tag", "namespace", "service.enabled"}, report.ValuesPaths)
+	assert.Equal(map[string][]string{
+		"image.tag":       {"kuard"},
+		"namespace":       {"kuard", "kuard-service"},
+		"service.enabled": {"kuard-service"},
+	}, report.Components)
+}
+
+func TestReportEscapedActionsInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	// Unclosed block
+	_, err := ReportEscapedActions(map[string][]string{
+		"kuard": {`{{ if .Values.enabled }}`},
+	})
+	assert.ErrorIs(err, ErrInvalidEscapedAction)
+	assert.Contains(err.Error(), `"kuard"`)
+}
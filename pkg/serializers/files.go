@@ -0,0 +1,116 @@
+package serializers
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrFileExists      = eris.New("FileExists")
+	ErrInvalidFilePath = eris.New("InvalidFilePath")
+)
+
+// Options for `WriteFiles`
+type WriteFilesOptions struct {
+	// If set, this text is put on the first line of each file, e.g.
+	// `# Autogenerated by Helpa`.
+	//
+	// NOTE: The header is written as is, so it must be a valid comment
+	// in the format of the file (JSON has none).
+	Header string
+	// If true, each file is first written to a temporary file in the same
+	// directory, and then renamed, so readers never see a partially written file.
+	Atomic bool
+	// If true, no files are written if any of them already exists.
+	NoClobber bool
+}
+
+// Write arbitrary content to files in the target directory. Unlike `HelmChartSerializer`,
+// the content is not required to be K8s resources, so this can be used for any
+// rendered text, e.g. YAML, JSON or TOML configs:
+//
+//	serializers.WriteFiles(map[string]string{
+//		"config.toml":     tomlContent,
+//		"nested/app.json": jsonContent,
+//	}, "./out", serializers.WriteFilesOptions{Atomic: true})
+//
+// Keys are file paths relative to the target directory. Missing directories are created.
+func WriteFiles(files map[string]string, targetDir string, opts WriteFilesOptions) error {
+	// Write the files in a stable order
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Check all paths before writing anything
+	paths := map[string]string{}
+	for _, name := range names {
+		path, err := resolveFilePath(targetDir, name)
+		if err != nil {
+			return err
+		}
+		if opts.NoClobber {
+			if _, err := os.Stat(path); err == nil {
+				return eris.Wrapf(ErrFileExists, "file %q already exists", path)
+			}
+		}
+		paths[name] = path
+	}
+
+	for _, name := range names {
+		content := files[name]
+		if opts.Header != "" {
+			content = strings.Join([]string{opts.Header, content}, "\n")
+		}
+
+		path := paths[name]
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return eris.Wrapf(err, "failed to create directory for file %q", path)
+		}
+		if err := writeFile(path, []byte(content), opts.Atomic); err != nil {
+			return eris.Wrapf(err, "failed to write file %q", path)
+		}
+	}
+
+	return nil
+}
+
+// NOTE: Files must stay inside the target directory
+func resolveFilePath(targetDir string, name string) (string, error) {
+	cleaned := filepath.Clean(name)
+	if name == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", eris.Wrapf(ErrInvalidFilePath, "file path %q must be relative to the target directory", name)
+	}
+	return filepath.Join(targetDir, cleaned), nil
+}
+
+func writeFile(path string, content []byte, atomic bool) error {
+	if !atomic {
+		return os.WriteFile(path, content, 0644)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// NOTE: No-op once the file is renamed
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	// NOTE: Temp files are created with 0600
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
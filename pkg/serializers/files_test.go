@@ -0,0 +1,50 @@
+package serializers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestWriteFiles(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"config.toml":     "[server]\nport = 8080\n",
+		"nested/app.yaml": "name: kuard\n",
+	}
+	err := WriteFiles(files, dir, WriteFilesOptions{Header: "# Autogenerated", Atomic: true})
+	assert.Nil(err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "config.toml"))
+	assert.Nil(err)
+	assert.Equal("# Autogenerated\n[server]\nport = 8080\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "nested", "app.yaml"))
+	assert.Nil(err)
+	assert.Equal("# Autogenerated\nname: kuard\n", string(content))
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	assert.Nil(err)
+	assert.Len(entries, 2)
+
+	// Existing files are kept with `NoClobber`
+	err = WriteFiles(map[string]string{"a.json": "{}", "config.toml": ""}, dir, WriteFilesOptions{NoClobber: true})
+	assert.ErrorIs(err, ErrFileExists)
+	_, err = os.Stat(filepath.Join(dir, "a.json"))
+	assert.True(os.IsNotExist(err))
+
+	// But overwritten otherwise
+	err = WriteFiles(map[string]string{"config.toml": "port = 9090\n"}, dir, WriteFilesOptions{})
+	assert.Nil(err)
+	content, err = os.ReadFile(filepath.Join(dir, "config.toml"))
+	assert.Nil(err)
+	assert.Equal("port = 9090\n", string(content))
+
+	err = WriteFiles(map[string]string{"../outside.yaml": ""}, dir, WriteFilesOptions{})
+	assert.ErrorIs(err, ErrInvalidFilePath)
+}
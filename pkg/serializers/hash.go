@@ -0,0 +1,46 @@
+package serializers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type ContentHashOptions struct {
+	// By default, the hash depends on the order of the resources. If true,
+	// the resources are sorted before hashing, so that the same set of
+	// resources always gives the same hash.
+	Sort bool
+}
+
+// Compute a stable hash of the given K8s resources, e.g. for change detection
+// or rollout annotations like `kubectl.kubernetes.io/restartedAt`.
+//
+// Resources are serialized the same way as in `HelmChartSerializer` (sorted keys,
+// no timestamps), and the result is a hex-encoded sha256 digest.
+func ContentHash(resources []runtime.Object) (string, error) {
+	return ContentHashWithOptions(resources, ContentHashOptions{})
+}
+
+// Same as `ContentHash`, but configurable.
+func ContentHashWithOptions(resources []runtime.Object, opts ContentHashOptions) (string, error) {
+	serialized := []string{}
+	for index, resource := range resources {
+		content, err := MarshalK8sResource(resource)
+		if err != nil {
+			return "", eris.Wrapf(err, "failed to marshal resource at index %v", index)
+		}
+		serialized = append(serialized, content)
+	}
+
+	if opts.Sort {
+		sort.Strings(serialized)
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(serialized, "\n---\n")))
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,62 @@
+package serializers
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func makeTestResources() []runtime.Object {
+	return []runtime.Object{
+		&appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard"},
+		},
+		&corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kuard"},
+		},
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	assert := assert.New(t)
+
+	hash1, err := ContentHash(makeTestResources())
+	assert.Nil(err)
+	assert.Len(hash1, 64)
+
+	hash2, err := ContentHash(makeTestResources())
+	assert.Nil(err)
+	assert.Equal(hash1, hash2)
+
+	resources := makeTestResources()
+	resources[0].(*appsv1.Deployment).Name = "kuard-2"
+	hash3, err := ContentHash(resources)
+	assert.Nil(err)
+	assert.NotEqual(hash1, hash3)
+}
+
+func TestContentHashOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	resources := makeTestResources()
+	reversed := []runtime.Object{resources[1], resources[0]}
+
+	hash1, err := ContentHash(resources)
+	assert.Nil(err)
+	hash2, err := ContentHash(reversed)
+	assert.Nil(err)
+	assert.NotEqual(hash1, hash2)
+
+	opts := ContentHashOptions{Sort: true}
+	hash1, err = ContentHashWithOptions(resources, opts)
+	assert.Nil(err)
+	hash2, err = ContentHashWithOptions(reversed, opts)
+	assert.Nil(err)
+	assert.Equal(hash1, hash2)
+}
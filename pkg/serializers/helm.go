@@ -1,10 +1,12 @@
 package serializers
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 
 var (
 	ErrInvalidGroupByKey = eris.New("InvalidGroupByKey")
+	ErrDuplicateFileName = eris.New("DuplicateFileName")
+	ErrInvalidExtension  = eris.New("InvalidExtension")
 )
 
 func K8sGroupResourcesByFunc[T runtime.Object](resources []T, groupBy func(T) (string, error)) (map[string][]T, error) {
@@ -62,42 +66,275 @@ func K8sGroupResourcesBy[T runtime.Object](resources []T, groupBy string) (map[s
 	return groups, nil
 }
 
-func writeK8sResourcesToFile(resourceGroups map[string][]runtime.Object, targetDir string) error {
+var creationTimestampRegex = regexp.MustCompile(`\n?[ \t]*creationTimestamp: null[ \t]*\n?`)
+
+// Serialize a K8s resource to YAML, omitting the fields that are only noise
+// in the generated files, like `creationTimestamp: null`.
+//
+// This is the format of the generated files, so use it when comparing resources
+// against them, e.g. in `apply.Diff`.
+func MarshalK8sResource(resource runtime.Object) (string, error) {
+	return marshalK8sResourceWith(resource, yaml.Marshal)
+}
+
+func marshalK8sResourceWith(resource runtime.Object, marshal func(any) ([]byte, error)) (string, error) {
+	yamlBytes, err := marshal(resource)
+	if err != nil {
+		return "", err
+	}
+
+	content := creationTimestampRegex.ReplaceAllString(string(yamlBytes), "\n")
+	return content, nil
+}
+
+// Options for `HelmChartSerializerWithOptions`
+type SerializerOptions struct {
+	// If set, an index of the generated files and the resources they contain
+	// is written to this file, relative to the target directory. See `ChartIndex`.
+	//
+	// NOTE: Helm renders all files in the `templates` directory, except those
+	// whose name starts with an underscore. So when writing into Helm templates,
+	// use e.g. `_index.yaml`, or place the index outside, e.g. `../index.yaml`.
+	IndexFile string
+	// If set, the standard Helm labels and annotations are added to each resource
+	// before it's written. See `ReleaseInfo`.
+	ReleaseInfo *ReleaseInfo
+	// Custom function to serialize each resource, e.g. to control indentation
+	// or flow style with `gopkg.in/yaml.v3`.
+	//
+	// NOTE: K8s types define only `json` struct tags. So to use a YAML library
+	// that reads `yaml` tags, first convert the resource to a map, e.g. with
+	// `runtime.DefaultUnstructuredConverter.ToUnstructured`.
+	//
+	// Default: `sigs.k8s.io/yaml.Marshal`
+	Marshal func(v any) ([]byte, error)
+	// If true, empty slices and maps are kept in the output, e.g. `imagePullSecrets: []`,
+	// even if their fields are marked with `omitempty`. Nil slices and maps are
+	// still omitted, so set the field to an empty non-nil value to keep it.
+	//
+	// NOTE: With a custom `Marshal`, it then receives the resource converted to
+	// `map[string]any` instead of the resource itself.
+	KeepEmpty bool
+	// If true, the resources of each file are wrapped in a single `kind: List`
+	// object, instead of being written as multiple YAML documents. See `WrapInList`.
+	WrapInList bool
+	// If true, `stringData` of Secrets is moved into `data`, base64-encoded.
+	// See `NormalizeSecret`.
+	NormalizeSecrets bool
+	// Extension of the generated files, e.g. `.yml`, or `.json` with a custom
+	// `Marshal` that outputs JSON. The leading dot may be omitted.
+	//
+	// NOTE: Files start with a `#` comment, so for JSON, strip the first line
+	// before parsing.
+	//
+	// Default: `.yaml`
+	Extension string
+}
+
+// Information about the Helm release, from which the standard Helm labels and
+// annotations are generated. Empty fields are omitted.
+//
+// NOTE: Values may also be Helm template actions, e.g. `{{ .Release.Name }}`,
+// so they are filled in by Helm at install time.
+type ReleaseInfo struct {
+	// Sets `app.kubernetes.io/instance` label and `meta.helm.sh/release-name` annotation
+	ReleaseName string
+	// Sets `meta.helm.sh/release-namespace` annotation
+	ReleaseNamespace string
+	// `ChartName` and `ChartVersion` together set the `helm.sh/chart` label
+	ChartName    string
+	ChartVersion string
+	// Sets `app.kubernetes.io/version` label
+	AppVersion string
+	// Sets `app.kubernetes.io/managed-by` label. Default: `Helm`
+	Service string
+}
+
+// Standard Helm labels for the release.
+// See https://helm.sh/docs/chart_best_practices/labels/#standard-labels
+func (r ReleaseInfo) Labels() map[string]string {
+	labels := map[string]string{}
+
+	service := r.Service
+	if service == "" {
+		service = "Helm"
+	}
+	labels["app.kubernetes.io/managed-by"] = service
+
+	if r.ReleaseName != "" {
+		labels["app.kubernetes.io/instance"] = r.ReleaseName
+	}
+	if r.AppVersion != "" {
+		labels["app.kubernetes.io/version"] = r.AppVersion
+	}
+	if r.ChartName != "" {
+		chart := r.ChartName
+		if r.ChartVersion != "" {
+			// NOTE: Label values cannot contain `+`, which is valid in SemVer
+			chart = fmt.Sprintf("%s-%s", r.ChartName, strings.ReplaceAll(r.ChartVersion, "+", "_"))
+		}
+		labels["helm.sh/chart"] = chart
+	}
+
+	return labels
+}
+
+// Annotations with which Helm marks the resources that belong to the release.
+func (r ReleaseInfo) Annotations() map[string]string {
+	annotations := map[string]string{}
+	if r.ReleaseName != "" {
+		annotations["meta.helm.sh/release-name"] = r.ReleaseName
+	}
+	if r.ReleaseNamespace != "" {
+		annotations["meta.helm.sh/release-namespace"] = r.ReleaseNamespace
+	}
+	return annotations
+}
+
+// Return copies of the resources with the release labels and annotations added.
+// Labels and annotations already set on the resources are kept.
+func addReleaseMetadata(resourceGroups map[string][]runtime.Object, release ReleaseInfo) (map[string][]runtime.Object, error) {
+	labels := release.Labels()
+	annotations := release.Annotations()
+
+	out := make(map[string][]runtime.Object, len(resourceGroups))
+	for groupName, resources := range resourceGroups {
+		outResources := make([]runtime.Object, 0, len(resources))
+		for index, resource := range resources {
+			resource = resource.DeepCopyObject()
+			accessor, err := meta.Accessor(resource)
+			if err != nil {
+				return nil, eris.Wrapf(err, "failed getting accessor for resource in file %s at index %v", groupName, index)
+			}
+			accessor.SetLabels(mergeMissing(accessor.GetLabels(), labels))
+			accessor.SetAnnotations(mergeMissing(accessor.GetAnnotations(), annotations))
+			outResources = append(outResources, resource)
+		}
+		out[groupName] = outResources
+	}
+	return out, nil
+}
+
+func mergeMissing(dst map[string]string, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]string{}
+	}
+	for key, val := range src {
+		if _, ok := dst[key]; !ok {
+			dst[key] = val
+		}
+	}
+	return dst
+}
+
+// Index of the files generated by `HelmChartSerializerWithOptions`
+type ChartIndex struct {
+	Files []ChartIndexFile `json:"files"`
+}
+
+type ChartIndexFile struct {
+	File      string               `json:"file"`
+	Resources []ChartIndexResource `json:"resources"`
+}
+
+type ChartIndexResource struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func fileNameForGroup(groupName string, extension string) string {
+	return groupName + extension
+}
+
+// Normalize the file extension to start with a dot, e.g. `yml` to `.yml`
+func normalizeExtension(extension string) (string, error) {
+	if extension == "" {
+		return ".yaml", nil
+	}
+	if !strings.HasPrefix(extension, ".") {
+		extension = "." + extension
+	}
+	if strings.HasSuffix(extension, ".") || strings.ContainsAny(extension, "/\\ \t\n") {
+		return "", eris.Wrapf(ErrInvalidExtension, "invalid file extension %q", extension)
+	}
+	return extension, nil
+}
+
+// Serialize the resource groups, and return the content by file names
+func marshalK8sResourceGroups(resourceGroups map[string][]runtime.Object, marshal func(any) ([]byte, error), wrapInList bool, extension string) (map[string]string, error) {
 	groups := make(map[string]string)
 
 	// Serialize
 	for key, resources := range resourceGroups {
+		if wrapInList {
+			resources = []runtime.Object{WrapInList(resources)}
+		}
+
 		serialized := []string{}
 		for index, resource := range resources {
-			yamlBytes, err := yaml.Marshal(resource)
+			content, err := marshalK8sResourceWith(resource, marshal)
 			if err != nil {
-				return eris.Wrapf(err, "failed to marshal resource for file %s at index %v", key, index)
+				return nil, eris.Wrapf(err, "failed to marshal resource for file %s at index %v", key, index)
 			}
-			serialized = append(serialized, string(yamlBytes))
+			serialized = append(serialized, content)
 		}
 
-		content := strings.Join(serialized, "\n---\n")
-
-		re := regexp.MustCompile(`\n?[ \t]*creationTimestamp: null[ \t]*\n?`)
-		content = re.ReplaceAllString(content, "\n")
-
-		groups[key] = content
+		groups[key] = strings.Join(serialized, "\n---\n")
 	}
 
 	timestamp := time.Now().Format(time.RFC3339)
 	comment := fmt.Sprintf("# Autogenerated by Helpa HelmChartSerializer on %s", timestamp)
 
-	// Write groups to files
+	files := make(map[string]string)
 	for groupName, content := range groups {
-		content = strings.Join([]string{comment, content}, "\n")
+		files[fileNameForGroup(groupName, extension)] = strings.Join([]string{comment, content}, "\n")
+	}
+	return files, nil
+}
+
+// Build the index of files and the resources they contain, sorted by file names.
+func buildChartIndex(resourceGroups map[string][]runtime.Object, extension string) (ChartIndex, error) {
+	groupNames := make([]string, 0, len(resourceGroups))
+	for groupName := range resourceGroups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
 
-		filename := filepath.Join(targetDir, fmt.Sprintf("%s.yaml", groupName))
-		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
-			return eris.Wrapf(err, "failed to write resources to file %s", groupName)
+	index := ChartIndex{Files: []ChartIndexFile{}}
+	for _, groupName := range groupNames {
+		file := ChartIndexFile{File: fileNameForGroup(groupName, extension), Resources: []ChartIndexResource{}}
+		for resIndex, resource := range resourceGroups[groupName] {
+			accessor, err := meta.Accessor(resource)
+			if err != nil {
+				return index, eris.Wrapf(err, "failed getting accessor for resource in file %s at index %v", groupName, resIndex)
+			}
+			file.Resources = append(file.Resources, ChartIndexResource{
+				Kind:      resource.GetObjectKind().GroupVersionKind().Kind,
+				Name:      accessor.GetName(),
+				Namespace: accessor.GetNamespace(),
+			})
 		}
+		index.Files = append(index.Files, file)
 	}
 
-	return nil
+	return index, nil
+}
+
+func marshalChartIndex(resourceGroups map[string][]runtime.Object, extension string) (string, error) {
+	index, err := buildChartIndex(resourceGroups, extension)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := yaml.Marshal(index)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to marshal index")
+	}
+	return string(content), nil
 }
 
 // Given a target directory and a Map of `template name -> list K8s resources`,
@@ -106,14 +343,174 @@ func writeK8sResourcesToFile(resourceGroups map[string][]runtime.Object, targetD
 //
 // The output is intended to be compatible with Helm chart templates.
 func HelmChartSerializer(resources map[string][]runtime.Object, targetDir string) error {
+	return HelmChartSerializerWithOptions(resources, targetDir, SerializerOptions{})
+}
+
+// Same as `HelmChartSerializer`, but configurable with `SerializerOptions`.
+func HelmChartSerializerWithOptions(resources map[string][]runtime.Object, targetDir string, opts SerializerOptions) error {
+	files, err := HelmChartContents(resources, opts)
+	if err != nil {
+		return err
+	}
+
 	// See https://stackoverflow.com/a/31151508/9788634
 	if err := os.MkdirAll(targetDir, 0755); err != nil {
 		return eris.Wrapf(err, "failed to create directory at %q", targetDir)
 	}
 
-	if err := writeK8sResourcesToFile(resources, targetDir); err != nil {
-		return eris.Wrapf(err, "failed to write k8s resources to directory %q", targetDir)
+	// Write the files in a stable order
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filename := filepath.Join(targetDir, name)
+		if err := os.WriteFile(filename, []byte(files[name]), 0644); err != nil {
+			return eris.Wrapf(err, "failed to write file %s to directory %q", name, targetDir)
+		}
 	}
 
 	return nil
 }
+
+// Same as `HelmChartSerializerWithOptions`, but instead of writing the files,
+// return their content by file names, relative to the target directory, e.g.
+// `kuard.yaml`. Use it to preview the chart, e.g. in CI, without writing to disk.
+//
+// If `SerializerOptions.IndexFile` is set, the index is included too.
+func HelmChartContents(resources map[string][]runtime.Object, opts SerializerOptions) (map[string]string, error) {
+	extension, err := normalizeExtension(opts.Extension)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ReleaseInfo != nil {
+		resources, err = addReleaseMetadata(resources, *opts.ReleaseInfo)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to add release metadata")
+		}
+	}
+
+	if opts.NormalizeSecrets {
+		resources = normalizeSecrets(resources)
+	}
+
+	marshal := opts.Marshal
+	if marshal == nil {
+		marshal = yaml.Marshal
+	}
+	if opts.KeepEmpty {
+		marshal = keepEmptyMarshal(marshal)
+	}
+
+	files, err := marshalK8sResourceGroups(resources, marshal, opts.WrapInList, extension)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to serialize k8s resources")
+	}
+
+	if opts.IndexFile != "" {
+		if _, ok := files[opts.IndexFile]; ok {
+			return nil, eris.Wrapf(ErrDuplicateFileName, "index file %q has the same name as a file with resources", opts.IndexFile)
+		}
+		files[opts.IndexFile], err = marshalChartIndex(resources, extension)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to serialize index")
+		}
+	}
+
+	return files, nil
+}
+
+// Default naming function for `SerializePerResource`, which names files as
+// `<kind>-<name>`, e.g. `deployment-kuard`.
+func K8sResourceKindName(resource runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(resource)
+	if err != nil {
+		return "", eris.Wrap(err, "failed getting name accessor")
+	}
+	kind := strings.ToLower(resource.GetObjectKind().GroupVersionKind().Kind)
+	return fmt.Sprintf("%s-%s", kind, accessor.GetName()), nil
+}
+
+// Same as `HelmChartSerializer`, but each resource is written to its own file.
+//
+// `nameFn` defines the name of the file (without the extension) for given resource.
+// If `nil`, files are named `<kind>-<name>`, see `K8sResourceKindName`.
+func SerializePerResource(resources []runtime.Object, targetDir string, nameFn func(runtime.Object) (string, error)) error {
+	if nameFn == nil {
+		nameFn = K8sResourceKindName
+	}
+
+	groups := make(map[string][]runtime.Object)
+	for index, resource := range resources {
+		name, err := nameFn(resource)
+		if err != nil {
+			return eris.Wrapf(err, "failed to get file name for resource at index %v", index)
+		}
+		if _, ok := groups[name]; ok {
+			return eris.Wrapf(ErrDuplicateFileName, "file name %q of resource at index %v is already taken", name, index)
+		}
+		groups[name] = []runtime.Object{resource}
+	}
+
+	return HelmChartSerializer(groups, targetDir)
+}
+
+// Render multiple components and serialize them into the given directory with
+// `HelmChartSerializer`, so that the resources of each component end up
+// in a file named by the map key, e.g. `kuard.yaml`.
+//
+// All components are rendered, even if some of them fail, and the errors are
+// joined together. If any component fails, no files are written.
+//
+// NOTE: Use closures to pass inputs to the components, e.g.:
+//
+//	serializers.RenderChart(map[string]func() ([]runtime.Object, error){
+//		"kuard": func() ([]runtime.Object, error) {
+//			specs, _, err := kuard.Component.Render(input.KuardInput)
+//			return specs, err
+//		},
+//	}, "./templates")
+func RenderChart(components map[string]func() ([]runtime.Object, error), targetDir string) error {
+	resources, err := renderChartComponents(components)
+	if err != nil {
+		return err
+	}
+	return HelmChartSerializer(resources, targetDir)
+}
+
+// Same as `RenderChart`, but instead of writing the files, return their content
+// by file names, e.g. to preview the chart in CI. See `HelmChartContents`.
+func RenderChartDryRun(components map[string]func() ([]runtime.Object, error)) (map[string]string, error) {
+	resources, err := renderChartComponents(components)
+	if err != nil {
+		return nil, err
+	}
+	return HelmChartContents(resources, SerializerOptions{})
+}
+
+func renderChartComponents(components map[string]func() ([]runtime.Object, error)) (map[string][]runtime.Object, error) {
+	// Render in a stable order, so errors are reported consistently
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resources := make(map[string][]runtime.Object)
+	errs := []error{}
+	for _, name := range names {
+		objs, err := components[name]()
+		if err != nil {
+			errs = append(errs, eris.Wrapf(err, "failed to render component %q", name))
+			continue
+		}
+		resources[name] = objs
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return resources, nil
+}
@@ -0,0 +1,274 @@
+package serializers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	yaml "sigs.k8s.io/yaml"
+)
+
+func TestSerializePerResource(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	err := SerializePerResource(makeTestResources(), dir, nil)
+	assert.Nil(err)
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(err)
+	assert.Len(entries, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, "deployment-kuard.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "kind: Deployment")
+	assert.NotContains(string(content), "kind: Service")
+
+	content, err = os.ReadFile(filepath.Join(dir, "service-kuard.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "kind: Service")
+}
+
+func TestSerializePerResourceDuplicateName(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	err := SerializePerResource(makeTestResources(), dir, func(runtime.Object) (string, error) {
+		return "same", nil
+	})
+	assert.ErrorIs(err, ErrDuplicateFileName)
+}
+
+func TestRenderChart(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	resources := makeTestResources()
+	err := RenderChart(map[string]func() ([]runtime.Object, error){
+		"deployment": func() ([]runtime.Object, error) {
+			return resources[:1], nil
+		},
+		"service": func() ([]runtime.Object, error) {
+			return resources[1:], nil
+		},
+	}, dir)
+	assert.Nil(err)
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(err)
+	assert.Len(entries, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, "deployment.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "kind: Deployment")
+
+	content, err = os.ReadFile(filepath.Join(dir, "service.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "kind: Service")
+}
+
+func TestRenderChartAggregatesErrors(t *testing.T) {
+	assert := assert.New(t)
+	dir := filepath.Join(t.TempDir(), "templates")
+
+	errFirst := errors.New("first failed")
+	errSecond := errors.New("second failed")
+	err := RenderChart(map[string]func() ([]runtime.Object, error){
+		"first":  func() ([]runtime.Object, error) { return nil, errFirst },
+		"second": func() ([]runtime.Object, error) { return nil, errSecond },
+		"third":  func() ([]runtime.Object, error) { return makeTestResources(), nil },
+	}, dir)
+	assert.ErrorIs(err, errFirst)
+	assert.ErrorIs(err, errSecond)
+	assert.Contains(err.Error(), `failed to render component "first"`)
+	assert.Contains(err.Error(), `failed to render component "second"`)
+
+	// Nothing is written if any of the components fail
+	_, err = os.Stat(dir)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestRenderChartDryRun(t *testing.T) {
+	assert := assert.New(t)
+	// Run in an empty directory, to check that nothing is written
+	cwd, err := os.Getwd()
+	assert.Nil(err)
+	assert.Nil(os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	resources := makeTestResources()
+	files, err := RenderChartDryRun(map[string]func() ([]runtime.Object, error){
+		"deployment": func() ([]runtime.Object, error) {
+			return resources[:1], nil
+		},
+		"service": func() ([]runtime.Object, error) {
+			return resources[1:], nil
+		},
+	})
+	assert.Nil(err)
+	assert.Len(files, 2)
+	assert.Contains(files["deployment.yaml"], "# Autogenerated by Helpa HelmChartSerializer")
+	assert.Contains(files["deployment.yaml"], "kind: Deployment")
+	assert.Contains(files["service.yaml"], "kind: Service")
+
+	entries, err := os.ReadDir(".")
+	assert.Nil(err)
+	assert.Empty(entries)
+}
+
+func TestHelmChartContents(t *testing.T) {
+	assert := assert.New(t)
+
+	groups := map[string][]runtime.Object{"kuard": makeTestResources()}
+	files, err := HelmChartContents(groups, SerializerOptions{IndexFile: "_index.yaml", Extension: "yml"})
+	assert.Nil(err)
+	assert.Len(files, 2)
+	assert.Contains(files["kuard.yml"], "kind: Service")
+	assert.Contains(files["_index.yaml"], "file: kuard.yml")
+
+	// Same content as written by the serializer, except for the timestamp
+	dir := t.TempDir()
+	err = HelmChartSerializerWithOptions(groups, dir, SerializerOptions{IndexFile: "_index.yaml", Extension: "yml"})
+	assert.Nil(err)
+	content, err := os.ReadFile(filepath.Join(dir, "_index.yaml"))
+	assert.Nil(err)
+	assert.Equal(files["_index.yaml"], string(content))
+
+	_, err = HelmChartContents(groups, SerializerOptions{IndexFile: "kuard.yaml"})
+	assert.ErrorIs(err, ErrDuplicateFileName)
+}
+
+func TestHelmChartSerializerIndexFile(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	resources := makeTestResources()
+	err := HelmChartSerializerWithOptions(map[string][]runtime.Object{
+		"kuard":   resources,
+		"service": resources[1:],
+	}, dir, SerializerOptions{IndexFile: "_index.yaml"})
+	assert.Nil(err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "_index.yaml"))
+	assert.Nil(err)
+
+	index := ChartIndex{}
+	err = yaml.Unmarshal(content, &index)
+	assert.Nil(err)
+	assert.Equal(ChartIndex{
+		Files: []ChartIndexFile{
+			{
+				File: "kuard.yaml",
+				Resources: []ChartIndexResource{
+					{Kind: "Deployment", Name: "kuard"},
+					{Kind: "Service", Name: "kuard"},
+				},
+			},
+			{
+				File: "service.yaml",
+				Resources: []ChartIndexResource{
+					{Kind: "Service", Name: "kuard"},
+				},
+			},
+		},
+	}, index)
+
+	// Index is written only when requested
+	dir = t.TempDir()
+	err = HelmChartSerializer(map[string][]runtime.Object{"kuard": resources}, dir)
+	assert.Nil(err)
+	entries, err := os.ReadDir(dir)
+	assert.Nil(err)
+	assert.Len(entries, 1)
+}
+
+func TestHelmChartSerializerReleaseInfo(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	resources := makeTestResources()
+	resources[1].(*corev1.Service).Labels = map[string]string{"app.kubernetes.io/instance": "custom"}
+
+	err := HelmChartSerializerWithOptions(map[string][]runtime.Object{"kuard": resources}, dir, SerializerOptions{
+		ReleaseInfo: &ReleaseInfo{
+			ReleaseName:      "{{ .Release.Name }}",
+			ReleaseNamespace: "{{ .Release.Namespace }}",
+			ChartName:        "kuard",
+			ChartVersion:     "0.1.0+build",
+		},
+	})
+	assert.Nil(err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "kuard.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "app.kubernetes.io/managed-by: Helm")
+	assert.Contains(string(content), "helm.sh/chart: kuard-0.1.0_build")
+	assert.Contains(string(content), "app.kubernetes.io/instance: '{{ .Release.Name }}'")
+	assert.Contains(string(content), "meta.helm.sh/release-namespace: '{{ .Release.Namespace }}'")
+	// Labels set on resources are kept
+	assert.Contains(string(content), "app.kubernetes.io/instance: custom")
+
+	// Original resources are not modified
+	deploy := resources[0].(*appsv1.Deployment)
+	assert.Nil(deploy.Labels)
+	assert.Len(resources[1].(*corev1.Service).Labels, 1)
+}
+
+func TestHelmChartSerializerCustomMarshal(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	called := 0
+	upperKind := func(v any) ([]byte, error) {
+		called++
+		content, err := yaml.Marshal(v)
+		return []byte(strings.ReplaceAll(string(content), "kind:", "KIND:")), err
+	}
+
+	resources := makeTestResources()
+	err := HelmChartSerializerWithOptions(map[string][]runtime.Object{
+		"kuard": resources,
+	}, dir, SerializerOptions{Marshal: upperKind})
+	assert.Nil(err)
+	assert.Equal(len(resources), called)
+
+	content, err := os.ReadFile(filepath.Join(dir, "kuard.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "KIND: Deployment")
+	assert.Contains(string(content), "KIND: Service")
+	assert.NotContains(string(content), "\nkind:")
+}
+
+func TestHelmChartSerializerExtension(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	err := HelmChartSerializerWithOptions(map[string][]runtime.Object{"kuard": makeTestResources()}, dir, SerializerOptions{
+		Extension: "yml",
+		IndexFile: "_index.yml",
+	})
+	assert.Nil(err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "kuard.yml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "kind: Deployment")
+	_, err = os.Stat(filepath.Join(dir, "kuard.yaml"))
+	assert.True(errors.Is(err, os.ErrNotExist))
+
+	index, err := os.ReadFile(filepath.Join(dir, "_index.yml"))
+	assert.Nil(err)
+	assert.Contains(string(index), "file: kuard.yml")
+
+	for _, ext := range []string{".", "yaml.", ".y/ml"} {
+		err = HelmChartSerializerWithOptions(map[string][]runtime.Object{"kuard": makeTestResources()}, t.TempDir(), SerializerOptions{
+			Extension: ext,
+		})
+		assert.ErrorIs(err, ErrInvalidExtension)
+	}
+}
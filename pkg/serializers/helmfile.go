@@ -0,0 +1,143 @@
+package serializers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	eris "github.com/rotisserie/eris"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrInvalidHelmfileRelease = eris.New("InvalidHelmfileRelease")
+)
+
+// Release entry of `helmfile.yaml`, see `HelmfileSerializer`.
+//
+// See https://helmfile.readthedocs.io/en/latest/#configuration
+type HelmfileRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Path to a local chart, e.g. `./charts/app`, or chart in a repository,
+	// e.g. `bitnami/redis`. For the latter, set also `Repository`.
+	Chart string `json:"chart"`
+	// Version of the chart from the repository, e.g. `18.1.0`
+	Version string `json:"version,omitempty"`
+	// If set, the repository is added to the `repositories` section of helmfile.
+	// Releases may share the same repository.
+	Repository *HelmfileRepository `json:"-"`
+	// Each item is either a path to a values file (a string), e.g. `./values/app.yaml`,
+	// or the values themselves (e.g. a struct or map), which are inlined as YAML.
+	//
+	// NOTE: Structs are marshalled by their `json` tags.
+	Values []any `json:"values,omitempty"`
+	// Releases that must be installed before this one, as `name`,
+	// or `namespace/name` for releases in other namespaces.
+	Needs []string `json:"needs,omitempty"`
+	// If set to false, helmfile uninstalls the release. Default: `true`
+	Installed *bool `json:"installed,omitempty"`
+}
+
+// Chart repository of `helmfile.yaml`, e.g. `{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"}`
+type HelmfileRepository struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type helmfileSpec struct {
+	Repositories []HelmfileRepository `json:"repositories,omitempty"`
+	Releases     []HelmfileRelease    `json:"releases"`
+}
+
+// Write the releases to a `helmfile.yaml` at the given path, e.g. to orchestrate
+// the charts generated with `HelmChartSerializer`:
+//
+//	serializers.HelmfileSerializer([]serializers.HelmfileRelease{
+//		{Name: "db", Chart: "./charts/db"},
+//		{Name: "app", Chart: "./charts/app", Values: []any{appValues}, Needs: []string{"db"}},
+//	}, "./helmfile.yaml")
+//
+// Releases are written in the given order. Repositories are sorted by name.
+// Missing directories are created.
+func HelmfileSerializer(releases []HelmfileRelease, path string) error {
+	content, err := marshalHelmfile(releases)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return eris.Wrapf(err, "failed to create directory for file %q", path)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return eris.Wrapf(err, "failed to write helmfile to %q", path)
+	}
+	return nil
+}
+
+func marshalHelmfile(releases []HelmfileRelease) ([]byte, error) {
+	spec := helmfileSpec{Releases: []HelmfileRelease{}}
+	repos := map[string]HelmfileRepository{}
+	releaseIds := map[string]bool{}
+
+	for index, release := range releases {
+		if release.Name == "" || release.Chart == "" {
+			return nil, eris.Wrapf(ErrInvalidHelmfileRelease, "release at index %v must have a name and a chart", index)
+		}
+		id := release.Namespace + "/" + release.Name
+		if releaseIds[id] {
+			return nil, eris.Wrapf(ErrInvalidHelmfileRelease, "release %q is defined more than once", release.Name)
+		}
+		releaseIds[id] = true
+
+		if repo := release.Repository; repo != nil {
+			if other, ok := repos[repo.Name]; ok && other.URL != repo.URL {
+				return nil, eris.Wrapf(ErrInvalidHelmfileRelease, "repository %q of release %q has URL %q, but another release uses %q", repo.Name, release.Name, repo.URL, other.URL)
+			}
+			repos[repo.Name] = *repo
+		}
+
+		values, err := helmfileValues(release.Values)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to process values of release %q", release.Name)
+		}
+		release.Values = values
+		spec.Releases = append(spec.Releases, release)
+	}
+
+	for _, repo := range repos {
+		spec.Repositories = append(spec.Repositories, repo)
+	}
+	sort.Slice(spec.Repositories, func(i, j int) bool {
+		return spec.Repositories[i].Name < spec.Repositories[j].Name
+	})
+
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to marshal helmfile")
+	}
+	return append([]byte("# Autogenerated by Helpa HelmfileSerializer\n"), content...), nil
+}
+
+// Keep file paths as they are, and convert inline values to maps,
+// so that the keys are sorted.
+func helmfileValues(values []any) ([]any, error) {
+	out := []any{}
+	for index, val := range values {
+		if path, ok := val.(string); ok {
+			out = append(out, path)
+			continue
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, eris.Wrapf(ErrInvalidHelmfileRelease, "values at index %v cannot be marshalled: %v", index, err)
+		}
+		valuesMap := map[string]any{}
+		if err := json.Unmarshal(data, &valuesMap); err != nil {
+			return nil, eris.Wrapf(ErrInvalidHelmfileRelease, "values at index %v must be a string, or marshal to an object: %v", index, err)
+		}
+		out = append(out, valuesMap)
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package serializers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	eris "github.com/rotisserie/eris"
+	assert "github.com/stretchr/testify/assert"
+)
+
+type helmfileTestValues struct {
+	Replicas int `json:"replicas"`
+	Image    struct {
+		Tag string `json:"tag"`
+	} `json:"image"`
+}
+
+func TestHelmfileSerializer(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	appValues := helmfileTestValues{Replicas: 2}
+	appValues.Image.Tag = "v1"
+	installed := true
+
+	bitnami := &HelmfileRepository{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"}
+	releases := []HelmfileRelease{
+		{
+			Name:       "redis",
+			Namespace:  "data",
+			Chart:      "bitnami/redis",
+			Version:    "18.1.0",
+			Repository: bitnami,
+			Values:     []any{"./values/redis.yaml"},
+		},
+		{
+			Name:      "app",
+			Namespace: "apps",
+			Chart:     "./charts/app",
+			Values:    []any{appValues, map[string]any{"debug": true}},
+			Needs:     []string{"data/redis"},
+			Installed: &installed,
+		},
+	}
+
+	path := filepath.Join(dir, "nested", "helmfile.yaml")
+	err := HelmfileSerializer(releases, path)
+	assert.Nil(err)
+
+	content, err := os.ReadFile(path)
+	assert.Nil(err)
+	golden, err := os.ReadFile("testdata/helmfile.yaml")
+	assert.Nil(err)
+	assert.Equal(string(golden), string(content))
+}
+
+func TestHelmfileSerializerInvalid(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "helmfile.yaml")
+
+	err := HelmfileSerializer([]HelmfileRelease{{Name: "app"}}, path)
+	assert.True(eris.Is(err, ErrInvalidHelmfileRelease))
+
+	err = HelmfileSerializer([]HelmfileRelease{
+		{Name: "app", Chart: "./charts/app"},
+		{Name: "app", Chart: "./charts/other"},
+	}, path)
+	assert.True(eris.Is(err, ErrInvalidHelmfileRelease))
+
+	err = HelmfileSerializer([]HelmfileRelease{
+		{Name: "a", Chart: "repo/a", Repository: &HelmfileRepository{Name: "repo", URL: "https://a.example.com"}},
+		{Name: "b", Chart: "repo/b", Repository: &HelmfileRepository{Name: "repo", URL: "https://b.example.com"}},
+	}, path)
+	assert.True(eris.Is(err, ErrInvalidHelmfileRelease))
+
+	err = HelmfileSerializer([]HelmfileRelease{{Name: "app", Chart: "./charts/app", Values: []any{[]int{1}}}}, path)
+	assert.True(eris.Is(err, ErrInvalidHelmfileRelease))
+
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+}
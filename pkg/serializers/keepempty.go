@@ -0,0 +1,122 @@
+package serializers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Wrap the marshal function, so that non-nil empty slices and maps are kept
+// in the output, even if their fields are marked with `omitempty`.
+// See `SerializerOptions.KeepEmpty`.
+func keepEmptyMarshal(marshal func(any) ([]byte, error)) func(any) ([]byte, error) {
+	return func(v any) ([]byte, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var out any
+		err = json.Unmarshal(data, &out)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to unmarshal resource")
+		}
+
+		restoreEmptyCollections(reflect.ValueOf(v), out)
+		return marshal(out)
+	}
+}
+
+// Walk the Go value alongside its JSON representation, and put back the empty
+// slices and maps that were dropped by `omitempty`.
+func restoreEmptyCollections(val reflect.Value, out any) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	// Types that marshal themselves are output as they are
+	if hasCustomJSON(val.Type()) {
+		return
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		outMap, ok := out.(map[string]any)
+		if !ok {
+			return
+		}
+		restoreEmptyStructFields(val, outMap)
+	case reflect.Slice, reflect.Array:
+		outList, ok := out.([]any)
+		if !ok || len(outList) != val.Len() {
+			return
+		}
+		for i := 0; i < val.Len(); i++ {
+			restoreEmptyCollections(val.Index(i), outList[i])
+		}
+	case reflect.Map:
+		outMap, ok := out.(map[string]any)
+		if !ok || val.Type().Key().Kind() != reflect.String {
+			return
+		}
+		iter := val.MapRange()
+		for iter.Next() {
+			restoreEmptyCollections(iter.Value(), outMap[iter.Key().String()])
+		}
+	}
+}
+
+func restoreEmptyStructFields(val reflect.Value, outMap map[string]any) {
+	valType := val.Type()
+	for i := 0; i < valType.NumField(); i++ {
+		fieldType := valType.Field(i)
+		tag := fieldType.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		field := val.Field(i)
+
+		// Embedded structs without a name are inlined, same as `encoding/json` does
+		if fieldType.Anonymous && name == "" {
+			embedded := field
+			for embedded.Kind() == reflect.Ptr && !embedded.IsNil() {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				restoreEmptyStructFields(embedded, outMap)
+				continue
+			}
+		}
+		if !fieldType.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = fieldType.Name
+		}
+
+		isCollection := field.Kind() == reflect.Slice || field.Kind() == reflect.Map
+		if _, ok := outMap[name]; !ok && isCollection && !field.IsNil() && field.Len() == 0 {
+			if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8 {
+				// NOTE: Bytes are encoded as base64 string
+				outMap[name] = ""
+			} else if field.Kind() == reflect.Slice {
+				outMap[name] = []any{}
+			} else {
+				outMap[name] = map[string]any{}
+			}
+			continue
+		}
+
+		restoreEmptyCollections(field, outMap[name])
+	}
+}
+
+func hasCustomJSON(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)
+}
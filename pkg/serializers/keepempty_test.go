@@ -0,0 +1,58 @@
+package serializers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func makeEmptyFieldsDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "kuard", Labels: map[string]string{}},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					// Intentionally empty
+					ImagePullSecrets: []corev1.LocalObjectReference{},
+					Containers: []corev1.Container{
+						{Name: "kuard", Image: "kuard:1", Args: []string{}, Env: nil},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHelmChartSerializerKeepEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	resources := map[string][]runtime.Object{"kuard": {makeEmptyFieldsDeployment()}}
+
+	// By default, empty collections are dropped
+	dir := t.TempDir()
+	err := HelmChartSerializer(resources, dir)
+	assert.Nil(err)
+	content, err := os.ReadFile(filepath.Join(dir, "kuard.yaml"))
+	assert.Nil(err)
+	assert.NotContains(string(content), "imagePullSecrets")
+
+	dir = t.TempDir()
+	err = HelmChartSerializerWithOptions(resources, dir, SerializerOptions{KeepEmpty: true})
+	assert.Nil(err)
+	content, err = os.ReadFile(filepath.Join(dir, "kuard.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "imagePullSecrets: []")
+	assert.Contains(string(content), "labels: {}")
+	assert.Contains(string(content), "args: []")
+	// Nil collections are still dropped
+	assert.NotContains(string(content), "env:")
+	assert.NotContains(string(content), "creationTimestamp")
+	assert.Contains(string(content), "image: kuard:1")
+}
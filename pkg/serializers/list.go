@@ -0,0 +1,24 @@
+package serializers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Wrap the resources in a single `kind: List` object, for tools that expect
+// one object instead of multiple YAML documents, e.g. `kubectl apply -f`
+// with JSON input.
+//
+// NOTE: The items are serialized as they are, so they should have their
+// `apiVersion` and `kind` set.
+func WrapInList(resources []runtime.Object) runtime.Object {
+	list := &corev1.List{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+		Items:    make([]runtime.RawExtension, 0, len(resources)),
+	}
+	for _, resource := range resources {
+		list.Items = append(list.Items, runtime.RawExtension{Object: resource})
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package serializers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	yaml "sigs.k8s.io/yaml"
+)
+
+func TestWrapInList(t *testing.T) {
+	assert := assert.New(t)
+
+	list := WrapInList(makeTestResources())
+	assert.Equal("List", list.GetObjectKind().GroupVersionKind().Kind)
+
+	content, err := MarshalK8sResource(list)
+	assert.Nil(err)
+	assert.Contains(content, "kind: List")
+	assert.Contains(content, "kind: Deployment")
+	assert.NotContains(content, "creationTimestamp")
+}
+
+func TestHelmChartSerializerWrapInList(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	err := HelmChartSerializerWithOptions(map[string][]runtime.Object{"kuard": makeTestResources()}, dir, SerializerOptions{
+		WrapInList: true,
+	})
+	assert.Nil(err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "kuard.yaml"))
+	assert.Nil(err)
+	assert.NotContains(string(content), "---")
+
+	var list struct {
+		Kind  string           `json:"kind"`
+		Items []map[string]any `json:"items"`
+	}
+	err = yaml.Unmarshal(content, &list)
+	assert.Nil(err)
+	assert.Equal("List", list.Kind)
+	assert.Len(list.Items, 2)
+	assert.Equal("Service", list.Items[1]["kind"])
+	assert.True(strings.HasPrefix(string(content), "# Autogenerated by Helpa"))
+}
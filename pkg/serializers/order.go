@@ -0,0 +1,107 @@
+package serializers
+
+import (
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Order in which Helm installs the resources, by their kinds, so that e.g.
+// Namespaces and CRDs exist before the resources that use them.
+//
+// See https://github.com/helm/helm/blob/main/pkg/releaseutil/kind_sorter.go
+var InstallOrder = []string{
+	"PriorityClass",
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// Return the resources sorted in the order Helm installs them, see `InstallOrder`.
+// Kinds not in `InstallOrder` (e.g. custom resources) go last, sorted by kind.
+// Resources of the same kind keep their order. The given slice is not modified.
+//
+// NOTE: Resources with no `kind` set are looked up in the client-go scheme,
+// so typed objects like `&appsv1.Deployment{}` are sorted correctly too.
+func SortByApplyOrder(resources []runtime.Object) []runtime.Object {
+	rank := make(map[string]int, len(InstallOrder))
+	for index, kind := range InstallOrder {
+		rank[kind] = index
+	}
+	kindRank := func(kind string) int {
+		if index, ok := rank[kind]; ok {
+			return index
+		}
+		return len(InstallOrder)
+	}
+
+	type item struct {
+		resource runtime.Object
+		kind     string
+	}
+	items := make([]item, 0, len(resources))
+	for _, resource := range resources {
+		items = append(items, item{resource: resource, kind: resourceKind(resource)})
+	}
+
+	slices.SortStableFunc(items, func(a, b item) int {
+		if diff := kindRank(a.kind) - kindRank(b.kind); diff != 0 {
+			return diff
+		}
+		// Unknown kinds are grouped by kind
+		return strings.Compare(a.kind, b.kind)
+	})
+
+	sorted := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		sorted = append(sorted, item.resource)
+	}
+	return sorted
+}
+
+func resourceKind(resource runtime.Object) string {
+	if kind := resource.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	gvks, _, err := scheme.Scheme.ObjectKinds(resource)
+	if err != nil || len(gvks) == 0 {
+		return ""
+	}
+	return gvks[0].Kind
+}
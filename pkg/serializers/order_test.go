@@ -0,0 +1,39 @@
+package serializers
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSortByApplyOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	widget := &unstructured.Unstructured{}
+	widget.SetKind("Widget")
+	gadget := &unstructured.Unstructured{}
+	gadget.SetKind("Gadget")
+
+	resources := []runtime.Object{
+		widget,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "first"}},
+		gadget,
+		// NOTE: No `kind` set, found from the type
+		&corev1.Namespace{},
+		&corev1.Service{TypeMeta: metav1.TypeMeta{Kind: "Service"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "second"}},
+	}
+
+	sorted := SortByApplyOrder(resources)
+	assert.Equal([]runtime.Object{
+		resources[3], resources[4], resources[1], resources[5], resources[2], resources[0],
+	}, sorted)
+
+	// Original order is kept
+	assert.Same(widget, resources[0])
+}
@@ -0,0 +1,44 @@
+package serializers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Move the `stringData` of the Secret into `data`, so the generated files
+// contain only base64-encoded values, the same as K8s stores them. Same as
+// in K8s, `stringData` takes precedence over `data` for the same key.
+//
+// NOTE: The Secret is modified in place.
+func NormalizeSecret(secret *corev1.Secret) {
+	if len(secret.StringData) == 0 {
+		secret.StringData = nil
+		return
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte, len(secret.StringData))
+	}
+	for key, val := range secret.StringData {
+		secret.Data[key] = []byte(val)
+	}
+	secret.StringData = nil
+}
+
+// Return copies of the Secrets with `NormalizeSecret` applied. Other resources
+// are returned as they are.
+func normalizeSecrets(resourceGroups map[string][]runtime.Object) map[string][]runtime.Object {
+	out := make(map[string][]runtime.Object, len(resourceGroups))
+	for groupName, resources := range resourceGroups {
+		outResources := make([]runtime.Object, 0, len(resources))
+		for _, resource := range resources {
+			if secret, ok := resource.(*corev1.Secret); ok {
+				secret = secret.DeepCopy()
+				NormalizeSecret(secret)
+				resource = secret
+			}
+			outResources = append(outResources, resource)
+		}
+		out[groupName] = outResources
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package serializers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNormalizeSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	secret := &corev1.Secret{
+		Data:       map[string][]byte{"user": []byte("admin"), "password": []byte("old")},
+		StringData: map[string]string{"password": "s3cr3t"},
+	}
+	NormalizeSecret(secret)
+	assert.Nil(secret.StringData)
+	assert.Equal(map[string][]byte{"user": []byte("admin"), "password": []byte("s3cr3t")}, secret.Data)
+}
+
+func TestHelmChartSerializerNormalizeSecrets(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "db"},
+		StringData: map[string]string{"password": "s3cr3t"},
+	}
+	err := HelmChartSerializerWithOptions(map[string][]runtime.Object{"db": {secret}}, dir, SerializerOptions{
+		NormalizeSecrets: true,
+	})
+	assert.Nil(err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "db.yaml"))
+	assert.Nil(err)
+	assert.Contains(string(content), "data:\n  password: czNjcjN0")
+	assert.NotContains(string(content), "stringData")
+
+	// Original resources are not modified
+	assert.Equal(map[string]string{"password": "s3cr3t"}, secret.StringData)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"reflect"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// Copy the fields of struct `src` to struct `dst`, if they have the same name
+// and the value of `src` is assignable to `dst`. Other fields are skipped.
+// Useful for porting values between similar Input structs, e.g.:
+//
+//	helmInput := helm.Input{}
+//	err := utils.CopyMatchingFields(&helmInput, kuardInput)
+//
+// NOTE: Values are copied shallowly, so pointers, slices and maps are shared.
+func CopyMatchingFields(dst any, src any) error {
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() {
+		return eris.Wrapf(ErrNotPointer, "expected pointer to struct, got %T", dst)
+	}
+	dstVal = dstVal.Elem()
+	if dstVal.Kind() != reflect.Struct {
+		return eris.Wrapf(ErrNotStruct, "expected pointer to struct, got %T", dst)
+	}
+
+	srcVal := reflect.ValueOf(src)
+	for srcVal.Kind() == reflect.Ptr {
+		if srcVal.IsNil() {
+			return nil
+		}
+		srcVal = srcVal.Elem()
+	}
+	if srcVal.Kind() != reflect.Struct {
+		return eris.Wrapf(ErrNotStruct, "expected struct, got %T", src)
+	}
+
+	dstType := dstVal.Type()
+	for i := 0; i < dstType.NumField(); i++ {
+		fieldType := dstType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		srcFieldType, ok := srcVal.Type().FieldByName(fieldType.Name)
+		if !ok || !srcFieldType.IsExported() || !srcFieldType.Type.AssignableTo(fieldType.Type) {
+			continue
+		}
+		// NOTE: Promoted fields behind nil embedded pointers cannot be read
+		srcField, err := srcVal.FieldByIndexErr(srcFieldType.Index)
+		if err != nil {
+			continue
+		}
+		dstVal.Field(i).Set(srcField)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type copyKuardInput struct {
+	Name      string
+	Namespace string
+	Replicas  *int32
+	Container corev1.Container
+	Port      int32
+	Labels    map[string]string
+}
+
+type copyHelmInput struct {
+	Name      string
+	Namespace string
+	Replicas  *int32
+	Container corev1.Container
+	Port      string
+	Enabled   bool
+	labels    map[string]string
+}
+
+func TestCopyMatchingFields(t *testing.T) {
+	assert := assert.New(t)
+
+	kuard := copyKuardInput{
+		Name:      "kuard",
+		Namespace: "default",
+		Replicas:  PointerOf(int32(2)),
+		Container: corev1.Container{Image: "gcr.io/kuar-demo/kuard-amd64:blue"},
+		Port:      8080,
+		Labels:    map[string]string{"app": "kuard"},
+	}
+	helm := copyHelmInput{Port: "http", Enabled: true}
+
+	err := CopyMatchingFields(&helm, kuard)
+	assert.Nil(err)
+	assert.Equal("kuard", helm.Name)
+	assert.Equal("default", helm.Namespace)
+	assert.Equal(int32(2), *helm.Replicas)
+	assert.Equal("gcr.io/kuar-demo/kuard-amd64:blue", helm.Container.Image)
+	// Fields with different types, or missing in source, are left untouched
+	assert.Equal("http", helm.Port)
+	assert.Equal(true, helm.Enabled)
+	assert.Nil(helm.labels)
+
+	// Pointer to source works too
+	other := copyKuardInput{}
+	err = CopyMatchingFields(&other, &helm)
+	assert.Nil(err)
+	assert.Equal("kuard", other.Name)
+	assert.Equal(int32(0), other.Port)
+
+	err = CopyMatchingFields(helm, kuard)
+	assert.ErrorIs(err, ErrNotPointer)
+	err = CopyMatchingFields(&helm, "kuard")
+	assert.ErrorIs(err, ErrNotStruct)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"reflect"
+)
+
+// Deprecated field that is set, see `FindDeprecatedFields`
+type DeprecatedField struct {
+	// Path to the field, e.g. `KuardInput.Image`
+	Path string
+	// Message from the `deprecated` struct tag, e.g. `use Container.Image instead`
+	Message string
+}
+
+// Find the fields marked with the `deprecated` struct tag (see `TagDeprecated`)
+// that have non-zero values. Nested structs and pointers to structs are searched too.
+func FindDeprecatedFields(v any) []DeprecatedField {
+	val := unwrapValue(reflect.ValueOf(v))
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+	return findDeprecatedFields(val, "", map[reflect.Type]bool{})
+}
+
+func findDeprecatedFields(val reflect.Value, path string, visiting map[reflect.Type]bool) []DeprecatedField {
+	valType := val.Type()
+	// NOTE: Stop at recursive types, e.g. linked lists
+	if visiting[valType] {
+		return nil
+	}
+	visiting[valType] = true
+	defer delete(visiting, valType)
+
+	found := []DeprecatedField{}
+	for i := 0; i < valType.NumField(); i++ {
+		fieldType := valType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		field := val.Field(i)
+		fieldPath := joinFieldPath(path, fieldType.Name)
+
+		if message, ok := tagOptionValue(fieldType, TagDeprecated); ok {
+			if !field.IsZero() {
+				found = append(found, DeprecatedField{Path: fieldPath, Message: message})
+			}
+			continue
+		}
+
+		if isRecursible(field) {
+			found = append(found, findDeprecatedFields(unwrapValue(field), fieldPath, visiting)...)
+		}
+	}
+	return found
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+type deprecatedContainer struct {
+	Image    string
+	ImageTag string `helpa:"deprecated=use Image instead"`
+}
+
+type deprecatedInput struct {
+	Name      string
+	Replicas  int32  `helpa:"deprecated=use Scale.Replicas instead"`
+	Namespace string `helpa:"keepzero,deprecated=set the namespace on the release"`
+	Container *deprecatedContainer
+}
+
+func TestFindDeprecatedFields(t *testing.T) {
+	assert := assert.New(t)
+
+	// Zero values are not reported
+	assert.Empty(FindDeprecatedFields(deprecatedInput{Name: "kuard"}))
+	assert.Empty(FindDeprecatedFields(&deprecatedInput{Container: &deprecatedContainer{Image: "kuard"}}))
+
+	found := FindDeprecatedFields(&deprecatedInput{
+		Replicas:  2,
+		Namespace: "default",
+		Container: &deprecatedContainer{ImageTag: "blue"},
+	})
+	assert.Equal([]DeprecatedField{
+		{Path: "Replicas", Message: "use Scale.Replicas instead"},
+		{Path: "Namespace", Message: "set the namespace on the release"},
+		{Path: "Container.ImageTag", Message: "use Image instead"},
+	}, found)
+
+	assert.Nil(FindDeprecatedFields("kuard"))
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrDuplicateFlag = eris.New("flag is already defined")
+)
+
+// Register a command-line flag for each field of struct `v`, so the input
+// can be overridden from the command line, e.g. `--kuard-input-name=kuard-prod`:
+//
+//	input := ChartDefaults()
+//	err := utils.BindFlags(flag.CommandLine, "", &input)
+//	flag.Parse()
+//
+// Flag names are the kebab-cased field names, prefixed by `prefix` and the names
+// of the parent structs, e.g. `KuardInput.Container.Image` becomes
+// `--kuard-input-container-image`. The help text is taken from the `desc` struct
+// tag, and defaults are the current values of the fields.
+//
+// Supported are strings, bools, numbers, `time.Duration`, types that parse
+// themselves from text (e.g. `resource.Quantity`), slices of these (given as
+// comma-separated values), and pointers to all of the above. Nested structs
+// are recursed into. Other fields (e.g. maps) are skipped.
+//
+// Values are parsed the same way as the `default` struct tags (see `ApplyTagDefaults`).
+// Pointer fields are set only if the flag was given, so they can be told apart
+// from unset values.
+func BindFlags(fs *flag.FlagSet, prefix string, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return eris.Wrapf(ErrNotPointer, "expected pointer to struct, got %T", v)
+	}
+	val = val.Elem()
+	if val.Kind() != reflect.Struct {
+		return eris.Wrapf(ErrNotStruct, "expected pointer to struct, got %T", v)
+	}
+
+	return bindStructFlags(fs, prefix, val, map[reflect.Type]bool{})
+}
+
+func bindStructFlags(fs *flag.FlagSet, prefix string, val reflect.Value, visiting map[reflect.Type]bool) error {
+	valType := val.Type()
+	// NOTE: Recursive types would generate flags forever
+	if visiting[valType] {
+		return nil
+	}
+	visiting[valType] = true
+	defer delete(visiting, valType)
+
+	for i := 0; i < valType.NumField(); i++ {
+		fieldType := valType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		field := val.Field(i)
+		name := joinFlagName(prefix, KebabCase(fieldType.Name))
+		// Embedded structs are inlined
+		if fieldType.Anonymous && isRecursible(field) {
+			name = prefix
+		}
+
+		if isFlagType(field.Type()) {
+			if fs.Lookup(name) != nil {
+				return eris.Wrapf(ErrDuplicateFlag, "flag %q of field %q", name, fieldType.Name)
+			}
+			fs.Var(&fieldFlag{value: field}, name, fieldType.Tag.Get(DescTagKey))
+			continue
+		}
+
+		if isRecursible(field) {
+			err := bindStructFlags(fs, name, unwrapValue(field), visiting)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinFlagName(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "-" + name
+}
+
+// Whether the value of given type can be parsed from a single flag
+func isFlagType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && isFlagType(t.Elem()) && !isStructLike(t.Elem())
+	}
+	return false
+}
+
+// NOTE: Slices of structs with custom JSON (e.g. `[]metav1.Time`) would need
+// commas in their values, so we don't support them.
+func isStructLike(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// Flag that sets a struct field, see `BindFlags`
+type fieldFlag struct {
+	value reflect.Value
+}
+
+func (f *fieldFlag) String() string {
+	// NOTE: The flag package calls `String()` on the zero value to detect default values
+	if f == nil || !f.value.IsValid() {
+		return ""
+	}
+
+	val := f.value
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return ""
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Slice {
+		items := []string{}
+		for i := 0; i < val.Len(); i++ {
+			items = append(items, fmt.Sprint(val.Index(i).Interface()))
+		}
+		return strings.Join(items, ",")
+	}
+	if stringer, ok := val.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	if val.CanAddr() {
+		if stringer, ok := val.Addr().Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+	}
+	return fmt.Sprint(val.Interface())
+}
+
+func (f *fieldFlag) Set(raw string) error {
+	parsed, err := parseTagDefault(f.value.Type(), raw)
+	if err != nil {
+		return err
+	}
+	f.value.Set(parsed)
+	return nil
+}
+
+// Allow bool flags to be given without value, e.g. `--enabled`
+func (f *fieldFlag) IsBoolFlag() bool {
+	t := f.value.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// Convert a Go field name to kebab-case, e.g. `KuardInput` to `kuard-input`,
+// or `TLSSecretName` to `tls-secret-name`.
+func KebabCase(name string) string {
+	runes := []rune(name)
+	var out strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := !unicode.IsUpper(runes[i-1])
+			// E.g. `TLSSecret` - the last uppercase letter starts the next word
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				out.WriteRune('-')
+			}
+		}
+		out.WriteRune(unicode.ToLower(r))
+	}
+	return out.String()
+}
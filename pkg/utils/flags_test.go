@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Same shape as the Input of the helmchart example
+type flagsCertbotInput struct {
+	CertbotNamespace    string
+	CertbotCronSchedule string `desc:"Cron schedule of the cert renewal"`
+	TlsSecretNamespaces []string
+	RunImmediately      bool
+	Timeout             time.Duration
+}
+
+type flagsKuardInput struct {
+	Name      string
+	Container corev1.Container
+	Port      corev1.ContainerPort
+	Memory    *resource.Quantity
+}
+
+type flagsChartInput struct {
+	CertbotInput   flagsCertbotInput
+	CertbotEnabled *bool
+	KuardInput     flagsKuardInput
+	Replicas       *int32
+	Labels         map[string]string
+}
+
+func TestBindFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	input := flagsChartInput{
+		CertbotInput: flagsCertbotInput{
+			CertbotNamespace:    "certbot",
+			CertbotCronSchedule: "20 3 * * */6",
+		},
+		KuardInput: flagsKuardInput{
+			Name:      "kuard",
+			Container: corev1.Container{Image: "gcr.io/kuar-demo/kuard-amd64:blue"},
+			Port:      corev1.ContainerPort{ContainerPort: 8080},
+		},
+	}
+
+	fs := flag.NewFlagSet("chart", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	err := BindFlags(fs, "", &input)
+	assert.Nil(err)
+
+	cronFlag := fs.Lookup("certbot-input-certbot-cron-schedule")
+	assert.NotNil(cronFlag)
+	assert.Equal("Cron schedule of the cert renewal", cronFlag.Usage)
+	assert.Equal("20 3 * * */6", cronFlag.DefValue)
+	assert.Nil(fs.Lookup("labels"))
+
+	err = fs.Parse([]string{
+		"--kuard-input-port-container-port=9090",
+		"--kuard-input-container-image=kuard:green",
+		"--kuard-input-container-image-pull-policy=Always",
+		"--kuard-input-memory=500Mi",
+		"--certbot-input-tls-secret-namespaces=default,kuard",
+		"--certbot-input-run-immediately",
+		"--certbot-input-timeout=90s",
+		"--certbot-enabled=false",
+	})
+	assert.Nil(err)
+
+	// Given flags are set
+	assert.Equal(int32(9090), input.KuardInput.Port.ContainerPort)
+	assert.Equal("kuard:green", input.KuardInput.Container.Image)
+	assert.Equal(corev1.PullAlways, input.KuardInput.Container.ImagePullPolicy)
+	assert.Equal(resource.MustParse("500Mi"), *input.KuardInput.Memory)
+	assert.Equal([]string{"default", "kuard"}, input.CertbotInput.TlsSecretNamespaces)
+	assert.Equal(true, input.CertbotInput.RunImmediately)
+	assert.Equal(90*time.Second, input.CertbotInput.Timeout)
+	assert.Equal(false, *input.CertbotEnabled)
+
+	// Others keep their values, and pointers stay nil
+	assert.Equal("kuard", input.KuardInput.Name)
+	assert.Equal("certbot", input.CertbotInput.CertbotNamespace)
+	assert.Nil(input.Replicas)
+}
+
+func TestBindFlagsPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	input := flagsCertbotInput{}
+	fs := flag.NewFlagSet("chart", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	err := BindFlags(fs, "certbot", &input)
+	assert.Nil(err)
+
+	err = fs.Parse([]string{"--certbot-certbot-namespace=infra"})
+	assert.Nil(err)
+	assert.Equal("infra", input.CertbotNamespace)
+
+	// Same flags cannot be registered twice
+	err = BindFlags(fs, "certbot", &input)
+	assert.ErrorIs(err, ErrDuplicateFlag)
+
+	err = BindFlags(fs, "", input)
+	assert.ErrorIs(err, ErrNotPointer)
+
+	err = fs.Parse([]string{"--certbot-timeout=soon"})
+	assert.NotNil(err)
+}
+
+func TestKebabCase(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("kuard-input", KebabCase("KuardInput"))
+	assert.Equal("tls-secret-name", KebabCase("TLSSecretName"))
+	assert.Equal("http-port", KebabCase("HTTPPort"))
+	assert.Equal("pod-id", KebabCase("PodID"))
+	assert.Equal("name", KebabCase("Name"))
+}
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Convert a struct to `map[string]any`, so it can be used in templates with
+// functions that work with maps, like `toYaml`, `merge` or `dict`. E.g. in `Setup`:
+//
+//	func(input Input) (Context, error) {
+//		container, err := utils.StructToMap(input.Container, "")
+//		return Context{Container: container}, err
+//	}
+//
+// Keys are taken from the `tagName` struct tags, `json` by default, honoring
+// `omitempty`, `-`, and inlined embedded structs. Fields without the tag use
+// the field name.
+//
+// Nested structs and maps are converted to `map[string]any`, and slices to `[]any`.
+// Types that marshal themselves to JSON (e.g. `resource.Quantity` or `metav1.Time`)
+// are converted to their JSON representation, e.g. `"500Mi"`.
+//
+// Returns an empty map if `v` is nil.
+func StructToMap(v any, tagName string) (map[string]any, error) {
+	return StructToMapWithOptions(v, StructToMapOptions{TagName: tagName})
+}
+
+type StructToMapOptions struct {
+	// Struct tag from which the keys are taken. Default: `json`
+	TagName string
+	// Key for fields without the tag. By default, the field name is used as is.
+	//
+	// E.g. use `LowerCamelCase` for Helm-style keys.
+	FieldName func(name string) string
+}
+
+// Same as `StructToMap`, but configurable with `StructToMapOptions`.
+func StructToMapWithOptions(v any, opts StructToMapOptions) (map[string]any, error) {
+	if opts.TagName == "" {
+		opts.TagName = "json"
+	}
+	if opts.FieldName == nil {
+		opts.FieldName = func(name string) string { return name }
+	}
+
+	val := unwrapValue(reflect.ValueOf(v))
+	if !val.IsValid() {
+		return map[string]any{}, nil
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, eris.Wrapf(ErrNotStruct, "cannot convert %s to map", val.Type())
+	}
+
+	out := map[string]any{}
+	err := structToMap(val, opts, out, "")
+	return out, err
+}
+
+func structToMap(val reflect.Value, opts StructToMapOptions, out map[string]any, path string) error {
+	valType := val.Type()
+	for i := 0; i < valType.NumField(); i++ {
+		fieldType := valType.Field(i)
+		field := val.Field(i)
+		fieldPath := joinFieldPath(path, fieldType.Name)
+
+		tag := fieldType.Tag.Get(opts.TagName)
+		if tag == "-" {
+			continue
+		}
+		name, tagOpts, _ := strings.Cut(tag, ",")
+		optList := strings.Split(tagOpts, ",")
+
+		// NOTE: Unlike `encoding/json`, we skip also the unexported embedded structs,
+		// because the values of their fields cannot be read via reflection.
+		if !fieldType.IsExported() {
+			continue
+		}
+		// Functions and channels have no data representation
+		if kind := fieldType.Type.Kind(); kind == reflect.Func || kind == reflect.Chan {
+			continue
+		}
+
+		// Embedded structs without a name are inlined, same as `encoding/json` does
+		isInline := fieldType.Anonymous && (name == "" || hasOption(optList, "inline"))
+		if isInline {
+			embedded := unwrapValue(field)
+			if embedded.IsValid() && embedded.Kind() == reflect.Struct && !hasCustomJSON(embedded.Type()) {
+				err := structToMap(embedded, opts, out, fieldPath)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if hasOption(optList, "omitempty") && isEmptyValue(field) {
+			continue
+		}
+		if name == "" {
+			name = opts.FieldName(fieldType.Name)
+		}
+
+		converted, err := valueToAny(field, opts, fieldPath)
+		if err != nil {
+			return err
+		}
+		out[name] = converted
+	}
+	return nil
+}
+
+func valueToAny(val reflect.Value, opts StructToMapOptions, path string) (any, error) {
+	val = unwrapValue(val)
+	if !val.IsValid() {
+		return nil, nil
+	}
+
+	if hasCustomJSON(val.Type()) {
+		data, err := json.Marshal(val.Interface())
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to marshal field %q", path)
+		}
+		var out any
+		err = json.Unmarshal(data, &out)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to unmarshal field %q", path)
+		}
+		return out, nil
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		out := map[string]any{}
+		err := structToMap(val, opts, out, path)
+		return out, err
+	case reflect.Map:
+		if val.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]any, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			item, err := valueToAny(iter.Value(), opts, fmt.Sprintf("%s[%s]", path, key))
+			if err != nil {
+				return nil, err
+			}
+			out[key] = item
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return nil, nil
+		}
+		// NOTE: Keep byte slices as they are, so they don't turn into a list of numbers.
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return val.Interface(), nil
+		}
+		out := make([]any, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			item, err := valueToAny(val.Index(i), opts, fmt.Sprintf("%s[%v]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = item
+		}
+		return out, nil
+	}
+
+	return val.Interface(), nil
+}
+
+func hasOption(opts []string, option string) bool {
+	for _, opt := range opts {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCustomJSON(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)
+}
+
+// Same rules as `omitempty` in `encoding/json`
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return v.IsZero()
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Convert a Go field name to the lowerCamel convention used by Helm values,
+// e.g. `ReplicaCount` to `replicaCount`, or `HTTPPort` to `httpPort`.
+func LowerCamelCase(name string) string {
+	runes := []rune(name)
+
+	// Find the leading run of uppercase letters
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+
+	switch {
+	case upper == 0:
+		return name
+	case upper == 1 || upper == len(runes):
+		// E.g. `Name` or `URL`
+	case unicode.IsLetter(runes[upper]):
+		// E.g. `HTTPPort` - the last uppercase letter starts the next word
+		upper--
+	}
+
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// Dereference an optional pointer, falling back to `def` if it's nil. Useful
+// in `Setup` functions for optional inputs, e.g.:
+//
+//	replicas := utils.ValueOr(input.Replicas, 1)
+func ValueOr[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
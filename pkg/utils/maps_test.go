@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestStructToMap(t *testing.T) {
+	assert := assert.New(t)
+
+	container := corev1.Container{
+		Name:  "kuard",
+		Image: "gcr.io/kuar-demo/kuard-amd64:1",
+		Ports: []corev1.ContainerPort{{ContainerPort: 8080, Protocol: "TCP"}},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("500Mi")},
+		},
+		Env: []corev1.EnvVar{{Name: "DEBUG", Value: "true"}},
+	}
+
+	result, err := StructToMap(container, "")
+	assert.Nil(err)
+	assert.Equal("kuard", result["name"])
+	assert.Equal([]any{map[string]any{"containerPort": int32(8080), "protocol": corev1.Protocol("TCP")}}, result["ports"])
+	assert.Equal(map[string]any{"limits": map[string]any{"memory": "500Mi"}}, result["resources"])
+	assert.NotContains(result, "command")
+
+	// Round trip
+	data, err := json.Marshal(result)
+	assert.Nil(err)
+	roundTripped := corev1.Container{}
+	err = json.Unmarshal(data, &roundTripped)
+	assert.Nil(err)
+	assert.Equal(container.Name, roundTripped.Name)
+	assert.Equal(container.Ports, roundTripped.Ports)
+	assert.Equal(container.Env, roundTripped.Env)
+	assert.True(container.Resources.Limits.Memory().Equal(*roundTripped.Resources.Limits.Memory()))
+
+	// Pointers are dereferenced
+	result, err = StructToMap(&container, "")
+	assert.Nil(err)
+	assert.Equal("kuard", result["name"])
+}
+
+func TestStructToMapTags(t *testing.T) {
+	assert := assert.New(t)
+
+	type Base struct {
+		Kind string `yaml:"kind"`
+	}
+	type Input struct {
+		Base
+		Name    string `yaml:"name"`
+		Skipped string `yaml:"-"`
+		Empty   string `yaml:"empty,omitempty"`
+		NoTag   int
+		Labels  map[string]string `yaml:"labels"`
+		Parent  *Input            `yaml:"parent"`
+	}
+
+	result, err := StructToMap(Input{
+		Base:   Base{Kind: "App"},
+		Name:   "kuard",
+		NoTag:  2,
+		Parent: &Input{Name: "parent"},
+	}, "yaml")
+	assert.Nil(err)
+	assert.Equal(map[string]any{
+		"kind":   "App",
+		"name":   "kuard",
+		"NoTag":  2,
+		"labels": nil,
+		"parent": map[string]any{
+			"kind":   "",
+			"name":   "parent",
+			"NoTag":  0,
+			"labels": nil,
+			"parent": nil,
+		},
+	}, result)
+}
+
+func TestStructToMapNil(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := StructToMap(nil, "")
+	assert.Nil(err)
+	assert.Equal(map[string]any{}, result)
+
+	var container *corev1.Container
+	result, err = StructToMap(container, "")
+	assert.Nil(err)
+	assert.Equal(map[string]any{}, result)
+
+	_, err = StructToMap("kuard", "")
+	assert.ErrorIs(err, ErrNotStruct)
+}
+
+func TestLowerCamelCase(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("replicaCount", LowerCamelCase("ReplicaCount"))
+	assert.Equal("name", LowerCamelCase("Name"))
+	assert.Equal("url", LowerCamelCase("URL"))
+	assert.Equal("httpPort", LowerCamelCase("HTTPPort"))
+	assert.Equal("tls2", LowerCamelCase("TLS2"))
+	assert.Equal("alreadyLower", LowerCamelCase("alreadyLower"))
+	assert.Equal("", LowerCamelCase(""))
+
+	result, err := StructToMapWithOptions(struct {
+		ReplicaCount int
+		ImageTag     string `json:"image_tag"`
+		OnRender     func()
+	}{ReplicaCount: 2, ImageTag: "1.0"}, StructToMapOptions{FieldName: LowerCamelCase})
+	assert.Nil(err)
+	assert.Equal(map[string]any{"replicaCount": 2, "image_tag": "1.0"}, result)
+}
+
+func TestValueOr(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(int32(1), ValueOr(nil, int32(1)))
+	assert.Equal(int32(3), ValueOr(PointerOf(int32(3)), 1))
+	assert.Equal(false, ValueOr(PointerOf(false), true))
+	assert.Equal("default", ValueOr[string](nil, "default"))
+}
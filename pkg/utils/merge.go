@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrInvalidSliceKey = eris.New("slice items cannot be merged by the given key")
+)
+
+// How values from `src` are applied to the values in `dst`
+type MergeStrategy int
+
+const (
+	// Non-zero values from `src` overwrite the values in `dst`.
+	MergeOverride MergeStrategy = iota
+	// Values from `src` are applied only where `dst` is zero-valued.
+	MergeFillEmpty
+)
+
+// How slices from `src` are merged into non-empty slices in `dst`
+type SliceStrategy int
+
+const (
+	// With `MergeOverride`, the slice from `src` replaces the slice in `dst`.
+	// With `MergeFillEmpty`, the slice in `dst` is kept.
+	SliceReplace SliceStrategy = iota
+	// Items from `src` are appended to the slice in `dst`.
+	SliceAppend
+	// Items are matched by the struct field `MergeOptions.SliceKey`. Matching
+	// items are merged, the rest is appended.
+	//
+	// NOTE: Slices whose items don't have the key field (e.g. `[]string`)
+	// are merged as with `SliceReplace`.
+	SliceMergeByKey
+)
+
+// How maps from `src` are merged into non-nil maps in `dst`
+type MapStrategy int
+
+const (
+	// Entries from `src` are set as they are. With `MergeFillEmpty`, only
+	// the missing keys are set.
+	MapShallow MapStrategy = iota
+	// Entries present in both maps are merged recursively.
+	MapDeep
+)
+
+type MergeOptions struct {
+	Strategy MergeStrategy
+	Slices   SliceStrategy
+	// Name of the struct field by which slice items are matched with
+	// `SliceMergeByKey`, e.g. `Name` for `[]corev1.Container`.
+	SliceKey string
+	Maps     MapStrategy
+}
+
+// Merge struct `src` into struct `dst`, e.g. to apply environment-specific
+// overrides onto a base config:
+//
+//	input := BaseChartInput()
+//	err := utils.Merge(&input, ProdChartInput(), utils.MergeOptions{
+//		Slices:   utils.SliceMergeByKey,
+//		SliceKey: "Name",
+//	})
+//
+// Both must be of the same type. Structs and pointers are recursed into, except
+// for leaf types (see `RegisterLeafType`), which are treated as single values.
+// Slices and maps are merged according to `opts`. Zero values in `src` are
+// considered unset, and never overwrite values in `dst`. Same as with `ApplyDefaults`,
+// use pointers (e.g. `*bool`) to override with zero values.
+//
+// Values copied from `src` are deep copies, so `dst` and `src` do not share
+// any state afterwards. Recursive pointers are supported.
+//
+// NOTE: Unlike `ApplyDefaults`, `Merge` ignores struct tags.
+func Merge(dst any, src any, opts MergeOptions) error {
+	if dst == nil {
+		return nil
+	}
+
+	val := reflect.ValueOf(dst)
+	if val.Kind() == reflect.Ptr && val.Elem().Kind() == reflect.Struct {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return eris.Wrapf(ErrNotStruct, "expected pointer to struct, got %T", dst)
+	}
+	if !val.CanSet() {
+		return eris.Wrapf(ErrNotPointer, "expected pointer to struct, got %s", val.Type())
+	}
+
+	srcVal := unwrapValue(reflect.ValueOf(src))
+	if !srcVal.IsValid() {
+		return nil
+	}
+	if srcVal.Type() != val.Type() {
+		return eris.Wrapf(ErrTypeMismatch, "cannot merge %s into %s", srcVal.Type(), val.Type())
+	}
+
+	m := merger{opts: opts, visited: map[[2]uintptr]bool{}, memo: map[pointerKey]reflect.Value{}}
+	return m.mergeStruct(val, srcVal, "")
+}
+
+type merger struct {
+	opts MergeOptions
+	// Pairs of `dst` and `src` pointers that were already merged,
+	// so we don't loop forever on recursive pointers.
+	visited map[[2]uintptr]bool
+	memo    map[pointerKey]reflect.Value
+}
+
+func (m *merger) copy(v reflect.Value) reflect.Value {
+	return deepCopyMemo(v, m.memo)
+}
+
+func (m *merger) mergeStruct(dst reflect.Value, src reflect.Value, path string) error {
+	dstType := dst.Type()
+	for i := 0; i < dstType.NumField(); i++ {
+		fieldType := dstType.Field(i)
+		// NOTE: Unexported fields cannot be set via reflection, so we skip them.
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		err := m.mergeValue(dst.Field(i), src.Field(i), joinFieldPath(path, fieldType.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeValue(dst reflect.Value, src reflect.Value, path string) error {
+	// Zero values in `src` are considered unset
+	if src.IsZero() {
+		return nil
+	}
+	// Anything goes if `dst` is unset
+	if dst.IsZero() {
+		dst.Set(m.copy(src))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Struct:
+		if isLeafType(dst.Type()) {
+			break
+		}
+		return m.mergeStruct(dst, src, path)
+	case reflect.Ptr:
+		// NOTE: Pointers to non-structs are tri-state, and the value they point
+		// to is set even if it's zero. So these are treated as single values.
+		if !isRecursible(dst) {
+			break
+		}
+		key := [2]uintptr{dst.Pointer(), src.Pointer()}
+		if m.visited[key] {
+			return nil
+		}
+		m.visited[key] = true
+		return m.mergeValue(dst.Elem(), src.Elem(), path)
+	case reflect.Map:
+		return m.mergeMap(dst, src, path)
+	case reflect.Slice:
+		return m.mergeSlice(dst, src, path)
+	}
+
+	// Scalars, interfaces, pointers to non-structs, and leaf types
+	if m.opts.Strategy == MergeOverride {
+		dst.Set(m.copy(src))
+	}
+	return nil
+}
+
+func (m *merger) mergeMap(dst reflect.Value, src reflect.Value, path string) error {
+	iter := src.MapRange()
+	for iter.Next() {
+		key := iter.Key()
+		dstItem := dst.MapIndex(key)
+
+		if !dstItem.IsValid() {
+			dst.SetMapIndex(key, m.copy(iter.Value()))
+			continue
+		}
+
+		if m.opts.Maps == MapDeep {
+			// NOTE: Map values are not addressable, so we merge into a copy
+			// and set it back.
+			item := reflect.New(dstItem.Type()).Elem()
+			item.Set(dstItem)
+			err := m.mergeValue(item, iter.Value(), fmt.Sprintf("%s[%v]", path, key.Interface()))
+			if err != nil {
+				return err
+			}
+			dst.SetMapIndex(key, item)
+			continue
+		}
+
+		if m.opts.Strategy == MergeOverride {
+			dst.SetMapIndex(key, m.copy(iter.Value()))
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeSlice(dst reflect.Value, src reflect.Value, path string) error {
+	switch m.opts.Slices {
+	case SliceAppend:
+		dst.Set(reflect.AppendSlice(dst, m.copy(src)))
+		return nil
+	case SliceMergeByKey:
+		if m.opts.SliceKey == "" {
+			return eris.Wrapf(ErrInvalidSliceKey, "cannot merge %q, SliceKey is not set", path)
+		}
+		if hasKeyField(dst.Type().Elem(), m.opts.SliceKey) {
+			return m.mergeSliceByKey(dst, src, path)
+		}
+	}
+
+	if m.opts.Strategy == MergeOverride {
+		dst.Set(m.copy(src))
+	}
+	return nil
+}
+
+func (m *merger) mergeSliceByKey(dst reflect.Value, src reflect.Value, path string) error {
+	// Index the `dst` items by key
+	dstIndex := map[any]int{}
+	for i := 0; i < dst.Len(); i++ {
+		key, err := m.sliceItemKey(dst.Index(i), path)
+		if err != nil {
+			return err
+		}
+		dstIndex[key] = i
+	}
+
+	for i := 0; i < src.Len(); i++ {
+		srcItem := src.Index(i)
+		key, err := m.sliceItemKey(srcItem, path)
+		if err != nil {
+			return err
+		}
+
+		dstPos, ok := dstIndex[key]
+		if !ok {
+			dst.Set(reflect.Append(dst, m.copy(srcItem)))
+			dstIndex[key] = dst.Len() - 1
+			continue
+		}
+
+		// NOTE: Slice items are addressable, so we can merge in place.
+		err = m.mergeValue(dst.Index(dstPos), srcItem, fmt.Sprintf("%s[%v]", path, key))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasKeyField(itemType reflect.Type, key string) bool {
+	if itemType.Kind() == reflect.Ptr {
+		itemType = itemType.Elem()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := itemType.FieldByName(key)
+	return ok && field.IsExported() && field.Type.Comparable() && field.Type.Kind() != reflect.Interface
+}
+
+func (m *merger) sliceItemKey(item reflect.Value, path string) (any, error) {
+	item = unwrapValue(item)
+	if !item.IsValid() {
+		return nil, eris.Wrapf(ErrInvalidSliceKey, "cannot merge %q, it contains nil items", path)
+	}
+	return item.FieldByName(m.opts.SliceKey).Interface(), nil
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+type mergeKuardInput struct {
+	Name       string
+	Replicas   *int32
+	Containers []corev1.Container
+	Labels     map[string]string
+}
+
+type mergeChartInput struct {
+	KuardInput     mergeKuardInput
+	CertbotEnabled *bool
+	Domains        []string
+}
+
+func makeBaseChartInput() mergeChartInput {
+	return mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Name:     "kuard",
+			Replicas: PointerOf(int32(1)),
+			Containers: []corev1.Container{
+				{Name: "kuard", Image: "kuard:1", ImagePullPolicy: "Always"},
+				{Name: "sidecar", Image: "sidecar:1"},
+			},
+			Labels: map[string]string{"app": "kuard", "env": "dev"},
+		},
+		CertbotEnabled: PointerOf(true),
+		Domains:        []string{"dev.example.com"},
+	}
+}
+
+func TestMergeOverlay(t *testing.T) {
+	assert := assert.New(t)
+
+	input := makeBaseChartInput()
+	prod := mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Replicas: PointerOf(int32(3)),
+			Containers: []corev1.Container{
+				{Name: "kuard", Image: "kuard:2"},
+				{Name: "metrics", Image: "metrics:1"},
+			},
+			Labels: map[string]string{"env": "prod"},
+		},
+		CertbotEnabled: PointerOf(false),
+		Domains:        []string{"example.com"},
+	}
+
+	err := Merge(&input, prod, MergeOptions{
+		Slices:   SliceMergeByKey,
+		SliceKey: "Name",
+	})
+	assert.Nil(err)
+
+	assert.Equal("kuard", input.KuardInput.Name)
+	assert.Equal(int32(3), *input.KuardInput.Replicas)
+	assert.False(*input.CertbotEnabled)
+	assert.Equal([]corev1.Container{
+		{Name: "kuard", Image: "kuard:2", ImagePullPolicy: "Always"},
+		{Name: "sidecar", Image: "sidecar:1"},
+		{Name: "metrics", Image: "metrics:1"},
+	}, input.KuardInput.Containers)
+	assert.Equal(map[string]string{"app": "kuard", "env": "prod"}, input.KuardInput.Labels)
+	// Slices without the key field are replaced
+	assert.Equal([]string{"example.com"}, input.Domains)
+
+	// `dst` does not share state with `src`
+	prod.KuardInput.Containers[1].Image = "changed"
+	*prod.KuardInput.Replicas = 5
+	assert.Equal("metrics:1", input.KuardInput.Containers[2].Image)
+	assert.Equal(int32(3), *input.KuardInput.Replicas)
+}
+
+func TestMergeFillEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	input := mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Name:       "nginx",
+			Containers: []corev1.Container{{Name: "nginx"}},
+			Labels:     map[string]string{"env": "prod"},
+		},
+	}
+
+	err := Merge(&input, makeBaseChartInput(), MergeOptions{Strategy: MergeFillEmpty})
+	assert.Nil(err)
+
+	assert.Equal("nginx", input.KuardInput.Name)
+	assert.Equal(int32(1), *input.KuardInput.Replicas)
+	assert.True(*input.CertbotEnabled)
+	assert.Equal([]corev1.Container{{Name: "nginx"}}, input.KuardInput.Containers)
+	assert.Equal(map[string]string{"app": "kuard", "env": "prod"}, input.KuardInput.Labels)
+}
+
+func TestMergeSliceAppendAndDeepMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	type Input struct {
+		Domains   []string
+		Resources map[string]corev1.ResourceRequirements
+	}
+
+	input := Input{
+		Domains: []string{"a.example.com"},
+		Resources: map[string]corev1.ResourceRequirements{
+			"kuard": {Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		},
+	}
+	overlay := Input{
+		Domains: []string{"b.example.com"},
+		Resources: map[string]corev1.ResourceRequirements{
+			"kuard": {Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+		},
+	}
+
+	// Shallow maps replace the whole entry
+	shallow := input
+	shallow.Resources = map[string]corev1.ResourceRequirements{"kuard": input.Resources["kuard"]}
+	err := Merge(&shallow, overlay, MergeOptions{Slices: SliceAppend})
+	assert.Nil(err)
+	assert.Equal([]string{"a.example.com", "b.example.com"}, shallow.Domains)
+	assert.Nil(shallow.Resources["kuard"].Limits)
+	assert.Equal(resource.MustParse("100m"), shallow.Resources["kuard"].Requests[corev1.ResourceCPU])
+
+	// Deep maps merge the entries
+	err = Merge(&input, overlay, MergeOptions{Maps: MapDeep})
+	assert.Nil(err)
+	assert.Equal([]string{"b.example.com"}, input.Domains)
+	assert.Equal(resource.MustParse("1"), input.Resources["kuard"].Limits[corev1.ResourceCPU])
+	assert.Equal(resource.MustParse("100m"), input.Resources["kuard"].Requests[corev1.ResourceCPU])
+}
+
+type mergeNode struct {
+	Name string
+	Next *mergeNode
+}
+
+func TestMergeRecursivePointers(t *testing.T) {
+	assert := assert.New(t)
+
+	dst := &mergeNode{Name: "a"}
+	dst.Next = dst
+	src := &mergeNode{Name: "b"}
+	src.Next = src
+
+	err := Merge(dst, src, MergeOptions{})
+	assert.Nil(err)
+	assert.Equal("b", dst.Name)
+	assert.Same(dst, dst.Next)
+
+	// Recursive pointers are copied as recursive
+	dst = &mergeNode{Name: "a"}
+	err = Merge(dst, src, MergeOptions{})
+	assert.Nil(err)
+	assert.NotSame(src, dst.Next)
+	assert.Same(dst.Next, dst.Next.Next)
+}
+
+func TestMergeInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	input := makeBaseChartInput()
+	err := Merge(&input, makeBaseChartInput(), MergeOptions{Slices: SliceMergeByKey})
+	assert.ErrorIs(err, ErrInvalidSliceKey)
+
+	err = Merge(&input, TestStruct{}, MergeOptions{})
+	assert.ErrorIs(err, ErrTypeMismatch)
+
+	err = Merge(input, makeBaseChartInput(), MergeOptions{})
+	assert.ErrorIs(err, ErrNotPointer)
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"reflect"
+
+	eris "github.com/rotisserie/eris"
+)
+
+var (
+	ErrUnknownOverlay = eris.New("overlay with given name does not exist")
+)
+
+// Layer the `overlays` on top of `base`, in the given order, e.g. to build
+// environment-specific inputs from a shared base:
+//
+//	input, err := utils.Overlay(BaseChartInput(), ProdChartInput(), RegionChartInput())
+//
+// Later overlays win. Non-zero values of an overlay override the values below
+// it, while zero values (incl. nil pointers) inherit them. Slices are replaced
+// and maps are merged key by key. See `Merge` for details, and `OverlayWithOptions`
+// to e.g. merge slices by key.
+//
+// The result is a deep copy, so neither `base` nor `overlays` are modified.
+//
+// NOTE: Apply the defaults (`ApplyDefaults`, or component's `Defaults`) AFTER
+// the overlays. Otherwise the defaults would count as values set by the base,
+// and overlays could no longer tell them apart from explicitly set values.
+// Components apply their `Defaults` on render, so it's enough to pass the result
+// of `Overlay` to `Render`.
+func Overlay[T any](base T, overlays ...T) (T, error) {
+	return OverlayWithOptions(MergeOptions{Maps: MapDeep}, base, overlays...)
+}
+
+// Same as `Overlay`, but with custom merge options.
+func OverlayWithOptions[T any](opts MergeOptions, base T, overlays ...T) (T, error) {
+	result := deepCopy(reflect.ValueOf(&base).Elem()).Interface().(T)
+
+	for index, overlay := range overlays {
+		err := Merge(&result, overlay, opts)
+		if err != nil {
+			return result, eris.Wrapf(err, "failed to apply overlay at index %v", index)
+		}
+	}
+
+	return result, nil
+}
+
+// Same as `Overlay`, but the overlays are selected by name, e.g. from the command line:
+//
+//	overlays := map[string]ChartInput{"prod": ProdChartInput(), "eu": EuChartInput()}
+//	input, err := utils.OverlayFromMap(BaseChartInput(), overlays, "prod", "eu")
+//
+// The overlays are applied in the order of `names`. Returns `ErrUnknownOverlay`
+// if there's no overlay for a name.
+func OverlayFromMap[T any](base T, overlays map[string]T, names ...string) (T, error) {
+	selected := []T{}
+	for _, name := range names {
+		overlay, ok := overlays[name]
+		if !ok {
+			return base, eris.Wrapf(ErrUnknownOverlay, "overlay %q", name)
+		}
+		selected = append(selected, overlay)
+	}
+	return Overlay(base, selected...)
+}
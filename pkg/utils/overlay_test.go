@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestOverlay(t *testing.T) {
+	assert := assert.New(t)
+
+	base := makeBaseChartInput()
+	staging := mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Replicas: PointerOf(int32(2)),
+			Labels:   map[string]string{"env": "staging"},
+		},
+		Domains: []string{"staging.example.com"},
+	}
+	prod := mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Replicas: PointerOf(int32(5)),
+			Labels:   map[string]string{"env": "prod", "tier": "critical"},
+		},
+		CertbotEnabled: PointerOf(false),
+	}
+	region := mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Name: "kuard-eu",
+		},
+		Domains: []string{"eu.example.com"},
+	}
+
+	result, err := Overlay(base, staging, prod, region)
+	assert.Nil(err)
+
+	// Last overlay that sets the value wins
+	assert.Equal("kuard-eu", result.KuardInput.Name)
+	assert.Equal(int32(5), *result.KuardInput.Replicas)
+	assert.Equal([]string{"eu.example.com"}, result.Domains)
+	// Nil pointers inherit, non-nil override even with zero values
+	assert.Equal(false, *result.CertbotEnabled)
+	// Maps are merged key by key
+	assert.Equal(map[string]string{"app": "kuard", "env": "prod", "tier": "critical"}, result.KuardInput.Labels)
+	// Values not set by any overlay come from the base
+	assert.Equal(base.KuardInput.Containers, result.KuardInput.Containers)
+
+	// Base is left untouched
+	assert.Equal(makeBaseChartInput(), base)
+	result.KuardInput.Containers[0].Image = "changed"
+	assert.Equal("kuard:1", base.KuardInput.Containers[0].Image)
+}
+
+func TestOverlayWithOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	overlay := mergeChartInput{
+		KuardInput: mergeKuardInput{
+			Containers: []corev1.Container{{Name: "sidecar", Image: "sidecar:2"}},
+		},
+	}
+
+	result, err := Overlay(makeBaseChartInput(), overlay)
+	assert.Nil(err)
+	assert.Len(result.KuardInput.Containers, 1)
+
+	result, err = OverlayWithOptions(MergeOptions{Slices: SliceMergeByKey, SliceKey: "Name"}, makeBaseChartInput(), overlay)
+	assert.Nil(err)
+	assert.Len(result.KuardInput.Containers, 2)
+	assert.Equal("kuard:1", result.KuardInput.Containers[0].Image)
+	assert.Equal("sidecar:2", result.KuardInput.Containers[1].Image)
+}
+
+func TestOverlayFromMap(t *testing.T) {
+	assert := assert.New(t)
+
+	overlays := map[string]mergeChartInput{
+		"dev":  {KuardInput: mergeKuardInput{Replicas: PointerOf(int32(1))}, Domains: []string{"dev.example.com"}},
+		"prod": {KuardInput: mergeKuardInput{Replicas: PointerOf(int32(5))}},
+		"eu":   {Domains: []string{"eu.example.com"}},
+	}
+
+	result, err := OverlayFromMap(makeBaseChartInput(), overlays, "prod", "eu")
+	assert.Nil(err)
+	assert.Equal(int32(5), *result.KuardInput.Replicas)
+	assert.Equal([]string{"eu.example.com"}, result.Domains)
+
+	// Order of names decides the precedence
+	result, err = OverlayFromMap(makeBaseChartInput(), overlays, "prod", "dev")
+	assert.Nil(err)
+	assert.Equal(int32(1), *result.KuardInput.Replicas)
+
+	_, err = OverlayFromMap(makeBaseChartInput(), overlays, "prod", "staging")
+	assert.ErrorIs(err, ErrUnknownOverlay)
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const deterministicChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Derive a stable pseudo-random string of given length from `seed`, e.g. to
+// generate IDs from the component input instead of using `uuid.NewString()`:
+//
+//	id := utils.DeterministicString(input.Domain, 8)
+//
+// Same seed always yields the same string, so renders stay reproducible.
+// The string consists of lowercase letters and digits, so it's safe to use
+// in K8s resource names.
+//
+// NOTE: This is NOT suitable for secrets, as anyone who knows the seed can
+// derive the string.
+func DeterministicString(seed string, length int) string {
+	if length <= 0 {
+		return ""
+	}
+
+	out := make([]byte, 0, length)
+	// Each hash gives us 32 bytes, so we chain hashes with a counter
+	// to produce strings of arbitrary length.
+	for counter := uint64(0); len(out) < length; counter++ {
+		counterBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(counterBytes, counter)
+		hash := sha256.Sum256(append([]byte(seed), counterBytes...))
+
+		for _, b := range hash {
+			if len(out) == length {
+				break
+			}
+			out = append(out, deterministicChars[int(b)%len(deterministicChars)])
+		}
+	}
+
+	return string(out)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestDeterministicString(t *testing.T) {
+	assert := assert.New(t)
+
+	first := DeterministicString("example.com", 8)
+	assert.Len(first, 8)
+	assert.Regexp(`^[a-z0-9]+$`, first)
+	assert.Equal(first, DeterministicString("example.com", 8))
+	assert.NotEqual(first, DeterministicString("example.org", 8))
+
+	// Shorter strings are prefixes of the longer ones
+	long := DeterministicString("example.com", 100)
+	assert.Len(long, 100)
+	assert.Equal(first, long[:8])
+
+	assert.Equal("", DeterministicString("example.com", 0))
+}
@@ -0,0 +1,369 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	eris "github.com/rotisserie/eris"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	yaml "sigs.k8s.io/yaml"
+)
+
+// JSON Schema draft used by `SchemaFor`
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Struct tag with the description of a field in the JSON Schema generated
+// with `SchemaFor`, e.g.
+//
+//	type Input struct {
+//		Replicas int32 `desc:"Number of pods"`
+//	}
+const DescTagKey = "desc"
+
+var (
+	ErrUnsupportedSchemaType = eris.New("type cannot be described with JSON Schema")
+	ErrInvalidSchema         = eris.New("invalid JSON Schema")
+	ErrInvalidValues         = eris.New("values do not match the schema")
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	metav1TimeType = reflect.TypeOf(metav1.Time{})
+)
+
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+}
+
+// Generate JSON Schema (draft 2020-12) of the input type `T`, e.g. to validate
+// values files before they are loaded with `LoadValues`, or to write it as
+// Helm's `values.schema.json`.
+//
+// Keys are taken from the `json` struct tags, same as in `LoadValues`. Structs
+// don't allow keys other than their fields. Fields can be further described
+// with struct tags:
+//   - `helpa:"required"` - The key must be present.
+//   - `helpa:"oneof=a|b"` - The value must be one of the listed values.
+//   - `desc:"..."` - Description of the field.
+//
+// NOTE: Types that implement their own JSON (un)marshalling, like `resource.Quantity`,
+// accept any value. Same for recursive types, at the point of recursion.
+func SchemaFor[T any]() ([]byte, error) {
+	gen := schemaGenerator{visiting: map[reflect.Type]bool{}}
+	schema, err := gen.schemaForType(reflect.TypeOf((*T)(nil)).Elem(), "")
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = SchemaDraft
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+type schemaGenerator struct {
+	// Structs that are currently being described, to detect recursive types
+	visiting map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) schemaForType(t reflect.Type, path string) (*jsonSchema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType || t == metav1TimeType {
+		return &jsonSchema{Type: "string", Format: "date-time"}, nil
+	}
+	if hasCustomJSON(t) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return &jsonSchema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.Interface:
+		return &jsonSchema{}, nil
+	case reflect.Slice, reflect.Array:
+		// NOTE: Same as `encoding/json`, bytes are encoded as base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: "string"}, nil
+		}
+		items, err := g.schemaForType(t.Elem(), path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		items, err := g.schemaForType(t.Elem(), path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: items}, nil
+	case reflect.Struct:
+		if g.visiting[t] {
+			return &jsonSchema{}, nil
+		}
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+
+		schema := &jsonSchema{
+			Type:                 "object",
+			Properties:           map[string]*jsonSchema{},
+			AdditionalProperties: false,
+		}
+		err := g.addStructFields(t, schema, path)
+		return schema, err
+	}
+
+	return nil, eris.Wrapf(ErrUnsupportedSchemaType, "type %s of field %q", t, path)
+}
+
+func (g *schemaGenerator) addStructFields(t reflect.Type, schema *jsonSchema, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		// Embedded structs without a name are inlined, same as `encoding/json` does
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			err := g.addStructFields(fieldType, schema, path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() || fieldType.Kind() == reflect.Func || fieldType.Kind() == reflect.Chan {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fieldPath := joinFieldPath(path, field.Name)
+
+		fieldSchema, err := g.schemaForType(field.Type, fieldPath)
+		if err != nil {
+			return err
+		}
+		fieldSchema.Description = field.Tag.Get(DescTagKey)
+
+		if oneOf, ok := tagOptionValue(field, TagOneOf); ok {
+			for _, option := range strings.Split(oneOf, "|") {
+				// NOTE: Options are parsed the same way as `default` tags, so e.g.
+				// `oneof=1|2` on int field gives numbers, not strings.
+				value, err := parseTagDefault(fieldType, option)
+				if err != nil {
+					return eris.Wrapf(err, "invalid option %q of field %q", option, fieldPath)
+				}
+				fieldSchema.Enum = append(fieldSchema.Enum, value.Interface())
+			}
+		}
+		if hasTagOption(field, TagRequired) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = fieldSchema
+	}
+	return nil
+}
+
+// Validate YAML (or JSON) values against JSON Schema, e.g. one generated with
+// `SchemaFor`. Use it in CI to check the values files before they are loaded.
+//
+// Each violation is reported as a separate error, prefixed with the JSON pointer
+// to the offending value, e.g. `/image/tag`. All are joined into a single error.
+//
+// NOTE: Only the subset of JSON Schema that's generated by `SchemaFor` is
+// supported - `type`, `enum`, `properties`, `required`, `additionalProperties`
+// and `items`. Other keywords are ignored.
+func ValidateValues(schema []byte, valuesYAML []byte) error {
+	var schemaObj map[string]any
+	err := decodeJSONNumbers(schema, &schemaObj)
+	if err != nil {
+		return eris.Wrap(ErrInvalidSchema, err.Error())
+	}
+
+	valuesJSON, err := yaml.YAMLToJSON(valuesYAML)
+	if err != nil {
+		return eris.Wrap(err, "failed to parse values")
+	}
+	var values any
+	err = decodeJSONNumbers(valuesJSON, &values)
+	if err != nil {
+		return eris.Wrap(err, "failed to parse values")
+	}
+	// Same as in Helm, empty values file means no values
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	issues := validateAgainstSchema(schemaObj, values, "")
+	return errors.Join(issues...)
+}
+
+func decodeJSONNumbers(data []byte, out any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+func validateAgainstSchema(schema map[string]any, value any, pointer string) []error {
+	issue := func(format string, args ...any) error {
+		location := pointer
+		if location == "" {
+			location = "/"
+		}
+		return eris.Wrapf(ErrInvalidValues, "%s: %s", location, fmt.Sprintf(format, args...))
+	}
+
+	if expected := schemaTypes(schema["type"]); len(expected) > 0 {
+		actual := jsonTypeOf(value)
+		if !matchesJSONType(actual, expected) {
+			return []error{issue("expected %s, got %s", strings.Join(expected, " or "), actual)}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !containsJSONValue(enum, value) {
+		options := []string{}
+		for _, option := range enum {
+			options = append(options, toJSONString(option))
+		}
+		return []error{issue("value %s is not one of %s", toJSONString(value), strings.Join(options, ", "))}
+	}
+
+	issues := []error{}
+	switch val := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, key := range required {
+				if _, ok := val[fmt.Sprint(key)]; !ok {
+					issues = append(issues, issue("missing required key %q", key))
+				}
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			itemPointer := pointer + "/" + escapeJSONPointer(key)
+			if propSchema, ok := properties[key].(map[string]any); ok {
+				issues = append(issues, validateAgainstSchema(propSchema, val[key], itemPointer)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					issues = append(issues, issue("key %q is not allowed", key))
+				}
+			case map[string]any:
+				issues = append(issues, validateAgainstSchema(additional, val[key], itemPointer)...)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for index, item := range val {
+				issues = append(issues, validateAgainstSchema(items, item, fmt.Sprintf("%s/%v", pointer, index))...)
+			}
+		}
+	}
+	return issues
+}
+
+// The `type` keyword may be a single type or a list of types
+func schemaTypes(t any) []string {
+	switch val := t.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		types := []string{}
+		for _, item := range val {
+			types = append(types, fmt.Sprint(item))
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonTypeOf(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesJSONType(actual string, expected []string) bool {
+	for _, t := range expected {
+		// NOTE: Integers are numbers too
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsJSONValue(options []any, value any) bool {
+	valueStr := toJSONString(value)
+	for _, option := range options {
+		if toJSONString(option) == valueStr {
+			return true
+		}
+	}
+	return false
+}
+
+func toJSONString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// See https://datatracker.ietf.org/doc/html/rfc6901#section-3
+func escapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
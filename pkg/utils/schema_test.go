@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+type schemaImage struct {
+	Repository string `json:"repository" helpa:"required"`
+	Tag        string `json:"tag,omitempty"`
+	PullPolicy string `json:"pullPolicy" helpa:"oneof=Always|IfNotPresent|Never"`
+}
+
+type schemaPort struct {
+	Name string `json:"name"`
+	Port int32  `json:"port" helpa:"required,oneof=80|443|8080"`
+}
+
+type schemaInput struct {
+	Name      string            `json:"name" helpa:"required" desc:"Name of the release"`
+	Replicas  *int32            `json:"replicas"`
+	Ratio     float64           `json:"ratio"`
+	Enabled   bool              `json:"enabled"`
+	Image     schemaImage       `json:"image"`
+	Ports     []schemaPort      `json:"ports"`
+	Labels    map[string]string `json:"labels"`
+	Memory    resource.Quantity `json:"memory"`
+	Ignored   string            `json:"-"`
+	OnRender  func()
+	unexposed string
+}
+
+func TestSchemaFor(t *testing.T) {
+	assert := assert.New(t)
+
+	schemaBytes, err := SchemaFor[schemaInput]()
+	assert.Nil(err)
+
+	var schema map[string]any
+	err = json.Unmarshal(schemaBytes, &schema)
+	assert.Nil(err)
+
+	assert.Equal(SchemaDraft, schema["$schema"])
+	assert.Equal("object", schema["type"])
+	assert.Equal(false, schema["additionalProperties"])
+	assert.Equal([]any{"name"}, schema["required"])
+
+	props := schema["properties"].(map[string]any)
+	assert.Len(props, 8)
+	assert.Equal(map[string]any{"type": "string", "description": "Name of the release"}, props["name"])
+	assert.Equal(map[string]any{"type": "integer"}, props["replicas"])
+	assert.Equal(map[string]any{"type": "number"}, props["ratio"])
+	assert.Equal(map[string]any{"type": "boolean"}, props["enabled"])
+	assert.Equal(map[string]any{}, props["memory"])
+
+	// Nested struct
+	image := props["image"].(map[string]any)
+	assert.Equal([]any{"repository"}, image["required"])
+	imageProps := image["properties"].(map[string]any)
+	assert.Equal([]any{"Always", "IfNotPresent", "Never"}, imageProps["pullPolicy"].(map[string]any)["enum"])
+
+	// Slice of structs, with enum of numbers
+	ports := props["ports"].(map[string]any)
+	assert.Equal("array", ports["type"])
+	portItems := ports["items"].(map[string]any)
+	assert.Equal([]any{"port"}, portItems["required"])
+	assert.Equal([]any{80.0, 443.0, 8080.0}, portItems["properties"].(map[string]any)["port"].(map[string]any)["enum"])
+
+	// Map
+	assert.Equal(map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}, props["labels"])
+}
+
+type schemaNode struct {
+	Name     string       `json:"name"`
+	Children []schemaNode `json:"children"`
+}
+
+func TestSchemaForRecursive(t *testing.T) {
+	assert := assert.New(t)
+
+	schemaBytes, err := SchemaFor[schemaNode]()
+	assert.Nil(err)
+
+	var schema map[string]any
+	err = json.Unmarshal(schemaBytes, &schema)
+	assert.Nil(err)
+
+	children := schema["properties"].(map[string]any)["children"].(map[string]any)
+	assert.Equal(map[string]any{}, children["items"])
+
+	_, err = SchemaFor[struct{ Value complex64 }]()
+	assert.ErrorIs(err, ErrUnsupportedSchemaType)
+}
+
+func TestValidateValues(t *testing.T) {
+	assert := assert.New(t)
+
+	schema, err := SchemaFor[schemaInput]()
+	assert.Nil(err)
+
+	err = ValidateValues(schema, []byte(`
+name: kuard
+replicas: 2
+ratio: 1
+image:
+  repository: gcr.io/kuar-demo/kuard
+  pullPolicy: Always
+ports:
+  - name: http
+    port: 8080
+labels:
+  app: kuard
+memory: 500Mi
+`))
+	assert.Nil(err)
+
+	err = ValidateValues(schema, []byte(`
+replicas: two
+image:
+  pullPolicy: Sometimes
+ports:
+  - name: http
+    port: 9090
+  - name: https
+labels:
+  app: 1
+extra: true
+`))
+	assert.ErrorIs(err, ErrInvalidValues)
+	assert.Contains(err.Error(), `/: missing required key "name"`)
+	assert.Contains(err.Error(), `/: key "extra" is not allowed`)
+	assert.Contains(err.Error(), `/image: missing required key "repository"`)
+	assert.Contains(err.Error(), `/image/pullPolicy: value "Sometimes" is not one of "Always", "IfNotPresent", "Never"`)
+	assert.Contains(err.Error(), `/labels/app: expected string, got integer`)
+	assert.Contains(err.Error(), `/ports/0/port: value 9090 is not one of 80, 443, 8080`)
+	assert.Contains(err.Error(), `/ports/1: missing required key "port"`)
+	assert.Contains(err.Error(), `/replicas: expected integer, got string`)
+
+	err = ValidateValues([]byte(`not json`), []byte(`name: kuard`))
+	assert.ErrorIs(err, ErrInvalidSchema)
+}
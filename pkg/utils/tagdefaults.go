@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	eris "github.com/rotisserie/eris"
+)
+
+// Struct tag that holds the default value of a field, e.g.
+//
+//	type Input struct {
+//		Replicas int32 `default:"3"`
+//	}
+const DefaultTagKey = "default"
+
+var (
+	ErrInvalidTagDefault     = eris.New("failed to parse default value from struct tag")
+	ErrUnsupportedTagDefault = eris.New("default struct tag is not supported for this field type")
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Populate the zero-valued fields of struct `s` with the values from their
+// `default:"..."` struct tags. This way the defaults live next to the fields,
+// instead of in a separate defaults struct.
+//
+// The tag value is parsed according to the field type:
+//   - Strings are used as they are.
+//   - Ints, uints, floats and bools are parsed with `strconv`.
+//   - `time.Duration` is parsed with `time.ParseDuration`, e.g. `default:"30s"`.
+//   - Slices are comma-separated, e.g. `default:"a,b,c"`.
+//   - Structs, maps and slices are parsed as JSON if the tag starts with `{` or `[`.
+//   - Types that implement `encoding.TextUnmarshaler` or `json.Unmarshaler` (e.g.
+//     `resource.Quantity`) use these.
+//   - Pointers are allocated, and the tag is parsed as the pointed-to type.
+//
+// Structs and non-nil pointers to structs without the tag are recursed into.
+// Fields marked with `helpa:"keepzero"` are skipped.
+//
+// To combine with `ApplyDefaults`, apply the tag defaults first, then the struct
+// defaults. Since tag defaults populate the zero-valued fields, they take precedence:
+//
+//	err := utils.ApplyTagDefaults(&input)
+//	err = utils.ApplyDefaults(&input, ChartDefaults())
+func ApplyTagDefaults(s any) error {
+	if s == nil {
+		return nil
+	}
+
+	val := reflect.ValueOf(s)
+	if val.Kind() == reflect.Ptr && val.Elem().Kind() == reflect.Struct {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return eris.Wrapf(ErrNotStruct, "expected pointer to struct, got %T", s)
+	}
+	if !val.CanSet() {
+		return eris.Wrapf(ErrNotPointer, "expected pointer to struct, got %s", val.Type())
+	}
+
+	return applyTagDefaultsStruct(val, "", map[pointerKey]bool{})
+}
+
+// NOTE: Tag defaults are the same for each visit of a struct, so we visit
+// each pointer only once. This also protects us from pointer cycles.
+func applyTagDefaultsStruct(val reflect.Value, path string, visited map[pointerKey]bool) error {
+	valType := val.Type()
+	for i := 0; i < valType.NumField(); i++ {
+		fieldType := valType.Field(i)
+		if !fieldType.IsExported() || hasTagOption(fieldType, TagKeepZero) {
+			continue
+		}
+
+		field := val.Field(i)
+		fieldPath := joinFieldPath(path, fieldType.Name)
+
+		tag, ok := fieldType.Tag.Lookup(DefaultTagKey)
+		if !ok || tag == "" {
+			if isRecursible(field) {
+				if field.Kind() == reflect.Ptr {
+					key := pointerKey{ptr: field.Pointer(), typ: field.Type()}
+					if visited[key] {
+						continue
+					}
+					visited[key] = true
+				}
+				err := applyTagDefaultsStruct(unwrapValue(field), fieldPath, visited)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// Do nothing if the value is set
+		if !field.IsZero() {
+			continue
+		}
+
+		dftVal, err := parseTagDefault(field.Type(), tag)
+		if err != nil {
+			return eris.Wrapf(err, "invalid default %q of field %q", tag, fieldPath)
+		}
+		field.Set(dftVal)
+	}
+
+	return nil
+}
+
+// Parse the value of the `default` struct tag as given type.
+func parseTagDefault(t reflect.Type, raw string) (reflect.Value, error) {
+	ptr := reflect.New(t)
+
+	if t.Kind() == reflect.Ptr {
+		elem, err := parseTagDefault(t.Elem(), raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr.Elem().Set(reflect.New(t.Elem()))
+		ptr.Elem().Elem().Set(elem)
+		return ptr.Elem(), nil
+	}
+
+	isJSON := strings.HasPrefix(raw, "{") || strings.HasPrefix(raw, "[")
+	switch {
+	case isJSON && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map || t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		if err := json.Unmarshal([]byte(raw), ptr.Interface()); err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		return ptr.Elem(), nil
+	case ptr.Type().Implements(textUnmarshalerType):
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		return ptr.Elem(), nil
+	case ptr.Type().Implements(jsonUnmarshalerType):
+		// NOTE: Types like `resource.Quantity` or `metav1.Duration` unmarshal from
+		// JSON strings, so we quote the tag value.
+		quoted, _ := json.Marshal(raw)
+		if err := ptr.Interface().(json.Unmarshaler).UnmarshalJSON(quoted); err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		return ptr.Elem(), nil
+	case t == durationType:
+		dur, err := time.ParseDuration(raw)
+		if err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		ptr.Elem().SetInt(int64(dur))
+		return ptr.Elem(), nil
+	}
+
+	val := ptr.Elem()
+	switch t.Kind() {
+	case reflect.String:
+		val.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		val.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		val.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		val.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, t.Bits())
+		if err != nil {
+			return reflect.Value{}, eris.Wrap(ErrInvalidTagDefault, err.Error())
+		}
+		val.SetFloat(n)
+	case reflect.Slice:
+		parts := strings.Split(raw, ",")
+		slice := reflect.MakeSlice(t, 0, len(parts))
+		for index, part := range parts {
+			item, err := parseTagDefault(t.Elem(), strings.TrimSpace(part))
+			if err != nil {
+				return reflect.Value{}, eris.Wrapf(err, "at index %v", index)
+			}
+			slice = reflect.Append(slice, item)
+		}
+		val.Set(slice)
+	default:
+		return reflect.Value{}, eris.Wrapf(ErrUnsupportedTagDefault, "type %s", t)
+	}
+
+	return val, nil
+}
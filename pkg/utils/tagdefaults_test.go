@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type tagDefaultsPort struct {
+	Name string `json:"name"`
+	Port int32  `json:"port"`
+}
+
+type tagDefaultsStruct struct {
+	Name     string            `default:"kuard"`
+	Replicas int32             `default:"3"`
+	MaxSurge uint8             `default:"2"`
+	Ratio    float64           `default:"0.5"`
+	Enabled  bool              `default:"true"`
+	Debug    *bool             `default:"false"`
+	Timeout  time.Duration     `default:"30s"`
+	Interval metav1.Duration   `default:"1m"`
+	Memory   resource.Quantity `default:"500Mi"`
+	Hosts    []string          `default:"a.example.com, b.example.com"`
+	Ports    []int             `default:"80,443"`
+	Labels   map[string]string `default:"{\"app\": \"kuard\"}"`
+	Port     tagDefaultsPort   `default:"{\"name\": \"http\", \"port\": 8080}"`
+	Extra    []tagDefaultsPort `default:"[{\"name\": \"metrics\", \"port\": 9090}]"`
+	Nested   struct {
+		City string `default:"Berlin"`
+	}
+	Kept  string `default:"value" helpa:"keepzero"`
+	NoTag string
+}
+
+func TestApplyTagDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	myStruct := tagDefaultsStruct{}
+	err := ApplyTagDefaults(&myStruct)
+	assert.Nil(err)
+
+	assert.Equal("kuard", myStruct.Name)
+	assert.Equal(int32(3), myStruct.Replicas)
+	assert.Equal(uint8(2), myStruct.MaxSurge)
+	assert.Equal(0.5, myStruct.Ratio)
+	assert.True(myStruct.Enabled)
+	assert.False(*myStruct.Debug)
+	assert.Equal(30*time.Second, myStruct.Timeout)
+	assert.Equal(time.Minute, myStruct.Interval.Duration)
+	assert.Equal("500Mi", myStruct.Memory.String())
+	assert.Equal([]string{"a.example.com", "b.example.com"}, myStruct.Hosts)
+	assert.Equal([]int{80, 443}, myStruct.Ports)
+	assert.Equal(map[string]string{"app": "kuard"}, myStruct.Labels)
+	assert.Equal(tagDefaultsPort{Name: "http", Port: 8080}, myStruct.Port)
+	assert.Equal([]tagDefaultsPort{{Name: "metrics", Port: 9090}}, myStruct.Extra)
+	assert.Equal("Berlin", myStruct.Nested.City)
+	assert.Equal("", myStruct.Kept)
+	assert.Equal("", myStruct.NoTag)
+}
+
+func TestApplyTagDefaultsKeepsSetValues(t *testing.T) {
+	assert := assert.New(t)
+
+	myStruct := tagDefaultsStruct{Name: "nginx", Debug: PointerOf(true), Hosts: []string{"c.example.com"}}
+	err := ApplyTagDefaults(&myStruct)
+	assert.Nil(err)
+	assert.Equal("nginx", myStruct.Name)
+	assert.True(*myStruct.Debug)
+	assert.Equal([]string{"c.example.com"}, myStruct.Hosts)
+}
+
+func TestApplyTagDefaultsWithApplyDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	type Input struct {
+		Name     string `default:"kuard"`
+		Replicas int32
+	}
+
+	myStruct := Input{}
+	err := ApplyTagDefaults(&myStruct)
+	assert.Nil(err)
+	err = ApplyDefaults(&myStruct, Input{Name: "nginx", Replicas: 2})
+	assert.Nil(err)
+	assert.Equal("kuard", myStruct.Name)
+	assert.Equal(int32(2), myStruct.Replicas)
+}
+
+func TestApplyTagDefaultsInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	type Invalid struct {
+		Nested struct {
+			Replicas int32 `default:"three"`
+		}
+	}
+	err := ApplyTagDefaults(&Invalid{})
+	assert.ErrorIs(err, ErrInvalidTagDefault)
+	assert.Contains(err.Error(), `"Nested.Replicas"`)
+
+	type Unsupported struct {
+		Callback func() `default:"noop"`
+	}
+	err = ApplyTagDefaults(&Unsupported{})
+	assert.ErrorIs(err, ErrUnsupportedTagDefault)
+
+	err = ApplyTagDefaults(Invalid{})
+	assert.ErrorIs(err, ErrNotPointer)
+}
@@ -1,31 +1,159 @@
 package utils
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
-	reflections "github.com/oleiade/reflections"
 	eris "github.com/rotisserie/eris"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// Errors of the reflection-based helpers, e.g. `ApplyDefaults`, `ApplyTagDefaults`,
+// `Merge`, `BindFlags` or `CopyMatchingFields`. The wrapping error tells which
+// value or field it was.
 var (
-	ErrNotStruct = eris.New("value passed to ApplyDefaults is not a struct")
+	ErrNotStruct    = eris.New("value is not a struct")
+	ErrNotPointer   = eris.New("value is not a pointer to a struct")
+	ErrTypeMismatch = eris.New("value's type is not assignable to the target's type")
+	ErrCycle        = eris.New("struct contains a pointer cycle")
+	ErrMaxDepth     = eris.New("struct is nested deeper than the max depth")
 )
 
+// Struct types that `ApplyDefaults` treats as opaque values instead of recursing
+// into their fields. See `RegisterLeafType`.
+var (
+	leafTypesLock sync.RWMutex
+	leafTypes     = map[reflect.Type]bool{
+		reflect.TypeOf(time.Time{}):          true,
+		reflect.TypeOf(metav1.Time{}):        true,
+		reflect.TypeOf(metav1.Duration{}):    true,
+		reflect.TypeOf(resource.Quantity{}):  true,
+		reflect.TypeOf(intstr.IntOrString{}): true,
+	}
+)
+
+// Register a struct type that `ApplyDefaults` should treat as a single value.
+//
+// By default, `ApplyDefaults` recurses into structs and applies defaults field
+// by field. That makes no sense for types like `time.Time` or `resource.Quantity`,
+// whose fields are internal. Fields of leaf types are instead set to (a copy of)
+// the default as a whole if they are zero-valued.
+//
+// NOTE: If the type has a `DeepCopy` method, it's used to copy the default.
+func RegisterLeafType(t reflect.Type) {
+	leafTypesLock.Lock()
+	defer leafTypesLock.Unlock()
+	leafTypes[t] = true
+}
+
+func isLeafType(t reflect.Type) bool {
+	leafTypesLock.RLock()
+	defer leafTypesLock.RUnlock()
+	return leafTypes[t]
+}
+
+// Struct tag that controls how `ApplyDefaults` treats a field, e.g.
+//
+//	type Input struct {
+//		Replicas int32 `helpa:"keepzero"`
+//	}
+const StructTagKey = "helpa"
+
+// Value of the `helpa` struct tag that marks a field as never-defaulted.
+// Such field keeps its value, even if it's zero.
+const TagKeepZero = "keepzero"
+
+// Value of the `helpa` struct tag that marks a field as required in the JSON
+// Schema generated with `SchemaFor`.
+const TagRequired = "required"
+
+// Key of the `helpa` struct tag that limits the field to the listed values in
+// the JSON Schema generated with `SchemaFor`, e.g. `helpa:"oneof=Always|IfNotPresent"`.
+const TagOneOf = "oneof"
+
+// Key of the `helpa` struct tag that marks a field as deprecated, with a message
+// for the users, e.g. `helpa:"deprecated=use Image instead"`. See `FindDeprecatedFields`.
+//
+// NOTE: The message must not contain commas, as these separate the tag options.
+const TagDeprecated = "deprecated"
+
+// What `ApplyDefaultsWithOptions` does when it encounters a pointer cycle
+type CyclePolicy int
+
+const (
+	// Return `ErrCycle`
+	CycleError CyclePolicy = iota
+	// Treat the pointer that closes the cycle as a leaf, and leave it as it is
+	CycleSkip
+)
+
+// Max depth of nested structs used if `DefaultsOptions.MaxDepth` is not set
+const DefaultMaxDepth = 256
+
+type DefaultsOptions struct {
+	// What to do if a pointer leads back to a struct that we're already
+	// applying defaults to, e.g. in linked config nodes.
+	//
+	// Default: `CycleError`
+	OnCycle CyclePolicy
+	// Max depth of nested structs. Serves as a backstop against runaway recursion,
+	// returning `ErrMaxDepth` instead of overflowing the stack.
+	//
+	// Default: `DefaultMaxDepth`
+	MaxDepth int
+	// Called with debug notes, e.g. when an unexported field is skipped.
+	//
+	// Default: notes are dropped
+	OnDebug func(message string)
+}
+
+// Populate the fields of struct `s` that are unset with the values from `defaults`.
+//
+// A field is considered unset if:
+//   - It's a zero-valued scalar or struct field.
+//   - It's a nil pointer, map, or slice. Such fields receive a (deep) copy of the default.
+//
+// Because of that, a scalar field that was intentionally set to its zero value
+// (e.g. `Replicas: 0` or `Enabled: false`) is overwritten by a non-zero default.
+// To avoid that, either:
+//   - Use pointers (`*bool`, `*int32`) - these are tri-state, and only `nil` is
+//     considered unset. This is the recommended approach for boolean toggles.
+//   - Or mark the field with the `helpa:"keepzero"` struct tag, so it's never defaulted.
+//
+// Structs and non-nil pointers to structs are recursed into, unless they are
+// leaf types (see `RegisterLeafType`). Non-nil maps receive
+// the entries from the default whose keys are missing.
+//
+// Only `s` itself is modified in place. Pointers and maps that need defaults are
+// replaced with their updated copies, so the structs and maps they point to,
+// which may be shared with the caller, are left unchanged.
+//
+// Fields are matched by name, so `defaults` may be of a different struct type
+// than `s`. Fields missing from `defaults` are left as they are. If the default's
+// type is not assignable to the field, `ErrTypeMismatch` is returned.
+//
+// NOTE: Unexported fields cannot be set via reflection, so these are skipped.
+// See `DefaultsOptions.OnDebug`.
+//
+// Pointer cycles result in `ErrCycle`. Use `ApplyDefaultsWithOptions` to
+// configure that.
+//
 // See https://stackoverflow.com/a/49471736/9788634
 func ApplyDefaults(s any, defaults any) error {
+	return ApplyDefaultsWithOptions(s, defaults, DefaultsOptions{})
+}
+
+// Same as `ApplyDefaults`, but configurable with `DefaultsOptions`.
+func ApplyDefaultsWithOptions(s any, defaults any, opts DefaultsOptions) error {
 	if s == nil {
 		return nil
 	}
 
-	defFieldValues, err := reflections.Items(defaults)
-	if err != nil {
-		return eris.Wrap(err, "failed to extract fields from defaults struct")
-	}
-	fieldNames, err := reflections.Fields(s)
-	if err != nil {
-		return eris.Wrap(err, "failed to extract fields from target struct")
-	}
-
 	val := reflect.ValueOf(s)
 
 	// If it's an interface or a pointer, unwrap it.
@@ -33,61 +161,344 @@ func ApplyDefaults(s any, defaults any) error {
 		val = val.Elem()
 	}
 	if val.Kind() != reflect.Struct {
-		return ErrNotStruct
+		return eris.Wrapf(ErrNotStruct, "expected pointer to struct, got %T", s)
+	}
+	if !val.CanSet() {
+		return eris.Wrapf(ErrNotPointer, "expected pointer to struct, got %s", val.Type())
 	}
 
-	valNumFields := val.NumField()
+	dftVal := unwrapValue(reflect.ValueOf(defaults))
+	if !dftVal.IsValid() {
+		return nil
+	}
+	if dftVal.Kind() != reflect.Struct {
+		return eris.Wrapf(ErrNotStruct, "defaults must be a struct, got %s", dftVal.Type())
+	}
 
-	for i := 0; i < valNumFields; i++ {
-		fieldName := fieldNames[i]
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+	d := defaulter{opts: opts, stack: map[pointerKey]bool{}}
 
-		field := val.Field(i)
-		fieldKind := field.Kind()
-		dftField := reflect.ValueOf(defFieldValues[fieldName])
+	// The struct itself may be the target of a cycle
+	if ptr := reflect.ValueOf(s); ptr.Kind() == reflect.Ptr {
+		d.stack[pointerKey{ptr: ptr.Pointer(), typ: ptr.Type()}] = true
+	}
 
-		// Check if it's a pointer to a struct.
-		if fieldKind == reflect.Ptr && field.Elem().Kind() == reflect.Struct {
-			if field.CanInterface() {
-				// Recurse using an interface of the field.
-				err := ApplyDefaults(field.Interface(), dftField.Interface())
-				if err != nil {
-					return err
-				}
-			}
+	return d.applyStruct(val, dftVal, "", 0)
+}
 
-			// Move onto the next field.
-			continue
+type defaulter struct {
+	opts DefaultsOptions
+	// Pointers to the structs we're currently applying defaults to. These are
+	// tracked only along the current path, so that multiple fields pointing
+	// to the same struct are not mistaken for a cycle.
+	stack map[pointerKey]bool
+}
+
+// Dereference pointers and interfaces. Returns invalid Value if nil.
+func unwrapValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
 		}
+		v = v.Elem()
+	}
+	return v
+}
 
-		// Check if it's a struct value.
-		if fieldKind == reflect.Struct {
-			if field.CanAddr() && field.Addr().CanInterface() {
-				// Recurse using an interface of the pointer value of the field.
-				err := ApplyDefaults(
-					field.Addr().Interface(),
-					defFieldValues[fieldName],
-				)
-				if err != nil {
-					return err
-				}
-			}
+func hasTagOption(field reflect.StructField, option string) bool {
+	tag, ok := field.Tag.Lookup(StructTagKey)
+	if !ok {
+		return false
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
 
-			// Move onto the next field.
+// Get the value of a `key=value` option of the `helpa` struct tag.
+func tagOptionValue(field reflect.StructField, key string) (string, bool) {
+	tag, ok := field.Tag.Lookup(StructTagKey)
+	if !ok {
+		return "", false
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		optKey, value, found := strings.Cut(strings.TrimSpace(opt), "=")
+		if found && optKey == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func joinFieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Report a debug note to `DefaultsOptions.OnDebug`, if set.
+func (d *defaulter) debugf(format string, args ...any) {
+	if d.opts.OnDebug != nil {
+		d.opts.OnDebug(fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *defaulter) applyStruct(val reflect.Value, dftVal reflect.Value, path string, depth int) error {
+	if depth > d.opts.MaxDepth {
+		return eris.Wrapf(ErrMaxDepth, "field %q exceeds max depth of %v", path, d.opts.MaxDepth)
+	}
+
+	// The fields of the target struct are the single source of truth. Defaults
+	// are looked up by the field name.
+	valType := val.Type()
+	for i := 0; i < valType.NumField(); i++ {
+		fieldType := valType.Field(i)
+
+		// NOTE: Unexported fields cannot be set via reflection, so we skip them
+		// instead of failing the whole struct.
+		if !fieldType.IsExported() {
+			d.debugf("skipped unexported field %q", joinFieldPath(path, fieldType.Name))
+			continue
+		}
+		if hasTagOption(fieldType, TagKeepZero) {
 			continue
 		}
 
-		// Do nothing if the value is set
-		isZero := field.IsZero()
-		if !isZero {
+		dftField := dftVal.FieldByName(fieldType.Name)
+		if !dftField.IsValid() {
 			continue
 		}
 
-		reflections.SetField(s, fieldNames[i], defFieldValues[fieldNames[i]])
+		err := d.applyField(val.Field(i), dftField, joinFieldPath(path, fieldType.Name), depth)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *defaulter) applyField(field reflect.Value, dftField reflect.Value, path string, depth int) error {
+	// Nothing to apply if the default is nil.
+	if dftField.Kind() == reflect.Interface {
+		if dftField.IsNil() {
+			return nil
+		}
+		dftField = dftField.Elem()
+	}
+	if isNillable(dftField) && dftField.IsNil() {
+		return nil
+	}
+
+	fieldKind := field.Kind()
+
+	// Structs and pointers to structs are recursed into, and the defaults are
+	// matched by field names.
+	if isRecursible(field) {
+		dftStruct := unwrapValue(dftField)
+		if dftStruct.Kind() != reflect.Struct {
+			return eris.Wrapf(ErrTypeMismatch, "cannot apply default of type %s to field %q of type %s", dftField.Type(), path, field.Type())
+		}
+		if fieldKind != reflect.Ptr {
+			return d.applyStruct(field, dftStruct, path, depth+1)
+		}
+
+		key := pointerKey{ptr: field.Pointer(), typ: field.Type()}
+		if d.stack[key] {
+			if d.opts.OnCycle == CycleSkip {
+				return nil
+			}
+			return eris.Wrapf(ErrCycle, "field %q points back to its parent", path)
+		}
+		d.stack[key] = true
+		defer delete(d.stack, key)
+
+		// NOTE: The pointee may be shared with the caller, so we apply the defaults
+		// to its (shallow) copy. Nested pointers and maps are copied the same way
+		// as we go.
+		copy := reflect.New(field.Type().Elem())
+		copy.Elem().Set(field.Elem())
+		if err := d.applyStruct(copy.Elem(), dftStruct, path, depth+1); err != nil {
+			return err
+		}
+		field.Set(copy)
+		return nil
+	}
+
+	if !dftField.Type().AssignableTo(field.Type()) {
+		return eris.Wrapf(ErrTypeMismatch, "cannot apply default of type %s to field %q of type %s", dftField.Type(), path, field.Type())
+	}
+
+	// Pointers, maps and slices that are nil get (a copy of) the default value.
+	// Pointers are thus tri-state - `nil` means "unset", while a pointer to a zero
+	// value is considered set.
+	if isNillable(field) && field.IsNil() {
+		field.Set(deepCopy(dftField))
+		return nil
+	}
+
+	switch fieldKind {
+	// Pointers to other types are already set, as they are not nil.
+	case reflect.Ptr:
+		return nil
+	// Merge in the map entries that are missing. The map may be shared with
+	// the caller, so the entries are merged into its copy.
+	case reflect.Map:
+		var merged reflect.Value
+		iter := dftField.MapRange()
+		for iter.Next() {
+			if field.MapIndex(iter.Key()).IsValid() {
+				continue
+			}
+			if !merged.IsValid() {
+				merged = reflect.MakeMapWithSize(field.Type(), field.Len()+dftField.Len())
+				fieldIter := field.MapRange()
+				for fieldIter.Next() {
+					merged.SetMapIndex(fieldIter.Key(), fieldIter.Value())
+				}
+			}
+			merged.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		if merged.IsValid() {
+			field.Set(merged)
+		}
+		return nil
 	}
 
+	// Do nothing if the value is set
+	if !field.IsZero() {
+		return nil
+	}
+
+	field.Set(deepCopy(dftField))
 	return nil
 }
 
+func isRecursible(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Struct:
+		return !isLeafType(field.Type())
+	case reflect.Ptr:
+		return !field.IsNil() && field.Elem().Kind() == reflect.Struct && !isLeafType(field.Elem().Type())
+	}
+	return false
+}
+
+func isNillable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
+}
+
+// Deep copy pointers, maps, slices, and struct fields, so that the defaults
+// and the values they were applied to do not share any state.
+func deepCopy(v reflect.Value) reflect.Value {
+	return deepCopyMemo(v, map[pointerKey]reflect.Value{})
+}
+
+// Identifies a pointer target. The type is needed, because a struct
+// and its first field share the same address.
+type pointerKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// NOTE: `memo` maps the already copied pointers to their copies, so that
+// recursive pointers are copied as recursive, instead of looping forever.
+func deepCopyMemo(v reflect.Value, memo map[pointerKey]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := pointerKey{ptr: v.Pointer(), typ: v.Type()}
+		if copy, ok := memo[key]; ok {
+			return copy
+		}
+		copy := reflect.New(v.Type().Elem())
+		memo[key] = copy
+		copy.Elem().Set(deepCopyMemo(v.Elem(), memo))
+		return copy
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copy := reflect.New(v.Type()).Elem()
+		copy.Set(deepCopyMemo(v.Elem(), memo))
+		return copy
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copy := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copy.SetMapIndex(iter.Key(), deepCopyMemo(iter.Value(), memo))
+		}
+		return copy
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copy := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copy.Index(i).Set(deepCopyMemo(v.Index(i), memo))
+		}
+		return copy
+	case reflect.Struct:
+		if copy, ok := deepCopyByMethod(v); ok {
+			return copy
+		}
+		// NOTE: Unexported fields cannot be set via reflection, so these are
+		// copied shallowly by the assignment below.
+		copy := reflect.New(v.Type()).Elem()
+		copy.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copy.Field(i).CanSet() {
+				copy.Field(i).Set(deepCopyMemo(v.Field(i), memo))
+			}
+		}
+		return copy
+	}
+	return v
+}
+
+// Use the type's own `DeepCopy() T` or `DeepCopy() *T` method if it has one,
+// as is the case for Kubernetes types.
+func deepCopyByMethod(v reflect.Value) (reflect.Value, bool) {
+	// NOTE: Most Kubernetes types define `DeepCopy` on the pointer receiver, so
+	// we look it up on a pointer to the value, which has both kinds of methods.
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	method := ptr.MethodByName("DeepCopy")
+	if !method.IsValid() {
+		return reflect.Value{}, false
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 {
+		return reflect.Value{}, false
+	}
+	switch methodType.Out(0) {
+	case v.Type():
+		return method.Call(nil)[0], true
+	case reflect.PointerTo(v.Type()):
+		copy := method.Call(nil)[0]
+		if copy.IsNil() {
+			return reflect.Value{}, false
+		}
+		return copy.Elem(), true
+	}
+	return reflect.Value{}, false
+}
+
 // Of is a helper routine that allocates a new any value
 // to store v and returns a pointer to it.
 // See https://github.com/xorcare/pointer
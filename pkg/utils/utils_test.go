@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	assert "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type TestStruct struct {
@@ -64,3 +69,408 @@ func TestApplyDefaultsNested(t *testing.T) {
 	assert.Equal(myStruct.Name, "")
 	assert.Equal(myStruct.NumOfEggs, 2.0)
 }
+
+type TestStructCollections struct {
+	Args       []string
+	Labels     map[string]string
+	Containers map[string]corev1.Container
+	Replicas   *int32
+	Enabled    *bool
+	Nested     *TestStruct
+}
+
+func TestApplyDefaultsSlice(t *testing.T) {
+	assert := assert.New(t)
+
+	defaults := TestStructCollections{Args: []string{"--verbose"}}
+
+	myStruct := TestStructCollections{}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal([]string{"--verbose"}, myStruct.Args)
+
+	// The default is copied, not shared
+	myStruct.Args[0] = "--quiet"
+	assert.Equal([]string{"--verbose"}, defaults.Args)
+
+	// Non-nil slices, even empty, are kept as is
+	myStruct = TestStructCollections{Args: []string{}}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal([]string{}, myStruct.Args)
+}
+
+func TestApplyDefaultsMap(t *testing.T) {
+	assert := assert.New(t)
+
+	defaults := TestStructCollections{
+		Labels: map[string]string{"app": "kuard", "tier": "web"},
+		Containers: map[string]corev1.Container{
+			"kuard": {Name: "kuard", Image: "gcr.io/kuar-demo/kuard-amd64:1"},
+			"proxy": {Name: "proxy", Image: "envoy"},
+		},
+	}
+
+	// Nil maps get the default
+	myStruct := TestStructCollections{}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(defaults.Labels, myStruct.Labels)
+	assert.Equal(defaults.Containers, myStruct.Containers)
+
+	myStruct.Labels["app"] = "changed"
+	assert.Equal("kuard", defaults.Labels["app"])
+
+	// Non-nil maps get the missing keys
+	myStruct = TestStructCollections{
+		Labels: map[string]string{"app": "other"},
+		Containers: map[string]corev1.Container{
+			"kuard": {Name: "kuard", Image: "kuard:2"},
+		},
+	}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(map[string]string{"app": "other", "tier": "web"}, myStruct.Labels)
+	assert.Equal("kuard:2", myStruct.Containers["kuard"].Image)
+	assert.Equal("envoy", myStruct.Containers["proxy"].Image)
+}
+
+func TestApplyDefaultsPointer(t *testing.T) {
+	assert := assert.New(t)
+
+	defaults := TestStructCollections{
+		Replicas: PointerOf[int32](3),
+		Enabled:  PointerOf(true),
+		Nested:   &TestStruct{Name: "John", NumOfEggs: 2},
+	}
+
+	// Nil pointers get a copy of the default
+	myStruct := TestStructCollections{}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(int32(3), *myStruct.Replicas)
+	assert.True(*myStruct.Enabled)
+	assert.Equal(TestStruct{Name: "John", NumOfEggs: 2}, *myStruct.Nested)
+	assert.NotSame(defaults.Replicas, myStruct.Replicas)
+	assert.NotSame(defaults.Nested, myStruct.Nested)
+
+	// Pointers to zero values are considered set
+	myStruct = TestStructCollections{
+		Replicas: PointerOf[int32](0),
+		Enabled:  PointerOf(false),
+		Nested:   &TestStruct{Name: "Jane"},
+	}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(int32(0), *myStruct.Replicas)
+	assert.False(*myStruct.Enabled)
+	// Pointers to structs are recursed into
+	assert.Equal(TestStruct{Name: "Jane", NumOfEggs: 2}, *myStruct.Nested)
+}
+
+func TestApplyDefaultsNilDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	myStruct := TestStructCollections{Nested: &TestStruct{Name: "Jane"}}
+	err := ApplyDefaults(&myStruct, TestStructCollections{})
+	assert.Nil(err)
+	assert.Nil(myStruct.Args)
+	assert.Nil(myStruct.Replicas)
+	assert.Equal(TestStruct{Name: "Jane"}, *myStruct.Nested)
+}
+
+type testStructUnexported struct {
+	Name   string
+	secret string
+}
+
+func TestApplyDefaultsUnexported(t *testing.T) {
+	assert := assert.New(t)
+
+	myStruct := testStructUnexported{}
+	err := ApplyDefaults(&myStruct, testStructUnexported{Name: "John", secret: "psst"})
+	assert.Nil(err)
+	assert.Equal("John", myStruct.Name)
+	assert.Equal("", myStruct.secret)
+
+	// Skipped fields are reported as debug notes
+	notes := []string{}
+	opts := DefaultsOptions{OnDebug: func(message string) { notes = append(notes, message) }}
+	myStruct = testStructUnexported{}
+	err = ApplyDefaultsWithOptions(&myStruct, testStructUnexported{Name: "John", secret: "psst"}, opts)
+	assert.Nil(err)
+	assert.Equal([]string{`skipped unexported field "secret"`}, notes)
+}
+
+func TestApplyDefaultsEmbedded(t *testing.T) {
+	assert := assert.New(t)
+
+	myStruct := TestStructNested{TestStruct: TestStruct{Name: "Jane"}}
+	defaults := TestStructNested{TestStruct: TestStruct{Name: "John", NumOfEggs: 3}, City: "Berlin"}
+
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal("Jane", myStruct.Name)
+	assert.Equal(3.0, myStruct.NumOfEggs)
+	assert.Equal("Berlin", myStruct.City)
+}
+
+func TestApplyDefaultsDifferentType(t *testing.T) {
+	assert := assert.New(t)
+
+	// Fields are matched by name, so defaults may be of a different type
+	type OtherStruct struct {
+		City    string
+		Unknown int
+	}
+	myStruct := TestStructNested{}
+	err := ApplyDefaults(&myStruct, OtherStruct{City: "Berlin", Unknown: 2})
+	assert.Nil(err)
+	assert.Equal("Berlin", myStruct.City)
+
+	// But fields with the same name must have assignable types
+	type MismatchedStruct struct {
+		City      string
+		NumOfEggs string
+	}
+	myStruct = TestStructNested{}
+	err = ApplyDefaults(&myStruct, struct {
+		TestStruct MismatchedStruct
+	}{TestStruct: MismatchedStruct{NumOfEggs: "two"}})
+	assert.ErrorIs(err, ErrTypeMismatch)
+	assert.Contains(err.Error(), `"TestStruct.NumOfEggs"`)
+}
+
+func TestApplyDefaultsNotPointer(t *testing.T) {
+	assert := assert.New(t)
+
+	err := ApplyDefaults(TestStruct{}, TestStruct{Name: "John"})
+	assert.ErrorIs(err, ErrNotPointer)
+}
+
+func TestApplyDefaultsLeafTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	type LeafStruct struct {
+		Timeout metav1.Duration
+		Memory  resource.Quantity
+		CPU     *resource.Quantity
+	}
+
+	defaults := LeafStruct{
+		Timeout: metav1.Duration{Duration: 30 * time.Second},
+		Memory:  resource.MustParse("500Mi"),
+		CPU:     PointerOf(resource.MustParse("250m")),
+	}
+
+	myStruct := LeafStruct{}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(30*time.Second, myStruct.Timeout.Duration)
+	assert.Equal("500Mi", myStruct.Memory.String())
+	assert.Equal("250m", myStruct.CPU.String())
+
+	// Set values are kept as they are, instead of being merged
+	myStruct = LeafStruct{
+		Timeout: metav1.Duration{Duration: time.Minute},
+		Memory:  resource.MustParse("1Gi"),
+		CPU:     PointerOf(resource.MustParse("1")),
+	}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(time.Minute, myStruct.Timeout.Duration)
+	assert.Equal("1Gi", myStruct.Memory.String())
+	assert.Equal("1", myStruct.CPU.String())
+}
+
+type testLeafStruct struct {
+	Value string
+	Unit  string
+}
+
+// NOTE: Unexported fields are copied only by `DeepCopy`
+type testDeepCopyable struct {
+	Name  string
+	items []string
+}
+
+func (in *testDeepCopyable) DeepCopy() *testDeepCopyable {
+	return &testDeepCopyable{Name: in.Name, items: append([]string{}, in.items...)}
+}
+
+func TestDeepCopyPointerReceiver(t *testing.T) {
+	assert := assert.New(t)
+
+	src := testDeepCopyable{Name: "a", items: []string{"x"}}
+	copy := deepCopy(reflect.ValueOf(src)).Interface().(testDeepCopyable)
+	src.items[0] = "y"
+	assert.Equal(testDeepCopyable{Name: "a", items: []string{"x"}}, copy)
+
+	// Also when the default is assigned to a nil pointer
+	type Wrapper struct {
+		Value *testDeepCopyable
+	}
+	defaults := Wrapper{Value: &testDeepCopyable{Name: "b", items: []string{"x"}}}
+	myStruct := Wrapper{}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	defaults.Value.items[0] = "y"
+	assert.Equal([]string{"x"}, myStruct.Value.items)
+}
+
+func TestApplyDefaultsRegisterLeafType(t *testing.T) {
+	assert := assert.New(t)
+
+	type Wrapper struct {
+		Size testLeafStruct
+	}
+	defaults := Wrapper{Size: testLeafStruct{Value: "10", Unit: "Gi"}}
+
+	// Without registration, the fields are merged one by one
+	myStruct := Wrapper{Size: testLeafStruct{Value: "5"}}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(testLeafStruct{Value: "5", Unit: "Gi"}, myStruct.Size)
+
+	RegisterLeafType(reflect.TypeOf(testLeafStruct{}))
+
+	myStruct = Wrapper{Size: testLeafStruct{Value: "5"}}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(testLeafStruct{Value: "5"}, myStruct.Size)
+
+	myStruct = Wrapper{}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.Equal(testLeafStruct{Value: "10", Unit: "Gi"}, myStruct.Size)
+}
+
+func TestApplyDefaultsExplicitZero(t *testing.T) {
+	assert := assert.New(t)
+
+	type ToggleStruct struct {
+		Enabled        bool
+		CertbotEnabled *bool
+		Replicas       *int32
+		Debug          bool `helpa:"keepzero"`
+	}
+	defaults := ToggleStruct{
+		Enabled:        true,
+		CertbotEnabled: PointerOf(true),
+		Replicas:       PointerOf(int32(3)),
+		Debug:          true,
+	}
+
+	myStruct := ToggleStruct{
+		CertbotEnabled: PointerOf(false),
+		Replicas:       PointerOf(int32(0)),
+	}
+	err := ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	// Plain scalars can't tell "false" from "unset"
+	assert.True(myStruct.Enabled)
+	// Pointers are tri-state, so explicit zeros survive
+	assert.False(*myStruct.CertbotEnabled)
+	assert.Equal(int32(0), *myStruct.Replicas)
+	// Fields marked with `keepzero` are never defaulted
+	assert.False(myStruct.Debug)
+
+	// Nil pointers are defaulted
+	myStruct = ToggleStruct{}
+	err = ApplyDefaults(&myStruct, defaults)
+	assert.Nil(err)
+	assert.True(*myStruct.CertbotEnabled)
+	assert.Equal(int32(3), *myStruct.Replicas)
+	assert.False(myStruct.Debug)
+}
+
+type testCycleNode struct {
+	Name  string
+	Port  int
+	Other *testCycleNode
+}
+
+func TestApplyDefaultsCycle(t *testing.T) {
+	assert := assert.New(t)
+
+	a := &testCycleNode{Name: "a"}
+	b := &testCycleNode{Name: "b", Other: a}
+	a.Other = b
+
+	defaults := testCycleNode{Port: 80, Other: &testCycleNode{Port: 8080, Other: &testCycleNode{Port: 9090}}}
+
+	err := ApplyDefaults(a, defaults)
+	assert.ErrorIs(err, ErrCycle)
+	assert.Contains(err.Error(), `"Other.Other"`)
+
+	a = &testCycleNode{Name: "a"}
+	b = &testCycleNode{Name: "b", Other: a}
+	a.Other = b
+	err = ApplyDefaultsWithOptions(a, defaults, DefaultsOptions{OnCycle: CycleSkip})
+	assert.Nil(err)
+	assert.Equal(80, a.Port)
+	// `b` is left as is, and `a` points to its defaulted copy instead
+	assert.Equal(8080, a.Other.Port)
+	assert.Same(a, a.Other.Other)
+	assert.Equal(0, b.Port)
+
+	// Pointers to the same struct in different fields are not a cycle
+	type Pair struct {
+		First  *testCycleNode
+		Second *testCycleNode
+	}
+	shared := &testCycleNode{Name: "shared"}
+	pair := Pair{First: shared, Second: shared}
+	err = ApplyDefaults(&pair, Pair{First: &testCycleNode{Port: 1}, Second: &testCycleNode{Port: 2}})
+	assert.Nil(err)
+	assert.Equal(1, pair.First.Port)
+	assert.Equal(2, pair.Second.Port)
+	assert.Equal(0, shared.Port)
+}
+
+func TestApplyDefaultsLeavesSharedValues(t *testing.T) {
+	assert := assert.New(t)
+
+	type Inner struct {
+		Name string
+		Port int
+	}
+	type Outer struct {
+		Labels map[string]string
+		Inner  *Inner
+	}
+
+	labels := map[string]string{"a": "mine"}
+	inner := &Inner{Name: "mine"}
+	outer := Outer{Labels: labels, Inner: inner}
+	err := ApplyDefaults(&outer, Outer{Labels: map[string]string{"b": "default"}, Inner: &Inner{Port: 80}})
+	assert.Nil(err)
+
+	assert.Equal(map[string]string{"a": "mine", "b": "default"}, outer.Labels)
+	assert.Equal(Inner{Name: "mine", Port: 80}, *outer.Inner)
+	assert.Equal(map[string]string{"a": "mine"}, labels)
+	assert.Equal(Inner{Name: "mine"}, *inner)
+}
+
+func TestApplyDefaultsDeep(t *testing.T) {
+	assert := assert.New(t)
+
+	makeChain := func(length int, port int) *testCycleNode {
+		var head *testCycleNode
+		for i := 0; i < length; i++ {
+			head = &testCycleNode{Port: port, Other: head}
+		}
+		return head
+	}
+
+	chain := makeChain(100, 0)
+	err := ApplyDefaults(chain, makeChain(100, 80))
+	assert.Nil(err)
+	for node := chain; node != nil; node = node.Other {
+		assert.Equal(80, node.Port)
+	}
+
+	chain = makeChain(100, 0)
+	err = ApplyDefaultsWithOptions(chain, makeChain(100, 80), DefaultsOptions{MaxDepth: 50})
+	assert.ErrorIs(err, ErrMaxDepth)
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	eris "github.com/rotisserie/eris"
+	yaml "sigs.k8s.io/yaml"
+)
+
+var (
+	ErrUnsupportedValuesFile = eris.New("values file must be YAML or JSON")
+	ErrUnknownValuesKeys     = eris.New("values file contains keys that are not in the input")
+)
+
+// Matches `${ENV_VAR}` and `${ENV_VAR:-default}`
+var envRefRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Load the input of a component from a YAML or JSON values file (by extension),
+// similar to Helm's `values.yaml`.
+//
+// References to environment variables in string values are expanded, e.g.
+// `${IMAGE_TAG}` or `${IMAGE_TAG:-latest}`. The default is used if the variable
+// is unset or empty.
+//
+// Decoding is strict - keys that don't match any field of `T` (by the `json`
+// struct tags) are reported as error, listing the key paths. Lastly, `defaults`
+// are applied with `ApplyDefaults`.
+//
+// NOTE: Env variables are expanded only in string values, so e.g. `replicas: ${REPLICAS}`
+// stays a string, and fails to decode into an int.
+func LoadValues[T any](path string, defaults T) (T, error) {
+	return LoadValuesMulti([]string{path}, defaults)
+}
+
+// Same as `LoadValues`, but loads multiple values files, e.g. `values.yaml`
+// and `values.prod.yaml`. Later files override the earlier ones. Maps are
+// merged key by key, other values (including lists) are replaced.
+func LoadValuesMulti[T any](paths []string, defaults T) (T, error) {
+	var result T
+
+	merged := map[string]any{}
+	for _, path := range paths {
+		values, err := readValuesFile(path)
+		if err != nil {
+			return result, err
+		}
+		merged = overlayValues(merged, expandEnvRefs(values).(map[string]any))
+	}
+
+	unknown := findUnknownKeys(reflect.TypeOf(result), merged, "")
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return result, eris.Wrapf(ErrUnknownValuesKeys, "unknown keys %s in %s", strings.Join(unknown, ", "), strings.Join(paths, ", "))
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return result, eris.Wrap(err, "failed to encode values")
+	}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return result, eris.Wrapf(err, "failed to decode values from %s", strings.Join(paths, ", "))
+	}
+
+	err = ApplyDefaults(&result, defaults)
+	return result, err
+}
+
+func readValuesFile(path string) (map[string]any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read values file %q", path)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		content, err = yaml.YAMLToJSON(content)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to parse values file %q", path)
+		}
+	case ".json":
+	default:
+		return nil, eris.Wrapf(ErrUnsupportedValuesFile, "got %q", path)
+	}
+
+	// NOTE: Keep numbers as they are, so large ints don't lose precision as floats
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var values map[string]any
+	err = decoder.Decode(&values)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to parse values file %q", path)
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+	return values, nil
+}
+
+func expandEnvRefs(value any) any {
+	switch val := value.(type) {
+	case string:
+		return envRefRegex.ReplaceAllStringFunc(val, func(ref string) string {
+			match := envRefRegex.FindStringSubmatch(ref)
+			envVal := os.Getenv(match[1])
+			if envVal == "" && match[2] != "" {
+				return match[3]
+			}
+			return envVal
+		})
+	case map[string]any:
+		for key, item := range val {
+			val[key] = expandEnvRefs(item)
+		}
+	case []any:
+		for index, item := range val {
+			val[index] = expandEnvRefs(item)
+		}
+	}
+	return value
+}
+
+func overlayValues(base map[string]any, overlay map[string]any) map[string]any {
+	for key, val := range overlay {
+		baseMap, baseOk := base[key].(map[string]any)
+		overlayMap, overlayOk := val.(map[string]any)
+		if baseOk && overlayOk {
+			base[key] = overlayValues(baseMap, overlayMap)
+		} else {
+			base[key] = val
+		}
+	}
+	return base
+}
+
+// Find keys in the decoded values that don't match any field of given type.
+func findUnknownKeys(t reflect.Type, value any, path string) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types that decode themselves (e.g. `resource.Quantity`) are not checked
+	if t == nil || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	unknown := []string{}
+	switch val := value.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := map[string]reflect.Type{}
+			collectJSONFields(t, fields)
+			for key, item := range val {
+				fieldType, ok := lookupJSONField(fields, key)
+				if !ok {
+					unknown = append(unknown, joinFieldPath(path, key))
+					continue
+				}
+				unknown = append(unknown, findUnknownKeys(fieldType, item, joinFieldPath(path, key))...)
+			}
+		case reflect.Map:
+			for key, item := range val {
+				unknown = append(unknown, findUnknownKeys(t.Elem(), item, joinFieldPath(path, key))...)
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for index, item := range val {
+				unknown = append(unknown, findUnknownKeys(t.Elem(), item, fmt.Sprintf("%s[%v]", path, index))...)
+			}
+		}
+	}
+	return unknown
+}
+
+// NOTE: Same as `encoding/json`, keys match the fields also case-insensitively,
+// so e.g. `replicas` matches field `Replicas` without a `json` tag.
+func lookupJSONField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if fieldType, ok := fields[key]; ok {
+		return fieldType, true
+	}
+	for name, fieldType := range fields {
+		if strings.EqualFold(name, key) {
+			return fieldType, true
+		}
+	}
+	return nil, false
+}
+
+// Collect the fields of a struct by their JSON keys, inlining embedded structs
+// the same way as `encoding/json` does.
+func collectJSONFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			collectJSONFields(fieldType, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+type valuesImage struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
+
+type valuesInput struct {
+	Name     string            `json:"name"`
+	Replicas int32             `json:"replicas"`
+	Image    valuesImage       `json:"image"`
+	Memory   resource.Quantity `json:"memory"`
+	Domains  []string          `json:"domains"`
+	Labels   map[string]string `json:"labels"`
+	Ports    []valuesPort      `json:"ports"`
+}
+
+type valuesPort struct {
+	Name string
+	Port int32
+}
+
+func writeValuesFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o644)
+	assert.Nil(t, err)
+	return path
+}
+
+func TestLoadValues(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("HELPA_TEST_TAG", "v2")
+	t.Setenv("HELPA_TEST_EMPTY", "")
+
+	path := writeValuesFile(t, "values.yaml", `
+name: kuard
+image:
+  repository: ${HELPA_TEST_REPO:-gcr.io/kuar-demo/kuard}
+  tag: ${HELPA_TEST_TAG}
+memory: 500Mi
+domains:
+  - ${HELPA_TEST_EMPTY:-example.com}
+  - www.${HELPA_TEST_UNSET}example.com
+ports:
+  - name: http
+    port: 8080
+`)
+
+	defaults := valuesInput{Replicas: 2, Labels: map[string]string{"app": "kuard"}}
+	values, err := LoadValues(path, defaults)
+	assert.Nil(err)
+	assert.Equal("kuard", values.Name)
+	assert.Equal(int32(2), values.Replicas)
+	assert.Equal("gcr.io/kuar-demo/kuard", values.Image.Repository)
+	assert.Equal("v2", values.Image.Tag)
+	assert.Equal(resource.MustParse("500Mi"), values.Memory)
+	assert.Equal([]string{"example.com", "www.example.com"}, values.Domains)
+	assert.Equal(map[string]string{"app": "kuard"}, values.Labels)
+	assert.Equal([]valuesPort{{Name: "http", Port: 8080}}, values.Ports)
+}
+
+func TestLoadValuesJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeValuesFile(t, "values.json", `{"name": "kuard", "replicas": 3}`)
+	values, err := LoadValues(path, valuesInput{})
+	assert.Nil(err)
+	assert.Equal("kuard", values.Name)
+	assert.Equal(int32(3), values.Replicas)
+
+	path = writeValuesFile(t, "values.toml", `name = "kuard"`)
+	_, err = LoadValues(path, valuesInput{})
+	assert.ErrorIs(err, ErrUnsupportedValuesFile)
+}
+
+func TestLoadValuesUnknownKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeValuesFile(t, "values.yaml", `
+name: kuard
+replica: 3
+image:
+  tags: latest
+ports:
+  - name: http
+    protocol: TCP
+labels:
+  anything: goes
+`)
+
+	_, err := LoadValues(path, valuesInput{})
+	assert.ErrorIs(err, ErrUnknownValuesKeys)
+	assert.Contains(err.Error(), "image.tags, ports[0].protocol, replica")
+}
+
+func TestLoadValuesMulti(t *testing.T) {
+	assert := assert.New(t)
+
+	base := writeValuesFile(t, "values.yaml", `
+name: kuard
+replicas: 1
+image:
+  repository: gcr.io/kuar-demo/kuard
+  tag: v1
+domains: [dev.example.com, www.dev.example.com]
+`)
+	prod := writeValuesFile(t, "values.prod.yaml", `
+replicas: 3
+image:
+  tag: v2
+domains: [example.com]
+`)
+
+	values, err := LoadValuesMulti([]string{base, prod}, valuesInput{})
+	assert.Nil(err)
+	assert.Equal("kuard", values.Name)
+	assert.Equal(int32(3), values.Replicas)
+	assert.Equal(valuesImage{Repository: "gcr.io/kuar-demo/kuard", Tag: "v2"}, values.Image)
+	assert.Equal([]string{"example.com"}, values.Domains)
+
+	// Reversed order, base wins
+	values, err = LoadValuesMulti([]string{prod, base}, valuesInput{})
+	assert.Nil(err)
+	assert.Equal(int32(1), values.Replicas)
+	assert.Equal("v1", values.Image.Tag)
+}